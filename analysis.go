@@ -0,0 +1,121 @@
+package chess
+
+import (
+	"sort"
+	"time"
+)
+
+// Line is one of the lines found by Analyze, with its principal variation in
+// both UCI and SAN
+type Line struct {
+	Score int
+	Depth int
+	Moves []Move
+	UCI   []string
+	SAN   []string
+}
+
+// Analyze finds the best multiPV lines from the current position using a
+// new single threaded Searcher. See Searcher.Analyze.
+func Analyze(chess *Chess, limits Limits, multiPV int, onUpdate func([]Line)) []Line {
+	return NewSearcher(DefaultHashSize).Analyze(chess, limits, multiPV, onUpdate)
+}
+
+// Analyze finds the best multiPV lines from the current position, best
+// first. Each depth searches the root once per line, leaving out the first
+// moves of the lines already found at that depth. onUpdate, if not nil, is
+// called with the lines each time a depth completes. The limits apply to the
+// whole analysis; when they run out the last completed depth is returned.
+func (searcher *Searcher) Analyze(chess *Chess, limits Limits, multiPV int, onUpdate func([]Line)) []Line {
+	var retVal []Line
+	start := time.Now()
+	var nodes int64
+
+	exhausted := func() bool {
+		return (limits.MoveTime > 0 && time.Since(start) >= limits.MoveTime) ||
+			(limits.Nodes > 0 && nodes >= limits.Nodes)
+	}
+
+	maxDepth := limits.Depth
+	if maxDepth <= 0 || maxDepth > maxPly {
+		maxDepth = maxPly
+	}
+	legalMoves := len(chess.Moves(true, ""))
+
+	stopped := false
+	for depth := 1; depth <= maxDepth && !stopped; depth++ {
+		var lines []Line
+		var excluded []Move
+		for len(lines) < multiPV && len(excluded) < legalMoves && !stopped {
+			depthLimits := Limits{Depth: depth}
+			if limits.MoveTime > 0 {
+				depthLimits.MoveTime = limits.MoveTime - time.Since(start)
+			}
+			if limits.Nodes > 0 {
+				depthLimits.Nodes = limits.Nodes - nodes
+			}
+			stopped = exhausted()
+			if !stopped {
+				result := searcher.search(chess, depthLimits, excluded)
+				nodes += result.Nodes
+				stopped = exhausted()
+				if !stopped {
+					lines = append(lines, chess.newLine(result))
+					excluded = append(excluded, result.BestMove)
+				}
+			}
+		}
+
+		// Only a completed depth replaces the lines from the one before
+		if !stopped && len(lines) > 0 {
+			sort.SliceStable(lines, func(i, j int) bool {
+				return lines[i].Score > lines[j].Score
+			})
+			retVal = lines
+			if onUpdate != nil {
+				onUpdate(retVal)
+			}
+		}
+	}
+	return retVal
+}
+
+// Mate returns how many moves the line's score says there are until mate,
+// negative if the side to move is the one mated, or 0 if it isn't a mate
+func (line Line) Mate() int {
+	retVal := 0
+	if line.Score > mateBound {
+		retVal = (mateScore - line.Score + 1) / 2
+	} else if line.Score < -mateBound {
+		retVal = -(mateScore + line.Score + 1) / 2
+	}
+	return retVal
+}
+
+func (chess *Chess) newLine(result SearchResult) Line {
+	retVal := Line{
+		Score: result.Score,
+		Depth: result.Depth,
+		Moves: result.PV,
+		SAN:   chess.lineToSAN(result.PV)}
+	for _, move := range result.PV {
+		retVal.UCI = append(retVal.UCI, move.UCI())
+	}
+	return retVal
+}
+
+// lineToSAN converts moves played one after the other from the current
+// position into SAN. The game itself isn't changed.
+func (chess *Chess) lineToSAN(moves []Move) []string {
+	return chess.lineToSANWithOptions(moves, SANOptions{})
+}
+
+func (chess *Chess) lineToSANWithOptions(moves []Move, options SANOptions) []string {
+	var retVal []string
+	copied := chess.Clone()
+	for _, move := range moves {
+		retVal = append(retVal, copied.moveToSANWithOptions(move, options))
+		copied.makeMove(move)
+	}
+	return retVal
+}
@@ -0,0 +1,84 @@
+package chess
+
+import "testing"
+
+func TestAnalyzeReturnsDistinctLinesBestFirst(t *testing.T) {
+	chess := New()
+	chess.Load("4k3/8/8/3q4/8/2N5/3R4/4K3 w - - 0 1")
+
+	var updates int
+	lines := Analyze(chess, Limits{Depth: 3}, 3, func(lines []Line) {
+		updates++
+	})
+	if len(lines) != 3 {
+		t.Fatalf("Expected 3 lines, got %d", len(lines))
+	}
+	if updates != 3 {
+		t.Errorf("Expected an update per depth, got %d", updates)
+	}
+
+	seen := make(map[string]bool)
+	for cntr, line := range lines {
+		if seen[line.UCI[0]] {
+			t.Errorf("Line %d repeats %s", cntr, line.UCI[0])
+		}
+		seen[line.UCI[0]] = true
+		if cntr > 0 && line.Score > lines[cntr-1].Score {
+			t.Errorf("Line %d scores %d, more than the line before", cntr, line.Score)
+		}
+		if line.Depth != 3 || len(line.SAN) != len(line.Moves) || len(line.UCI) != len(line.Moves) {
+			t.Errorf("Line %d is inconsistent: %v", cntr, line)
+		}
+	}
+	if lines[0].SAN[0] != "Nxd5" && lines[0].SAN[0] != "Rxd5" {
+		t.Errorf("Expected the queen to be taken, got %s", lines[0].SAN[0])
+	}
+}
+
+func TestAnalyzeStopsAtLegalMoveCount(t *testing.T) {
+	chess := New()
+	chess.Load("k7/8/1K6/8/8/8/8/7R b - - 0 1")
+
+	lines := Analyze(chess, Limits{Depth: 2}, 5, nil)
+	if len(lines) != len(chess.Moves(true, "")) {
+		t.Errorf("Expected %d lines, got %d", len(chess.Moves(true, "")), len(lines))
+	}
+}
+
+func TestLineToSAN(t *testing.T) {
+	chess := New()
+	e4, _ := chess.SANToMove("e4")
+	chess.makeMove(e4)
+	e5, _ := chess.SANToMove("e5")
+	chess.makeMove(e5)
+	nf3, _ := chess.SANToMove("Nf3")
+	chess.Undo()
+	chess.Undo()
+
+	san := chess.lineToSAN([]Move{e4, e5, nf3})
+	if len(san) != 3 || san[0] != "e4" || san[2] != "Nf3" {
+		t.Errorf("Expected e4 e5 Nf3, got %v", san)
+	}
+	if chess.GenerateFen() != defaultPosition {
+		t.Errorf("lineToSAN changed the game")
+	}
+}
+
+func TestLineMate(t *testing.T) {
+	tests := []struct {
+		score    int
+		expected int
+	}{
+		{mateScore - 1, 1},
+		{mateScore - 3, 2},
+		{-mateScore + 2, -1},
+		{-mateScore + 4, -2},
+		{150, 0},
+		{-150, 0},
+	}
+	for _, test := range tests {
+		if mate := (Line{Score: test.score}).Mate(); mate != test.expected {
+			t.Errorf("Expected %d for %d, got %d", test.expected, test.score, mate)
+		}
+	}
+}
@@ -0,0 +1,232 @@
+package chess
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+)
+
+// BookOptions controls which games and moves go into a book built by BookBuilder
+type BookOptions struct {
+	// MaxPly is how many plies of each game are recorded. Zero means 20.
+	MaxPly int
+	// MinOccurrences leaves out moves played fewer times than this
+	MinOccurrences int
+	// MinRating skips games unless both WhiteElo and BlackElo are at least this
+	MinRating int
+}
+
+// MoveStats counts how a move turned out for the side that played it
+type MoveStats struct {
+	Count  int
+	Wins   int
+	Draws  int
+	Losses int
+}
+
+// BookBuilder collects move statistics from games and turns them into a Polyglot book
+type BookBuilder struct {
+	options   BookOptions
+	positions map[uint64]map[uint16]*MoveStats
+	games     int
+}
+
+const defaultBookPly = 20
+
+// NewBookBuilder creates an empty BookBuilder
+func NewBookBuilder(options BookOptions) *BookBuilder {
+	if options.MaxPly <= 0 {
+		options.MaxPly = defaultBookPly
+	}
+	return &BookBuilder{options: options, positions: make(map[uint64]map[uint16]*MoveStats)}
+}
+
+// Games returns how many games have been added to the book
+func (builder *BookBuilder) Games() int {
+	return builder.games
+}
+
+// AddPGN adds every game read from reader. Games that are filtered out or
+// contain illegal moves are skipped; the number of games added is returned.
+func (builder *BookBuilder) AddPGN(reader io.Reader) (int, error) {
+	added := 0
+	pgnReader := NewPGNReader(reader)
+	game, err := pgnReader.Next()
+	for err == nil {
+		if builder.AddGame(game) == nil {
+			added++
+		}
+		game, err = pgnReader.Next()
+	}
+	if err == io.EOF {
+		err = nil
+	}
+	return added, err
+}
+
+// AddGame records the moves of a single game, replaying them with
+// SANToMove. Nothing is recorded if the game is filtered out or any of its
+// first MaxPly moves is illegal.
+func (builder *BookBuilder) AddGame(game *PGNGame) error {
+	err := builder.accept(game)
+	var chess *Chess
+	if err == nil {
+		chess, err = game.start()
+	}
+
+	type record struct {
+		key  uint64
+		move uint16
+		turn PieceColor
+	}
+	var records []record
+	for cntr := 0; err == nil && cntr < len(game.Moves) && cntr < builder.options.MaxPly; cntr++ {
+		var move Move
+		move, err = chess.SANToMove(game.Moves[cntr])
+		if err == nil {
+			records = append(records, record{chess.PolyglotKey(), moveToPolyglot(move), chess.turn})
+			chess.makeMove(move)
+		}
+	}
+
+	if err == nil {
+		for _, rec := range records {
+			moves, ok := builder.positions[rec.key]
+			if !ok {
+				moves = make(map[uint16]*MoveStats)
+				builder.positions[rec.key] = moves
+			}
+			stats, ok := moves[rec.move]
+			if !ok {
+				stats = new(MoveStats)
+				moves[rec.move] = stats
+			}
+			stats.add(game.Result, rec.turn)
+		}
+		builder.games++
+	}
+	return err
+}
+
+// Stats returns the statistics recorded for each move played from the
+// current position of chess, keyed by the move's UCI
+func (builder *BookBuilder) Stats(chess *Chess) map[string]MoveStats {
+	retVal := make(map[string]MoveStats)
+	for encoded, stats := range builder.positions[chess.PolyglotKey()] {
+		if move, err := chess.polyglotToMove(encoded); err == nil {
+			retVal[move.UCI()] = *stats
+		}
+	}
+	return retVal
+}
+
+// Entries returns the book as Polyglot entries sorted by key, best move
+// first. Weights are two points per win and one per draw, scaled down when
+// they'd overflow. Moves that scored nothing or were played too rarely are
+// left out.
+func (builder *BookBuilder) Entries() []PolyglotEntry {
+	var retVal []PolyglotEntry
+	for key, moves := range builder.positions {
+		var positionEntries []PolyglotEntry
+		maxScore := 0
+		for encoded, stats := range moves {
+			score := stats.score()
+			if stats.Count < builder.options.MinOccurrences || score == 0 {
+				continue
+			}
+			if score > maxScore {
+				maxScore = score
+			}
+			positionEntries = append(positionEntries, PolyglotEntry{Key: key, Move: encoded})
+		}
+		for cntr := range positionEntries {
+			score := moves[positionEntries[cntr].Move].score()
+			if maxScore > 0xffff {
+				score = score * 0xffff / maxScore
+				if score == 0 {
+					score = 1
+				}
+			}
+			positionEntries[cntr].Weight = uint16(score)
+		}
+		retVal = append(retVal, positionEntries...)
+	}
+	sort.Slice(retVal, func(i, j int) bool {
+		if retVal[i].Key != retVal[j].Key {
+			return retVal[i].Key < retVal[j].Key
+		}
+		if retVal[i].Weight != retVal[j].Weight {
+			return retVal[i].Weight > retVal[j].Weight
+		}
+		return retVal[i].Move < retVal[j].Move
+	})
+	return retVal
+}
+
+// WritePolyglot writes the book in Polyglot .bin format
+func (builder *BookBuilder) WritePolyglot(writer io.Writer) error {
+	return WritePolyglotEntries(writer, builder.Entries())
+}
+
+// WritePolyglotEntries writes entries in Polyglot .bin format. They should already be sorted by key.
+func WritePolyglotEntries(writer io.Writer, entries []PolyglotEntry) error {
+	var err error
+	record := make([]byte, polyglotEntrySize)
+	for cntr := 0; err == nil && cntr < len(entries); cntr++ {
+		binary.BigEndian.PutUint64(record[0:8], entries[cntr].Key)
+		binary.BigEndian.PutUint16(record[8:10], entries[cntr].Move)
+		binary.BigEndian.PutUint16(record[10:12], entries[cntr].Weight)
+		binary.BigEndian.PutUint32(record[12:16], entries[cntr].Learn)
+		_, err = writer.Write(record)
+	}
+	return err
+}
+
+func (builder *BookBuilder) accept(game *PGNGame) error {
+	var err error
+	if builder.options.MinRating > 0 {
+		for _, tag := range []string{"WhiteElo", "BlackElo"} {
+			rating, convErr := strconv.Atoi(game.Tags[tag])
+			if err == nil && (convErr != nil || rating < builder.options.MinRating) {
+				err = fmt.Errorf("%s '%s' is below the minimum rating %d", tag, game.Tags[tag], builder.options.MinRating)
+			}
+		}
+	}
+	return err
+}
+
+func (stats *MoveStats) add(result string, turn PieceColor) {
+	stats.Count++
+	switch {
+	case result == "1/2-1/2":
+		stats.Draws++
+	case (result == "1-0" && turn == white) || (result == "0-1" && turn == black):
+		stats.Wins++
+	case result == "1-0" || result == "0-1":
+		stats.Losses++
+	}
+}
+
+func (stats *MoveStats) score() int {
+	return 2*stats.Wins + stats.Draws
+}
+
+// moveToPolyglot encodes a move the way Polyglot books do, with castling as the king taking its own rook
+func moveToPolyglot(move Move) uint16 {
+	to := move.to
+	if move.flags&ksideCastleMove != 0 {
+		to = move.to + 1
+	} else if move.flags&qsideCastleMove != 0 {
+		to = move.to - 2
+	}
+	retVal := uint16(file(to)) |
+		uint16(7-rank(to))<<3 |
+		uint16(file(move.from))<<6 |
+		uint16(7-rank(move.from))<<9
+	if move.flags&promotionMove != 0 {
+		retVal |= uint16(promotionCodes[move.promotedType]) << 12
+	}
+	return retVal
+}
@@ -0,0 +1,111 @@
+package chess
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+const bookGames = `[White "A"]
+[Black "B"]
+[WhiteElo "2400"]
+[BlackElo "2300"]
+[Result "1-0"]
+
+1. e4 e5 2. Nf3 1-0
+
+[White "C"]
+[Black "D"]
+[WhiteElo "2500"]
+[BlackElo "2450"]
+[Result "1/2-1/2"]
+
+1. e4 c5 2. Nf3 1/2-1/2
+
+[White "E"]
+[Black "F"]
+[WhiteElo "1500"]
+[BlackElo "1400"]
+[Result "0-1"]
+
+1. d4 d5 0-1
+`
+
+func TestBookBuilderRecordsStats(t *testing.T) {
+	builder := NewBookBuilder(BookOptions{MaxPly: 2})
+	added, err := builder.AddPGN(strings.NewReader(bookGames))
+	if err != nil || added != 3 {
+		t.Fatalf("Expected 3 games, got %d, %v", added, err)
+	}
+
+	stats := builder.Stats(New())
+	if stats["e2e4"] != (MoveStats{Count: 2, Wins: 1, Draws: 1}) {
+		t.Errorf("Unexpected e4 stats %v", stats["e2e4"])
+	}
+	if stats["d2d4"] != (MoveStats{Count: 1, Losses: 1}) {
+		t.Errorf("Unexpected d4 stats %v", stats["d2d4"])
+	}
+
+	chess := New()
+	chess.Move("e4")
+	chess.Move("e5")
+	if len(builder.Stats(chess)) != 0 {
+		t.Errorf("Expected nothing recorded past MaxPly")
+	}
+}
+
+func TestBookBuilderFiltersByRating(t *testing.T) {
+	builder := NewBookBuilder(BookOptions{MinRating: 2000})
+	added, _ := builder.AddPGN(strings.NewReader(bookGames))
+	if added != 2 {
+		t.Errorf("Expected 2 games, got %d", added)
+	}
+	if _, ok := builder.Stats(New())["d2d4"]; ok {
+		t.Errorf("Expected the low rated game to be skipped")
+	}
+}
+
+func TestBookBuilderWritesReadableBook(t *testing.T) {
+	builder := NewBookBuilder(BookOptions{})
+	builder.AddPGN(strings.NewReader(bookGames + "\n[Result \"1-0\"]\n\n1. O-O 1-0\n"))
+	// Castling is written the Polyglot way and reads back
+	builder.AddGame(&PGNGame{
+		Tags:   map[string]string{"FEN": "4k3/8/8/8/8/8/8/4K2R w K - 0 1"},
+		Moves:  []string{"O-O"},
+		Result: "1-0"})
+
+	var buffer bytes.Buffer
+	if err := builder.WritePolyglot(&buffer); err != nil {
+		t.Fatalf("Got an error %v", err)
+	}
+	book, err := ReadPolyglotBook(&buffer)
+	if err != nil {
+		t.Fatalf("Got an error %v", err)
+	}
+
+	moves := book.Moves(New())
+	// d4 lost its only game so it scores nothing and is left out
+	if len(moves) != 1 || moves[0].Move.UCI() != "e2e4" || moves[0].Weight != 3 {
+		t.Errorf("Unexpected book moves %v", moves)
+	}
+
+	chess := New()
+	chess.Load("4k3/8/8/8/8/8/8/4K2R w K - 0 1")
+	moves = book.Moves(chess)
+	if len(moves) != 1 || chess.SAN(moves[0].Move) != "O-O" {
+		t.Errorf("Expected O-O from the book, got %v", moves)
+	}
+}
+
+func TestBookBuilderMinOccurrences(t *testing.T) {
+	builder := NewBookBuilder(BookOptions{MinOccurrences: 2})
+	builder.AddPGN(strings.NewReader(bookGames))
+	for _, entry := range builder.Entries() {
+		if entry.Key != New().PolyglotKey() {
+			t.Errorf("Expected only the start position, which has e4 twice")
+		}
+	}
+	if len(builder.Entries()) != 1 {
+		t.Errorf("Expected 1 entry, got %d", len(builder.Entries()))
+	}
+}
@@ -171,7 +171,7 @@ type Chess struct {
 	kings           kingsLocation
 	history         Stack
 	header          map[string]string
-	positionToCount map[string]int
+	positionToCount map[uint64]int
 }
 
 // New creates a new Chess instance initialized to the starting/default chess position
@@ -196,6 +196,62 @@ func (chess *Chess) Move(san string) error {
 	return err
 }
 
+// MakeMove makes the given move, which should come from Moves or one of the move parsers
+func (chess *Chess) MakeMove(move Move) {
+	chess.makeMove(move)
+}
+
+// UCIToMove converts a move in UCI long algebraic form (e2e4, e7e8q) into a Move, if it's a legal move, or returns an error
+func (chess *Chess) UCIToMove(uci string) (Move, error) {
+	var err error
+	var retVal Move
+
+	if len(uci) == 4 || len(uci) == 5 {
+		for _, move := range chess.Moves(true, uci[0:2]) {
+			if move.UCI() == uci {
+				retVal = move
+				break
+			}
+		}
+	}
+	if retVal.ptype == 0 {
+		err = fmt.Errorf("%s not a legal move", uci)
+	}
+	return retVal, err
+}
+
+// SAN returns the SAN encoding of the given move in the current position,
+// with + or # if it checks or mates
+func (chess *Chess) SAN(move Move) string {
+	return chess.moveToSAN(move)
+}
+
+// SANWithOptions returns the SAN encoding of the given move in the current
+// position, written as the options say
+func (chess *Chess) SANWithOptions(move Move, options SANOptions) string {
+	return chess.moveToSANWithOptions(move, options)
+}
+
+// History returns the moves made since the position was set up, oldest first
+func (chess *Chess) History() []Move {
+	retVal := make([]Move, chess.history.Len())
+	cntr := len(retVal) - 1
+	for curr := chess.history.top; curr != nil; curr = curr.next {
+		retVal[cntr] = curr.value.(historyEntry).move
+		cntr--
+	}
+	return retVal
+}
+
+// InitialFen returns the FEN of the position the move history starts from
+func (chess *Chess) InitialFen() string {
+	retVal := defaultPosition
+	if fen, ok := chess.header["FEN"]; ok {
+		retVal = fen
+	}
+	return retVal
+}
+
 // Get returns the Piece at the given square or an unspecified Piece if the square is unoccupied
 func (chess *Chess) Get(squareID string) Piece {
 	var retVal Piece
@@ -266,6 +322,7 @@ func (chess *Chess) Load(fenToLoad string) error {
 		}
 		chess.halfMoves = fen.halfMoves
 		chess.moveNumber = fen.fullMoves
+		chess.updateSetup(chess.GenerateFen())
 	}
 	return err
 }
@@ -314,6 +371,33 @@ func (chess *Chess) GenerateFen() string {
 	return retVal.String()
 }
 
+// ASCII returns a diagram of the board with white at the bottom, white
+// pieces in upper case and black in lower case
+func (chess *Chess) ASCII() string {
+	var retVal strings.Builder
+	retVal.WriteString("   +------------------------+\n")
+	for cntr := squareNameToID["a8"]; cntr <= squareNameToID["h1"]; cntr++ {
+		if file(cntr) == 0 {
+			retVal.WriteString(" " + "87654321"[rank(cntr):rank(cntr)+1] + " |")
+		}
+		symbol := '.'
+		if piece := chess.board[cntr]; !piece.IsUnspecified() {
+			symbol = rune(piece.ptype)
+			if piece.pcolor == white {
+				symbol = unicode.ToUpper(symbol)
+			}
+		}
+		retVal.WriteString(" " + string(symbol) + " ")
+		if ((cntr + 1) & 0x88) != 0 {
+			retVal.WriteString("|\n")
+			cntr += 8
+		}
+	}
+	retVal.WriteString("   +------------------------+\n")
+	retVal.WriteString("     a  b  c  d  e  f  g  h\n")
+	return retVal.String()
+}
+
 // Clear sets the Chess instance to the starting position
 func (chess *Chess) Clear() {
 	chess.board = make([]Piece, 128)
@@ -326,7 +410,8 @@ func (chess *Chess) Clear() {
 	chess.kings[black] = emptySquare
 	chess.kings[white] = emptySquare
 	chess.header = make(map[string]string)
-	chess.positionToCount = make(map[string]int)
+	chess.positionToCount = make(map[uint64]int)
+	chess.history = Stack{}
 }
 
 // Undo takes the most recently pushed history item and undoes it's effects
@@ -355,6 +440,10 @@ func (chess *Chess) Moves(legalMoves bool, singleSquareName string) []Move {
 	firstSquare, lastSquare, err := chess.determineSquareRange(singleSquareName)
 	if err == nil {
 		var allMoves []Move
+		var legality *legality
+		if legalMoves {
+			legality = chess.newLegality()
+		}
 		for cntr := firstSquare; cntr <= lastSquare; cntr++ {
 			if cntr&0x88 != 0 {
 				cntr += 7
@@ -364,6 +453,9 @@ func (chess *Chess) Moves(legalMoves bool, singleSquareName string) []Move {
 			if currPiece.IsUnspecified() || currPiece.pcolor != ourColor {
 				continue
 			}
+			if legalMoves && legality.doubleCheck() && currPiece.ptype != king {
+				continue
+			}
 			if currPiece.ptype == pawn {
 				// Pawn moves...
 				allMoves = append(allMoves, chess.getPawnMoves(cntr, ourColor)...)
@@ -380,11 +472,9 @@ func (chess *Chess) Moves(legalMoves bool, singleSquareName string) []Move {
 
 		if legalMoves {
 			for _, move := range allMoves {
-				chess.makeMove(move)
-				if !chess.kingAttacked(ourColor) {
+				if legality.legal(chess, move) {
 					retVal = append(retVal, move)
 				}
-				chess.Undo()
 			}
 		} else {
 			retVal = allMoves
@@ -398,12 +488,55 @@ func (chess *Chess) Turn() PieceColor {
 	return chess.turn
 }
 
+// UCI returns the move in the long algebraic form used by the UCI protocol, such as e2e4 or e7e8q
+func (move Move) UCI() string {
+	retVal := algebraic(move.from) + algebraic(move.to)
+	if move.flags&promotionMove != 0 {
+		retVal += string(rune(move.promotedType))
+	}
+	return retVal
+}
+
 // IsUnspecified returns true if this the piece given is doesn't have a type or a color
 func (p *Piece) IsUnspecified() bool {
 	retVal := p.pcolor == 0 || p.ptype == 0
 	return (retVal)
 }
 
+// Clone returns a deep copy of the game, including its history and headers,
+// that can be changed without affecting the original. A Chess isn't safe to
+// use from more than one goroutine at a time, so each should have its own
+// Clone.
+func (chess *Chess) Clone() *Chess {
+	retVal := new(Chess)
+	*retVal = *chess
+	retVal.board = append([]Piece(nil), chess.board...)
+	retVal.castling = castlingState{white: chess.castling[white], black: chess.castling[black]}
+	retVal.kings = kingsLocation{white: chess.kings[white], black: chess.kings[black]}
+	retVal.header = make(map[string]string)
+	for key, value := range chess.header {
+		retVal.header[key] = value
+	}
+	retVal.positionToCount = make(map[uint64]int)
+	for key, value := range chess.positionToCount {
+		retVal.positionToCount[key] = value
+	}
+
+	// The entries hold maps that Undo hands back to the game, so each copy needs its own
+	var entries []historyEntry
+	for curr := chess.history.top; curr != nil; curr = curr.next {
+		entries = append(entries, curr.value.(historyEntry))
+	}
+	retVal.history = Stack{}
+	for cntr := len(entries) - 1; cntr >= 0; cntr-- {
+		entry := entries[cntr]
+		entry.kings = kingsLocation{white: entry.kings[white], black: entry.kings[black]}
+		entry.castling = castlingState{white: entry.castling[white], black: entry.castling[black]}
+		retVal.history.Push(entry)
+	}
+	return retVal
+}
+
 func (chess *Chess) maybeUpdateKings(piece Piece, squareID int) error {
 
 	var retVal error
@@ -428,9 +561,7 @@ func (chess *Chess) maybeUpdateKings(piece Piece, squareID int) error {
 }
 
 func (chess *Chess) removeFromPositionCount() {
-	fen := chess.GenerateFen()
-	fen = fen[:len(fen)-4]
-	chess.positionToCount[fen]--
+	chess.positionToCount[chess.hash()]--
 }
 
 func (chess *Chess) undoCastling(move Move) {
@@ -617,9 +748,7 @@ func (chess *Chess) makeMove(moveToMake Move) {
 }
 
 func (chess *Chess) addToPositionCount() {
-	fen := chess.GenerateFen()
-	fen = fen[:len(fen)-4]
-	chess.positionToCount[fen]++
+	chess.positionToCount[chess.hash()]++
 }
 
 func (chess *Chess) updateMoveCounters(move Move) {
@@ -780,47 +909,67 @@ func (chess *Chess) attacked(colorAttacking PieceColor, squareNumAttacked int) b
 		if chess.board[cntr].IsUnspecified() || chess.board[cntr].pcolor != colorAttacking {
 			continue
 		}
-		piece := chess.board[cntr]
-		difference := cntr - squareNumAttacked
-		index := difference + 119
-
-		if (attacks[index] & (1 << shifts[piece.ptype])) != 0 {
-			if piece.ptype == pawn {
-				if difference > 0 {
-					if piece.pcolor == white {
-						retVal = true
-						break
-					}
-				} else {
-					if piece.pcolor == black {
-						retVal = true
-						break
-					}
-				}
-				continue
-			}
-			if piece.ptype == knight || piece.ptype == king {
-				retVal = true
-				break
-			}
+		retVal = chess.attacks(cntr, squareNumAttacked)
+	}
+
+	return retVal
+}
+
+// attackers returns the squares of the pieces of colorAttacking that attack the square
+func (chess *Chess) attackers(colorAttacking PieceColor, squareNumAttacked int) []int {
+	var retVal []int
+	for cntr := squareNameToID["a8"]; cntr <= squareNameToID["h1"]; cntr++ {
+		if cntr&0x88 != 0 {
+			cntr += 7
+			continue
+		}
+		if !chess.board[cntr].IsUnspecified() && chess.board[cntr].pcolor == colorAttacking && chess.attacks(cntr, squareNumAttacked) {
+			retVal = append(retVal, cntr)
+		}
+	}
+	return retVal
+}
+
+// attacks returns true if the piece on square attacks squareNumAttacked
+func (chess *Chess) attacks(square int, squareNumAttacked int) bool {
+	return chess.attacksAfter(square, squareNumAttacked, emptySquare, emptySquare, emptySquare)
+}
+
+// attacksAfter returns true if the piece on square would attack
+// squareNumAttacked with vacated and alsoVacated empty and filled occupied
+func (chess *Chess) attacksAfter(square int, squareNumAttacked int, vacated int, alsoVacated int, filled int) bool {
+	return chess.pieceAttacksAfter(chess.board[square], square, squareNumAttacked, vacated, alsoVacated, filled)
+}
+
+// pieceAttacksAfter is attacksAfter for a piece that isn't on square yet
+func (chess *Chess) pieceAttacksAfter(piece Piece, square int, squareNumAttacked int, vacated int, alsoVacated int, filled int) bool {
+	retVal := false
+	difference := square - squareNumAttacked
+	index := difference + 119
 
+	if (attacks[index] & (1 << shifts[piece.ptype])) != 0 {
+		if piece.ptype == pawn {
+			if difference > 0 {
+				retVal = piece.pcolor == white
+			} else {
+				retVal = piece.pcolor == black
+			}
+		} else if piece.ptype == knight || piece.ptype == king {
+			retVal = true
+		} else {
 			offset := rays[index]
-			j := cntr + offset
+			j := square + offset
 			blocked := false
 			for j != squareNumAttacked {
-				if !chess.board[j].IsUnspecified() {
+				if j == filled || (j != vacated && j != alsoVacated && !chess.board[j].IsUnspecified()) {
 					blocked = true
 					break
 				}
 				j += offset
 			}
-			if !blocked {
-				retVal = true
-				break
-			}
+			retVal = !blocked
 		}
 	}
-
 	return retVal
 }
 
@@ -917,6 +1066,41 @@ func (chess *Chess) InThreefoldRepition() bool {
 	return retVal
 }
 
+// Outcome is how a game ended. Result is "1-0", "0-1", "1/2-1/2", or "*"
+// while the game is still going. Reason says why, such as "checkmate",
+// "stalemate", "insufficient material", "threefold repetition" or "fifty
+// move rule".
+type Outcome struct {
+	Result string
+	Reason string
+}
+
+// Over returns true if the game has ended
+func (outcome Outcome) Over() bool {
+	return outcome.Result != "*"
+}
+
+// Outcome returns how the game ended, if it has
+func (chess *Chess) Outcome() Outcome {
+	retVal := Outcome{Result: "*"}
+	switch {
+	case chess.InCheckmate():
+		retVal = Outcome{Result: "1-0", Reason: "checkmate"}
+		if chess.turn == white {
+			retVal.Result = "0-1"
+		}
+	case chess.InStalemate():
+		retVal = Outcome{Result: "1/2-1/2", Reason: "stalemate"}
+	case chess.InsufficientMaterial():
+		retVal = Outcome{Result: "1/2-1/2", Reason: "insufficient material"}
+	case chess.InThreefoldRepition():
+		retVal = Outcome{Result: "1/2-1/2", Reason: "threefold repetition"}
+	case chess.halfMoves >= 100:
+		retVal = Outcome{Result: "1/2-1/2", Reason: "fifty move rule"}
+	}
+	return retVal
+}
+
 func (chess *Chess) determineSquareRange(singleSquareName string) (int, int, error) {
 	var err error
 	firstSquare := emptySquare
@@ -908,3 +908,128 @@ func TestAlgebraicWorks(t *testing.T) {
 		}
 	}
 }
+
+func TestMoveUCI(t *testing.T) {
+	var move Move
+	move.from = squareNameToID["e7"]
+	move.to = squareNameToID["e8"]
+	move.flags = promotionMove
+	move.promotedType = knight
+	if move.UCI() != "e7e8n" {
+		t.Errorf("Expected e7e8n, got %s", move.UCI())
+	}
+}
+
+func TestUCIToMove(t *testing.T) {
+	chess := New()
+	move, err := chess.UCIToMove("g1f3")
+	if err != nil {
+		t.Errorf("Got an error %v", err)
+	} else if move.ptype != knight || algebraic(move.to) != "f3" {
+		t.Errorf("Expected knight to f3, got %v", move)
+	}
+
+	if _, err = chess.UCIToMove("e2e5"); err == nil {
+		t.Errorf("Expected an error for an illegal move")
+	}
+	if _, err = chess.UCIToMove("e2"); err == nil {
+		t.Errorf("Expected an error for a malformed move")
+	}
+}
+
+func TestHistoryIsOldestFirst(t *testing.T) {
+	chess := New()
+	chess.Move("e4")
+	chess.Move("e5")
+	chess.Move("Nf3")
+
+	history := chess.History()
+	if len(history) != 3 {
+		t.Fatalf("Expected 3 moves, got %d", len(history))
+	}
+	if history[0].UCI() != "e2e4" || history[2].UCI() != "g1f3" {
+		t.Errorf("History out of order, got %v", history)
+	}
+}
+
+func TestInitialFen(t *testing.T) {
+	chess := New()
+	chess.Move("e4")
+	if chess.InitialFen() != defaultPosition {
+		t.Errorf("Expected default position, got '%s'", chess.InitialFen())
+	}
+
+	fen := "4k3/8/8/8/8/8/4P3/4K3 w - - 0 1"
+	chess.Load(fen)
+	chess.Move("e4")
+	if chess.InitialFen() != fen {
+		t.Errorf("Expected %s, got '%s'", fen, chess.InitialFen())
+	}
+}
+
+func TestCloneIsIndependent(t *testing.T) {
+	chess := New()
+	chess.Move("e4")
+	copied := chess.Clone()
+	copied.Move("e5")
+	copied.Undo()
+	copied.Undo()
+	copied.Move("d4")
+
+	if chess.GenerateFen() != "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1" {
+		t.Errorf("Original changed to %s", chess.GenerateFen())
+	}
+	chess.Undo()
+	if chess.GenerateFen() != defaultPosition {
+		t.Errorf("Original history changed, got %s", chess.GenerateFen())
+	}
+}
+
+func TestOutcome(t *testing.T) {
+	tests := []struct {
+		fen     string
+		outcome Outcome
+	}{
+		{defaultPosition, Outcome{"*", ""}},
+		{"k7/1Q6/1K6/8/8/8/8/8 b - - 0 1", Outcome{"1-0", "checkmate"}},
+		{"8/8/8/8/8/1k6/1q6/K7 w - - 0 1", Outcome{"0-1", "checkmate"}},
+		{"k7/8/1Q6/8/8/8/8/7K b - - 0 1", Outcome{"1/2-1/2", "stalemate"}},
+		{"k7/8/8/8/8/8/8/6NK w - - 0 1", Outcome{"1/2-1/2", "insufficient material"}},
+		{"k7/8/8/8/8/8/8/6RK w - - 100 80", Outcome{"1/2-1/2", "fifty move rule"}},
+	}
+	for _, test := range tests {
+		chess := New()
+		chess.Load(test.fen)
+		if outcome := chess.Outcome(); outcome != test.outcome || outcome.Over() != (test.outcome.Result != "*") {
+			t.Errorf("Expected %v for %s, got %v", test.outcome, test.fen, outcome)
+		}
+	}
+
+	chess := New()
+	for _, move := range []string{"Nf3", "Nf6", "Ng1", "Ng8", "Nf3", "Nf6", "Ng1", "Ng8", "Nf3"} {
+		chess.Move(move)
+	}
+	if chess.Outcome() != (Outcome{"1/2-1/2", "threefold repetition"}) {
+		t.Errorf("Expected threefold repetition, got %v", chess.Outcome())
+	}
+}
+
+func TestASCII(t *testing.T) {
+	chess := New()
+	chess.Move("e4")
+	expected := `   +------------------------+
+ 8 | r  n  b  q  k  b  n  r |
+ 7 | p  p  p  p  p  p  p  p |
+ 6 | .  .  .  .  .  .  .  . |
+ 5 | .  .  .  .  .  .  .  . |
+ 4 | .  .  .  .  P  .  .  . |
+ 3 | .  .  .  .  .  .  .  . |
+ 2 | P  P  P  P  .  P  P  P |
+ 1 | R  N  B  Q  K  B  N  R |
+   +------------------------+
+     a  b  c  d  e  f  g  h
+`
+	if ascii := chess.ASCII(); ascii != expected {
+		t.Errorf("Unexpected diagram\n%s", ascii)
+	}
+}
@@ -0,0 +1,329 @@
+package chess
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Bonus is how a player gets time back for each move
+type Bonus int
+
+const (
+	// NoBonus is sudden death: the stage's time is all there is
+	NoBonus Bonus = iota
+	// Fischer adds the bonus time after every move
+	Fischer
+	// Bronstein gives back the time used on a move, up to the bonus time
+	Bronstein
+	// SimpleDelay waits for the bonus time before the clock starts running down
+	SimpleDelay
+)
+
+// TimeControlStage is one period of a time control. Moves is how many moves
+// each player has to make in the stage, or 0 for the rest of the game.
+type TimeControlStage struct {
+	Moves     int
+	Time      time.Duration
+	Bonus     Bonus
+	BonusTime time.Duration
+}
+
+// TimeControl is the stages of a game's time control in the order they're
+// played. A player's unused time carries over into the next stage. If the
+// last stage has a move count it repeats, so 40/7200:40/3600 gives an hour
+// for every 40 moves after the first 40.
+type TimeControl []TimeControlStage
+
+// ParseTimeControl parses the value of a PGN TimeControl tag, for example
+// "40/5400+30:1800+30" for 90 minutes for 40 moves and 30 more for the rest of
+// the game, with 30 seconds added per move throughout. Increments are Fischer.
+// The unknown "?", untimed "-" and sandclock "*" controls are errors, as a
+// Clock can't play them.
+func ParseTimeControl(tag string) (TimeControl, error) {
+	var retVal TimeControl
+	var err error
+	tag = strings.TrimSpace(tag)
+	switch {
+	case tag == "?" || tag == "-" || tag == "":
+		err = fmt.Errorf("No time control in '%s'", tag)
+	case strings.HasPrefix(tag, "*"):
+		err = fmt.Errorf("Sandclock time controls aren't supported")
+	}
+	for _, field := range strings.Split(tag, ":") {
+		if err != nil {
+			break
+		}
+		var stage TimeControlStage
+		stage, err = parseTimeControlStage(field)
+		if err == nil && stage.Moves == 0 && len(retVal) > 0 && retVal[len(retVal)-1].Moves == 0 {
+			err = fmt.Errorf("Time control '%s' has a stage after the rest of the game", tag)
+		}
+		retVal = append(retVal, stage)
+	}
+	if err != nil {
+		retVal = nil
+	}
+	return retVal, err
+}
+
+// parseTimeControlStage parses one of "moves/seconds", "seconds" or "seconds+increment",
+// where the moves form may have an increment too
+func parseTimeControlStage(field string) (TimeControlStage, error) {
+	var retVal TimeControlStage
+	var err error
+	seconds := field
+	if slash := strings.Index(field, "/"); slash >= 0 {
+		retVal.Moves, err = strconv.Atoi(field[:slash])
+		if err == nil && retVal.Moves <= 0 {
+			err = fmt.Errorf("Bad move count in '%s'", field)
+		}
+		seconds = field[slash+1:]
+	}
+	var increment int
+	if plus := strings.Index(seconds, "+"); plus >= 0 && err == nil {
+		increment, err = strconv.Atoi(seconds[plus+1:])
+		if err == nil && increment < 0 {
+			err = fmt.Errorf("Bad increment in '%s'", field)
+		}
+		retVal.Bonus = Fischer
+		retVal.BonusTime = time.Duration(increment) * time.Second
+		seconds = seconds[:plus]
+	}
+	var base int
+	if err == nil {
+		base, err = strconv.Atoi(seconds)
+	}
+	if err == nil && base <= 0 {
+		err = fmt.Errorf("Bad time in '%s'", field)
+	}
+	if err != nil {
+		err = fmt.Errorf("Invalid time control stage '%s': %v", field, err)
+	}
+	retVal.Time = time.Duration(base) * time.Second
+	return retVal, err
+}
+
+// Clock times a game. Moves are played through the clock, which charges the
+// time since the last move to the player who made it and starts the other
+// player's time.
+type Clock struct {
+	chess     *Chess
+	control   TimeControl
+	now       func() time.Time
+	remaining map[PieceColor]time.Duration
+	stage     map[PieceColor]int
+	moves     map[PieceColor]int
+	used      []time.Duration
+	started   time.Time
+	running   bool
+}
+
+// NewClock creates a Clock for a game with the given time control. The clock
+// doesn't run until Start is called. now is the time source, for tests; nil
+// means time.Now.
+func NewClock(chess *Chess, control TimeControl, now func() time.Time) (*Clock, error) {
+	var retVal *Clock
+	var err error
+	if len(control) == 0 {
+		err = fmt.Errorf("A clock needs a time control")
+	}
+	for _, stage := range control {
+		if err == nil && (stage.Time < 0 || stage.BonusTime < 0 || stage.Moves < 0) {
+			err = fmt.Errorf("Invalid time control stage %+v", stage)
+		}
+	}
+	if err == nil && control[0].Time <= 0 {
+		err = fmt.Errorf("The first stage of a time control needs some time")
+	}
+	if err == nil {
+		if now == nil {
+			now = time.Now
+		}
+		retVal = &Clock{
+			chess:     chess,
+			control:   control,
+			now:       now,
+			remaining: map[PieceColor]time.Duration{white: control[0].Time, black: control[0].Time},
+			stage:     map[PieceColor]int{white: 0, black: 0},
+			moves:     map[PieceColor]int{white: 0, black: 0},
+		}
+	}
+	return retVal, err
+}
+
+// Start starts the clock of the side to move
+func (clock *Clock) Start() {
+	if !clock.running {
+		clock.running = true
+		clock.started = clock.now()
+	}
+}
+
+// Stop stops the clock, for a game ended by resignation or agreement, or to
+// pause it. Start carries on from where it stopped.
+func (clock *Clock) Stop() {
+	if clock.running {
+		color := clock.chess.Turn()
+		clock.remaining[color] = clock.Remaining(color)
+		clock.running = false
+	}
+}
+
+// Running returns true if the clock has been started and hasn't been stopped,
+// either by Stop or by the end of the game
+func (clock *Clock) Running() bool {
+	return clock.running
+}
+
+// Move plays a move in SAN and switches the clock to the other player. It
+// fails if the move is illegal or the player has run out of time, in which
+// case the move isn't played. The clock stops when the game ends.
+func (clock *Clock) Move(san string) error {
+	move, err := clock.chess.SANToMove(san)
+	if err == nil {
+		err = clock.MakeMove(move)
+	}
+	return err
+}
+
+// MakeMove plays a legal move and switches the clock to the other player,
+// the same as Move
+func (clock *Clock) MakeMove(move Move) error {
+	var err error
+	color := clock.chess.Turn()
+	if !clock.running {
+		err = fmt.Errorf("The clock isn't running")
+	} else if clock.Remaining(color) <= 0 {
+		err = fmt.Errorf("%s has run out of time", colorName(color))
+	}
+	if err == nil {
+		now := clock.now()
+		elapsed := now.Sub(clock.started)
+		clock.charge(color, elapsed)
+		clock.used = append(clock.used, elapsed)
+		clock.chess.MakeMove(move)
+		clock.started = now
+		clock.running = !clock.chess.Outcome().Over()
+	}
+	return err
+}
+
+// charge takes the time a move took off the player's clock, adds any bonus and
+// moves them on to the next stage if they've made its moves
+func (clock *Clock) charge(color PieceColor, elapsed time.Duration) {
+	stage := clock.control[clock.stage[color]]
+	switch stage.Bonus {
+	case Fischer:
+		clock.remaining[color] += stage.BonusTime - elapsed
+	case Bronstein, SimpleDelay:
+		// They differ only in how the clock looks while the player thinks
+		clock.remaining[color] -= elapsed - min(elapsed, stage.BonusTime)
+	default:
+		clock.remaining[color] -= elapsed
+	}
+
+	clock.moves[color]++
+	if stage.Moves > 0 && clock.moves[color] == stage.Moves {
+		clock.moves[color] = 0
+		if clock.stage[color] < len(clock.control)-1 {
+			clock.stage[color]++
+		}
+		clock.remaining[color] += clock.control[clock.stage[color]].Time
+	}
+}
+
+// Remaining returns how much time a player has left, counting the time
+// they've been thinking if it's their move. With a simple delay the clock
+// doesn't run down until the delay is up.
+func (clock *Clock) Remaining(color PieceColor) time.Duration {
+	retVal := clock.remaining[color]
+	if clock.running && clock.chess.Turn() == color {
+		thinking := clock.now().Sub(clock.started)
+		if stage := clock.control[clock.stage[color]]; stage.Bonus == SimpleDelay {
+			thinking -= min(thinking, stage.BonusTime)
+		}
+		retVal -= thinking
+	}
+	return retVal
+}
+
+// Used returns how long each move played through the clock took, in order
+func (clock *Clock) Used() []time.Duration {
+	return append([]time.Duration(nil), clock.used...)
+}
+
+// Flagged returns true if the side to move has run out of time
+func (clock *Clock) Flagged() bool {
+	return clock.Remaining(clock.chess.Turn()) <= 0
+}
+
+// Outcome returns how the game ended, including on time. A player who runs
+// out of time loses, unless their opponent has nothing left to mate with, in
+// which case it's a draw.
+func (clock *Clock) Outcome() Outcome {
+	retVal := clock.chess.Outcome()
+	if !retVal.Over() && clock.Flagged() {
+		color := clock.chess.Turn()
+		retVal = Outcome{Result: "1-0", Reason: "timeout"}
+		if color == white {
+			retVal.Result = "0-1"
+		}
+		if !clock.chess.hasMatingMaterial(swapColor(color)) {
+			retVal = Outcome{Result: "1/2-1/2", Reason: "timeout vs insufficient material"}
+		}
+	}
+	return retVal
+}
+
+// hasMatingMaterial returns true if color could mate with some series of legal
+// moves. A lone king can't, nor can a king and a single minor piece, unless
+// the other side has men of its own to block their king in. Bishops that all
+// stand on one colour of square can't either, as no bishop can then guard the
+// squares next to the king on the other colour.
+func (chess *Chess) hasMatingMaterial(color PieceColor) bool {
+	var knights, bishops, others, otherBishops int
+	// bishopShades has a bit for each colour of square a bishop stands on
+	bishopShades := 0
+	majors := false
+	for cntr := squareNameToID["a8"]; cntr <= squareNameToID["h1"]; cntr++ {
+		if (cntr & 0x88) != 0 {
+			cntr += 7
+			continue
+		}
+		piece := chess.board[cntr]
+		if piece.ptype == bishop {
+			bishopShades |= 1 << ((cntr>>4 + cntr&7) & 1)
+		}
+		switch {
+		case piece.IsUnspecified() || piece.ptype == king:
+		case piece.pcolor != color:
+			others++
+			if piece.ptype == bishop {
+				otherBishops++
+			}
+		case piece.ptype == knight:
+			knights++
+		case piece.ptype == bishop:
+			bishops++
+		default:
+			majors = true
+		}
+	}
+	minors := knights + bishops
+	retVal := majors || minors > 1 || (minors == 1 && others > 0)
+	if !majors && knights == 0 && bishops > 0 {
+		// Only bishops on both colours, or men that aren't bishops, can be
+		// used to cover or block the king's flight squares
+		retVal = bishopShades == 3 || others > otherBishops
+	}
+	return retVal
+}
+
+func colorName(color PieceColor) string {
+	if color == white {
+		return "White"
+	}
+	return "Black"
+}
@@ -0,0 +1,244 @@
+package chess
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// fakeTime is a time source that only moves when told to
+type fakeTime struct {
+	now time.Time
+}
+
+func (fake *fakeTime) Now() time.Time {
+	return fake.now
+}
+
+func (fake *fakeTime) Advance(duration time.Duration) {
+	fake.now = fake.now.Add(duration)
+}
+
+func newTestClock(t *testing.T, fen string, tag string) (*Clock, *fakeTime) {
+	game := New()
+	if fen != "" {
+		game.Load(fen)
+	}
+	control, err := ParseTimeControl(tag)
+	if err != nil {
+		t.Fatalf("Got an error %v", err)
+	}
+	fake := &fakeTime{now: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
+	clock, err := NewClock(game, control, fake.Now)
+	if err != nil {
+		t.Fatalf("Got an error %v", err)
+	}
+	clock.Start()
+	return clock, fake
+}
+
+func TestParseTimeControl(t *testing.T) {
+	tests := []struct {
+		tag      string
+		expected TimeControl
+	}{
+		{"300", TimeControl{{Time: 5 * time.Minute}}},
+		{"180+2", TimeControl{{Time: 3 * time.Minute, Bonus: Fischer, BonusTime: 2 * time.Second}}},
+		{"40/5400+30:1800+30", TimeControl{
+			{Moves: 40, Time: 90 * time.Minute, Bonus: Fischer, BonusTime: 30 * time.Second},
+			{Time: 30 * time.Minute, Bonus: Fischer, BonusTime: 30 * time.Second}}},
+		{"40/7200:20/3600", TimeControl{{Moves: 40, Time: 2 * time.Hour}, {Moves: 20, Time: time.Hour}}},
+	}
+	for _, test := range tests {
+		control, err := ParseTimeControl(test.tag)
+		if err != nil || !reflect.DeepEqual(control, test.expected) {
+			t.Errorf("Expected %v for %s, got %v %v", test.expected, test.tag, control, err)
+		}
+	}
+
+	for _, tag := range []string{"?", "-", "*180", "", "abc", "0", "40/", "/300", "300+x", "300:600", "-5"} {
+		if _, err := ParseTimeControl(tag); err == nil {
+			t.Errorf("Expected an error for '%s'", tag)
+		}
+	}
+}
+
+func TestSuddenDeathAndFlagFall(t *testing.T) {
+	clock, fake := newTestClock(t, "", "60")
+
+	fake.Advance(10 * time.Second)
+	if remaining := clock.Remaining(white); remaining != 50*time.Second {
+		t.Errorf("Expected 50s for white while thinking, got %v", remaining)
+	}
+	clock.Move("e4")
+	fake.Advance(5 * time.Second)
+	if clock.Remaining(white) != 50*time.Second || clock.Remaining(black) != 55*time.Second {
+		t.Errorf("Expected 50s and 55s, got %v and %v", clock.Remaining(white), clock.Remaining(black))
+	}
+	clock.Move("e5")
+
+	fake.Advance(50 * time.Second)
+	if !clock.Flagged() {
+		t.Errorf("Expected white to have run out of time")
+	}
+	if err := clock.Move("Nf3"); err == nil || len(clock.chess.History()) != 2 {
+		t.Errorf("Expected no move after the flag fell")
+	}
+	if outcome := clock.Outcome(); outcome != (Outcome{"0-1", "timeout"}) {
+		t.Errorf("Expected black to win on time, got %v", outcome)
+	}
+	if !reflect.DeepEqual(clock.Used(), []time.Duration{10 * time.Second, 5 * time.Second}) {
+		t.Errorf("Unexpected time used %v", clock.Used())
+	}
+}
+
+func TestTimeoutVsInsufficientMaterial(t *testing.T) {
+	tests := []struct {
+		fen      string
+		expected Outcome
+	}{
+		{"4k3/8/8/8/8/8/4P3/4K3 b - - 0 1", Outcome{"1-0", "timeout"}},
+		{"4k3/4p3/8/8/8/8/8/4K3 w - - 0 1", Outcome{"0-1", "timeout"}},
+		{"4k3/8/8/8/8/8/4P3/4K3 w - - 0 1", Outcome{"1/2-1/2", "timeout vs insufficient material"}},
+		{"4k3/3n4/8/8/8/8/4P3/4K3 w - - 0 1", Outcome{"0-1", "timeout"}},
+		{"4k3/3n4/8/8/8/8/8/4KQ2 b - - 0 1", Outcome{"1-0", "timeout"}},
+		{"4k3/8/8/8/8/8/8/4KQ2 w - - 0 1", Outcome{"1/2-1/2", "timeout vs insufficient material"}},
+		{"4k3/8/8/8/8/8/8/1NN1K3 b - - 0 1", Outcome{"1-0", "timeout"}},
+		{"4k3/8/8/8/8/8/8/2B1KB2 b - - 0 1", Outcome{"1-0", "timeout"}},
+		{"4k3/4p3/8/8/8/8/8/1B2KB2 b - - 0 1", Outcome{"1-0", "timeout"}},
+	}
+	for _, test := range tests {
+		clock, fake := newTestClock(t, test.fen, "10")
+		fake.Advance(10 * time.Second)
+		if outcome := clock.Outcome(); outcome != test.expected {
+			t.Errorf("Expected %v for %s, got %v", test.expected, test.fen, outcome)
+		}
+	}
+}
+
+func TestBishopsOnOneColourCantMate(t *testing.T) {
+	tests := []struct {
+		fen      string
+		expected bool
+	}{
+		{"4k3/8/8/8/8/8/8/1B2KB2 w - - 0 1", false},
+		{"4k3/8/8/8/8/8/8/B3K1B1 w - - 0 1", false},
+		{"4k3/8/8/1b6/8/8/8/1B2KB2 w - - 0 1", false},
+		{"4k3/8/8/8/8/8/8/2B1KB2 w - - 0 1", true},
+		// Their bishop on the other colour, or a pawn, can block their king in
+		{"4k3/8/8/8/1b6/8/8/1B2KB2 w - - 0 1", true},
+		{"4k3/4p3/8/8/8/8/8/1B2KB2 w - - 0 1", true},
+	}
+	for _, test := range tests {
+		chess := New()
+		chess.Load(test.fen)
+		if actual := chess.hasMatingMaterial(white); actual != test.expected {
+			t.Errorf("Expected %v for %s, got %v", test.expected, test.fen, actual)
+		}
+	}
+}
+
+func TestIncrementsAndDelays(t *testing.T) {
+	tests := []struct {
+		bonus    Bonus
+		thinking time.Duration
+		expected time.Duration
+	}{
+		{Fischer, 2 * time.Second, 63 * time.Second},
+		{Fischer, 10 * time.Second, 55 * time.Second},
+		{Bronstein, 2 * time.Second, 60 * time.Second},
+		{Bronstein, 10 * time.Second, 55 * time.Second},
+		{SimpleDelay, 2 * time.Second, 60 * time.Second},
+		{SimpleDelay, 10 * time.Second, 55 * time.Second},
+	}
+	for _, test := range tests {
+		fake := &fakeTime{now: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)}
+		clock, _ := NewClock(New(), TimeControl{{Time: time.Minute, Bonus: test.bonus, BonusTime: 5 * time.Second}}, fake.Now)
+		clock.Start()
+		fake.Advance(test.thinking)
+		clock.Move("e4")
+		if remaining := clock.Remaining(white); remaining != test.expected {
+			t.Errorf("Expected %v after %v with bonus %d, got %v", test.expected, test.thinking, test.bonus, remaining)
+		}
+	}
+
+	// The clock only runs down once a simple delay is up
+	clock, fake := newTestClock(t, "", "60")
+	clock.control = TimeControl{{Time: time.Minute, Bonus: SimpleDelay, BonusTime: 5 * time.Second}}
+	fake.Advance(3 * time.Second)
+	if remaining := clock.Remaining(white); remaining != time.Minute {
+		t.Errorf("Expected the delay to hold the clock, got %v", remaining)
+	}
+	fake.Advance(4 * time.Second)
+	if remaining := clock.Remaining(white); remaining != 58*time.Second {
+		t.Errorf("Expected 58s once the delay was up, got %v", remaining)
+	}
+}
+
+func TestMultiStageControl(t *testing.T) {
+	clock, fake := newTestClock(t, "", "2/60+1:30")
+	for _, san := range []string{"Nf3", "Nf6", "Ng1"} {
+		fake.Advance(10 * time.Second)
+		clock.Move(san)
+	}
+	// White has made both moves of the first stage, picking up the second's time and losing its increment
+	if remaining := clock.Remaining(white); remaining != 72*time.Second {
+		t.Errorf("Expected 72s for white, got %v", remaining)
+	}
+	if remaining := clock.Remaining(black); remaining != 51*time.Second {
+		t.Errorf("Expected 51s for black, got %v", remaining)
+	}
+	fake.Advance(10 * time.Second)
+	clock.Move("Ng8")
+	fake.Advance(10 * time.Second)
+	clock.Move("Nf3")
+	if remaining := clock.Remaining(white); remaining != 62*time.Second {
+		t.Errorf("Expected no increment in the second stage, got %v", remaining)
+	}
+
+	// A last stage with a move count repeats
+	clock, fake = newTestClock(t, "", "1/60:1/30")
+	for _, san := range []string{"Nf3", "Nf6", "Ng1", "Ng8", "Nf3"} {
+		fake.Advance(time.Second)
+		clock.Move(san)
+	}
+	if remaining := clock.Remaining(white); remaining != 147*time.Second {
+		t.Errorf("Expected 147s after repeating the last stage, got %v", remaining)
+	}
+}
+
+func TestClockStopsWhenTheGameEnds(t *testing.T) {
+	clock, fake := newTestClock(t, "", "60")
+	if _, err := NewClock(New(), nil, nil); err == nil {
+		t.Errorf("Expected an error for a clock without a time control")
+	}
+	for _, san := range []string{"f3", "e5", "g4", "Qh4"} {
+		fake.Advance(time.Second)
+		if err := clock.Move(san); err != nil {
+			t.Errorf("Got an error %v", err)
+		}
+	}
+	fake.Advance(time.Hour)
+	if clock.Running() || clock.Flagged() || clock.Outcome() != (Outcome{"0-1", "checkmate"}) {
+		t.Errorf("Expected mate to stop the clock, got %v", clock.Outcome())
+	}
+}
+
+func TestStopAndStart(t *testing.T) {
+	clock, fake := newTestClock(t, "", "60")
+	fake.Advance(10 * time.Second)
+	clock.Stop()
+	fake.Advance(time.Hour)
+	if clock.Running() || clock.Remaining(white) != 50*time.Second {
+		t.Errorf("Expected the clock to stop at 50s, got %v", clock.Remaining(white))
+	}
+	if err := clock.Move("e4"); err == nil {
+		t.Errorf("Expected no moves while the clock is stopped")
+	}
+	clock.Start()
+	fake.Advance(5 * time.Second)
+	clock.Move("e4")
+	if remaining := clock.Remaining(white); remaining != 45*time.Second {
+		t.Errorf("Expected 45s after starting again, got %v", remaining)
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rkitts/chess"
+)
+
+// lineJSON is a line as analyse prints it. Score is in centipawns for the
+// side to move; Mate is the moves to mate instead, when there is one.
+type lineJSON struct {
+	Depth int      `json:"depth"`
+	Score int      `json:"score"`
+	Mate  int      `json:"mate,omitempty"`
+	SAN   []string `json:"san"`
+	UCI   []string `json:"uci"`
+}
+
+// analyse prints the best lines as each depth completes, or just the final
+// ones as JSON
+func (cli *cli) analyse(args []string) error {
+	flags, asJSON := cli.flags("analyse")
+	depth := flags.Int("depth", 12, "how many plies to search")
+	moveTime := flags.Duration("movetime", 10*time.Second, "the longest to search")
+	multiPV := flags.Int("multipv", 1, "how many lines to find")
+	err := parse(flags, args)
+	var game *chess.Chess
+	if err == nil {
+		game, err = load(flags.Args())
+	}
+	if err == nil && *multiPV < 1 {
+		err = fmt.Errorf("multipv must be at least 1")
+	}
+	if err != nil {
+		return err
+	}
+
+	var onUpdate func([]chess.Line)
+	if !*asJSON {
+		fmt.Fprintln(cli.stdout, describeTurn(game))
+		onUpdate = func(lines []chess.Line) {
+			for cntr, line := range lines {
+				fmt.Fprintf(cli.stdout, "depth %2d  %d. %6s  %s\n", line.Depth, cntr+1, formatScore(line), strings.Join(line.SAN, " "))
+			}
+		}
+	}
+	lines := chess.Analyze(game, chess.Limits{Depth: *depth, MoveTime: *moveTime}, *multiPV, onUpdate)
+
+	if *asJSON {
+		result := []lineJSON{}
+		for _, line := range lines {
+			result = append(result, lineJSON{Depth: line.Depth, Score: line.Score, Mate: line.Mate(), SAN: line.SAN, UCI: line.UCI})
+		}
+		err = cli.printJSON(result)
+	}
+	return err
+}
+
+// formatScore shows a score in pawns, or as #n for a mate in n
+func formatScore(line chess.Line) string {
+	retVal := fmt.Sprintf("%+.2f", float64(line.Score)/100)
+	if mate := line.Mate(); mate != 0 {
+		retVal = fmt.Sprintf("#%d", mate)
+	}
+	return retVal
+}
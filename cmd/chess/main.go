@@ -0,0 +1,151 @@
+// Command chess does everyday position and game work from the command line:
+// checking and drawing FENs, listing moves, perft, converting PGN, playing
+// against the package's own search, analysing positions and running test
+// suites. Most commands print plain text, or JSON with -json.
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/rkitts/chess"
+)
+
+const usage = `Usage: chess <command> [flags] [arguments]
+
+Commands:
+  fen validate <fen>          check a FEN, exiting with 1 if it's invalid
+  fen show <fen>              draw the position
+  moves [fen]                 list the legal moves in SAN and UCI
+  perft [-divide] [fen] <depth>
+                              count the leaf nodes depth plies deep
+  pgn convert [-fens] [-sloppy] [-notation n] [file ...]
+                              rewrite PGN in export format, or list each
+                              game's FEN after every ply; reads stdin if no
+                              files are given, or for "-". With -json, one game per line.
+  play [-color w|b] [-fen fen] [-depth n] [-movetime d] [-notation n]
+                              play against the bot, text only
+  analyse [-depth n] [-movetime d] [-multipv n] [fen]
+                              find the best lines
+  suite [-depth n] [-movetime d] <file>
+                              search each position in an EPD test suite and
+                              check the moves against its bm and am
+
+FENs can be given as one argument or several; leaving one out means the
+starting position. Run "chess <command> -h" for a command's flags.
+`
+
+// The colours as chess.Chess.Turn returns them
+const (
+	white = chess.PieceColor('w')
+	black = chess.PieceColor('b')
+)
+
+// errUsage is returned for bad arguments, which have already been explained
+var errUsage = errors.New("usage")
+
+// errFailed is returned when a command fails having already said why
+var errFailed = errors.New("failed")
+
+// cli is where a command reads and writes
+type cli struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run runs the command in args and returns the exit status: 0 for success,
+// 1 if it failed and 2 for bad arguments
+func run(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	cli := &cli{stdin: stdin, stdout: stdout, stderr: stderr}
+	var command func([]string) error
+	name := strings.Join(args[:min(len(args), 2)], " ")
+	switch {
+	case name == "fen validate":
+		command = cli.fenValidate
+	case name == "fen show":
+		command = cli.fenShow
+	case name == "pgn convert":
+		command = cli.pgnConvert
+	case len(args) > 0 && args[0] == "moves":
+		command, name = cli.moves, args[0]
+	case len(args) > 0 && args[0] == "perft":
+		command, name = cli.perft, args[0]
+	case len(args) > 0 && args[0] == "play":
+		command, name = cli.play, args[0]
+	case len(args) > 0 && (args[0] == "analyse" || args[0] == "analyze"):
+		command, name = cli.analyse, args[0]
+	case len(args) > 0 && args[0] == "suite":
+		command, name = cli.suite, args[0]
+	}
+
+	retVal := 0
+	if command == nil {
+		fmt.Fprint(stderr, usage)
+		retVal = 2
+	} else if err := command(args[len(strings.Fields(name)):]); errors.Is(err, errUsage) || errors.Is(err, flag.ErrHelp) {
+		retVal = 2
+	} else if errors.Is(err, errFailed) {
+		retVal = 1
+	} else if err != nil {
+		fmt.Fprintf(stderr, "chess %s: %v\n", name, err)
+		retVal = 1
+	}
+	return retVal
+}
+
+// flags creates the flag set for a command, with the -json flag most of them take
+func (cli *cli) flags(name string) (*flag.FlagSet, *bool) {
+	retVal := flag.NewFlagSet("chess "+name, flag.ContinueOnError)
+	retVal.SetOutput(cli.stderr)
+	return retVal, retVal.Bool("json", false, "print JSON instead of text")
+}
+
+// parse parses a command's flags, returning errUsage if they're bad
+func parse(flags *flag.FlagSet, args []string) error {
+	err := flags.Parse(args)
+	if err != nil && !errors.Is(err, flag.ErrHelp) {
+		err = errUsage
+	}
+	return err
+}
+
+// notationFlag adds the -notation flag, for writing moves with other piece letters
+func notationFlag(flags *flag.FlagSet) *string {
+	return flags.String("notation", "en", "how pieces are written: en, de, fr, es, it, nl or figurine")
+}
+
+// lookupNotation returns the notation a -notation flag names
+func lookupNotation(name string) (chess.Notation, error) {
+	var err error
+	retVal, ok := chess.Notations[name]
+	if !ok {
+		err = fmt.Errorf("Unknown notation '%s'", name)
+	}
+	return retVal, err
+}
+
+// load sets up a game from FEN arguments, or the starting position if there are none
+func load(args []string) (*chess.Chess, error) {
+	var err error
+	retVal := chess.New()
+	if len(args) > 0 {
+		err = retVal.Load(strings.Join(args, " "))
+	}
+	return retVal, err
+}
+
+func (cli *cli) printJSON(value interface{}) error {
+	encoder := json.NewEncoder(cli.stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
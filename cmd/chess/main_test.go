@@ -0,0 +1,188 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// runWith runs a command with the given stdin, returning its status and output
+func runWith(stdin string, args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	status := run(args, strings.NewReader(stdin), &stdout, &stderr)
+	return status, stdout.String(), stderr.String()
+}
+
+func TestUsage(t *testing.T) {
+	for _, args := range [][]string{nil, {"nope"}, {"fen"}, {"moves", "-bad"}} {
+		if status, _, stderr := runWith("", args...); status != 2 || !strings.Contains(stderr, "Usage") {
+			t.Errorf("Expected usage for %v, got %d %s", args, status, stderr)
+		}
+	}
+}
+
+func TestFenValidate(t *testing.T) {
+	status, stdout, _ := runWith("", "fen", "validate", "8/8/8/8/8/8/6k1/4K3", "w", "-", "-", "0", "1")
+	if status != 0 || stdout != "valid\n" {
+		t.Errorf("Expected a valid FEN, got %d %s", status, stdout)
+	}
+
+	var result validation
+	status, stdout, stderr := runWith("", "fen", "validate", "-json", "8/8/8/8 w - - 0 1")
+	json.Unmarshal([]byte(stdout), &result)
+	if status != 1 || result.Valid || result.Error == "" || stderr != "" {
+		t.Errorf("Expected an invalid FEN, got %d %v %s", status, result, stderr)
+	}
+}
+
+func TestFenShow(t *testing.T) {
+	_, stdout, _ := runWith("", "fen", "show", "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1")
+	if !strings.Contains(stdout, " 4 | .  .  .  .  P  .  .  . |") || !strings.HasSuffix(stdout, "Black to move\n") {
+		t.Errorf("Unexpected diagram\n%s", stdout)
+	}
+
+	var shown position
+	_, stdout, _ = runWith("", "fen", "show", "-json", "4k3/4Q3/4K3/8/8/8/8/8 b - - 0 1")
+	json.Unmarshal([]byte(stdout), &shown)
+	if len(shown.Board) != 8 || shown.Board[1] != "....Q..." || shown.Outcome != "checkmate" || !shown.InCheck {
+		t.Errorf("Unexpected position %v", shown)
+	}
+}
+
+func TestMoves(t *testing.T) {
+	var moves []moveJSON
+	_, stdout, _ := runWith("", "moves", "-json")
+	json.Unmarshal([]byte(stdout), &moves)
+	if len(moves) != 20 {
+		t.Errorf("Expected 20 moves, got %v", moves)
+	}
+
+	_, stdout, _ = runWith("", "moves", "4k3/8/8/8/8/8/8/4K2R w K - 0 1")
+	if !strings.Contains(stdout, "O-O      e1g1\n") {
+		t.Errorf("Expected castling in\n%s", stdout)
+	}
+}
+
+func TestPerft(t *testing.T) {
+	var result perftResult
+	_, stdout, _ := runWith("", "perft", "-json", "-divide", "2")
+	json.Unmarshal([]byte(stdout), &result)
+	if result.Nodes != 400 || len(result.Divide) != 20 || result.Divide["g1f3"] != 20 {
+		t.Errorf("Unexpected perft %v", result)
+	}
+
+	_, stdout, _ = runWith("", "perft", "8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1", "3")
+	if !strings.HasPrefix(stdout, "Nodes: 2812\n") {
+		t.Errorf("Expected 2812 nodes, got %s", stdout)
+	}
+	if status, _, _ := runWith("", "perft", "-json"); status != 1 {
+		t.Errorf("Expected perft without a depth to fail, got %d", status)
+	}
+}
+
+const testPGN = `[Event "Short"]
+[Result "0-1"]
+
+1. f3 e5 2. g4 Qh4# 0-1
+
+[Event "Broken"]
+
+1. e4 e4 *
+`
+
+func TestPGNConvert(t *testing.T) {
+	status, stdout, stderr := runWith(testPGN, "pgn", "convert")
+	if status != 1 || !strings.Contains(stderr, "stdin: game 2: ply 2") {
+		t.Errorf("Expected the broken game to be skipped, got %d %s", status, stderr)
+	}
+	if !strings.Contains(stdout, "[Event \"Short\"]\n[Site \"?\"]") || !strings.HasSuffix(stdout, "1. f3 e5 2. g4 Qh4# 0-1\n\n") {
+		t.Errorf("Unexpected PGN\n%s", stdout)
+	}
+
+	_, stdout, _ = runWith(testPGN, "pgn", "convert", "-notation", "de")
+	if !strings.HasSuffix(stdout, "1. f3 e5 2. g4 Dh4# 0-1\n\n") {
+		t.Errorf("Expected the moves in German\n%s", stdout)
+	}
+	if status, _, _ = runWith(testPGN, "pgn", "convert", "-notation", "xx"); status != 1 {
+		t.Errorf("Expected an unknown notation to fail, got %d", status)
+	}
+
+	_, stdout, _ = runWith(testPGN, "pgn", "convert", "-fens")
+	fens := strings.Split(strings.TrimSpace(stdout), "\n")
+	if len(fens) != 5 || fens[4] != "rnb1kbnr/pppp1ppp/8/4p3/6Pq/5P2/PPPPP2P/RNBQKBNR w KQkq - 1 3" {
+		t.Errorf("Unexpected FENs %v", fens)
+	}
+
+	var converted convertedGame
+	_, stdout, _ = runWith(testPGN, "pgn", "convert", "-json", "-fens")
+	json.Unmarshal([]byte(strings.Split(stdout, "\n")[0]), &converted)
+	if converted.Tags["Event"] != "Short" || len(converted.Moves) != 4 || len(converted.FENs) != 5 || converted.Result != "0-1" {
+		t.Errorf("Unexpected game %v", converted)
+	}
+}
+
+func TestAnalyse(t *testing.T) {
+	var lines []lineJSON
+	_, stdout, _ := runWith("", "analyse", "-json", "-depth", "3", "-multipv", "2", "6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1")
+	json.Unmarshal([]byte(stdout), &lines)
+	if len(lines) != 2 || lines[0].Mate != 1 || lines[0].SAN[0] != "Ra8#" || lines[1].Mate != 0 {
+		t.Errorf("Unexpected lines %v", lines)
+	}
+
+	_, stdout, _ = runWith("", "analyse", "-depth", "2", "6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1")
+	if !strings.Contains(stdout, "#1  Ra8") {
+		t.Errorf("Expected mate in one in\n%s", stdout)
+	}
+}
+
+func TestPlay(t *testing.T) {
+	_, stdout, _ := runWith("e4\nnonsense\nresign\n", "play", "-depth", "1")
+	if !strings.Contains(stdout, "Can't read 'nonsense' as a move") || !strings.Contains(stdout, "0-1 by resignation") {
+		t.Errorf("Unexpected game\n%s", stdout)
+	}
+	if !strings.Contains(stdout, "[White \"You\"]") || !strings.Contains(stdout, "1. e4 ") {
+		t.Errorf("Expected the game as PGN in\n%s", stdout)
+	}
+
+	_, stdout, _ = runWith("knight to f3\nresign\n", "play", "-depth", "1")
+	if !strings.Contains(stdout, "1. Nf3 ") {
+		t.Errorf("Expected a move in words to be played\n%s", stdout)
+	}
+
+	_, stdout, _ = runWith("Sf3\nresign\n", "play", "-depth", "1", "-notation", "de")
+	if !strings.Contains(stdout, "1. Sf3 ") {
+		t.Errorf("Expected the game in German\n%s", stdout)
+	}
+
+	_, stdout, _ = runWith("Qxf7\n", "play", "-depth", "1", "-fen", "r1bqkbnr/pppp1ppp/2n5/4p3/2B1P3/5Q2/PPPP1PPP/RNB1K1NR w KQkq - 4 4")
+	if !strings.Contains(stdout, "1-0 by checkmate") || !strings.Contains(stdout, "4. Qxf7# 1-0") {
+		t.Errorf("Expected mate to end the game\n%s", stdout)
+	}
+}
+
+func TestSuite(t *testing.T) {
+	const suite = `6k1/5ppp/8/8/8/8/8/R5K1 w - - bm Ra8; id "mate.1";
+6k1/5ppp/8/8/8/8/8/R5K1 w - - am Ra8; id "avoid.1";
+`
+	status, stdout, _ := runWith(suite, "suite", "-depth", "2", "-")
+	if status != 0 || !strings.Contains(stdout, "pass  mate.1") || !strings.Contains(stdout, "fail  avoid.1") || !strings.HasSuffix(stdout, "Passed 1 of 2\n") {
+		t.Errorf("Unexpected suite output %d\n%s", status, stdout)
+	}
+
+	var report suiteReport
+	_, stdout, _ = runWith(suite, "suite", "-json", "-depth", "2", "-")
+	json.Unmarshal([]byte(stdout), &report)
+	if report.Tests != 2 || report.Passed != 1 || report.Results[1].Found != "Ra8#" || report.Results[1].Avoid[0] != "Ra8" {
+		t.Errorf("Unexpected report %v", report)
+	}
+	if status, _, _ = runWith("", "suite"); status != 2 {
+		t.Errorf("Expected usage without a file, got %d", status)
+	}
+
+	// A record that can't be read is reported and the rest still run
+	status, stdout, stderr := runWith("8/8/8/8/8/8/8/9 w - - ;\n"+suite, "suite", "-depth", "2", "-")
+	if status != 0 || !strings.Contains(stderr, "-: skipped line 1: ") || !strings.HasSuffix(stdout, "Passed 1 of 2\n") {
+		t.Errorf("Unexpected suite output %d\n%s\n%s", status, stdout, stderr)
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/rkitts/chess"
+)
+
+// perftResult is the JSON for perft. Divide is only there with -divide.
+type perftResult struct {
+	FEN    string           `json:"fen"`
+	Depth  int              `json:"depth"`
+	Nodes  int64            `json:"nodes"`
+	Divide map[string]int64 `json:"divide,omitempty"`
+}
+
+func (cli *cli) perft(args []string) error {
+	flags, asJSON := cli.flags("perft")
+	divide := flags.Bool("divide", false, "count the nodes under each move separately")
+	err := parse(flags, args)
+	var depth int
+	if err == nil && flags.NArg() > 0 {
+		depth, err = strconv.Atoi(flags.Arg(flags.NArg() - 1))
+	}
+	if err == nil && (flags.NArg() == 0 || depth < 0) {
+		err = fmt.Errorf("Expected a depth of zero or more")
+	}
+	var game *chess.Chess
+	if err == nil {
+		game, err = load(flags.Args()[:flags.NArg()-1])
+	}
+	if err != nil {
+		return err
+	}
+
+	start := time.Now()
+	result := perftResult{FEN: game.GenerateFen(), Depth: depth}
+	if *divide && depth > 0 {
+		result.Divide = chess.Divide(game, depth)
+		for _, nodes := range result.Divide {
+			result.Nodes += nodes
+		}
+	} else {
+		result.Nodes = chess.Perft(game, depth)
+	}
+	elapsed := time.Since(start)
+
+	if *asJSON {
+		err = cli.printJSON(result)
+	} else {
+		var moves []string
+		for move := range result.Divide {
+			moves = append(moves, move)
+		}
+		sort.Strings(moves)
+		for _, move := range moves {
+			fmt.Fprintf(cli.stdout, "%s: %d\n", move, result.Divide[move])
+		}
+		if len(moves) > 0 {
+			fmt.Fprintln(cli.stdout)
+		}
+		fmt.Fprintf(cli.stdout, "Nodes: %d\n", result.Nodes)
+		fmt.Fprintf(cli.stdout, "Time: %v\n", elapsed.Round(time.Millisecond))
+	}
+	return err
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/rkitts/chess"
+)
+
+// convertedGame is the JSON for each game pgn convert reads. FENs, with
+// -fens, starts with the position before the first move.
+type convertedGame struct {
+	Tags   map[string]string `json:"tags"`
+	Moves  []string          `json:"moves"`
+	Result string            `json:"result"`
+	FENs   []string          `json:"fens,omitempty"`
+}
+
+// convertOptions are the flags for pgn convert
+type convertOptions struct {
+	fens     bool
+	asJSON   bool
+	sloppy   bool
+	notation chess.Notation
+}
+
+// pgnConvert reads every game, replays it to check it and normalise its
+// moves, and writes it out again. A game that doesn't replay is reported and
+// skipped, and the command fails once the rest have been written.
+func (cli *cli) pgnConvert(args []string) error {
+	var options convertOptions
+	flags, asJSON := cli.flags("pgn convert")
+	flags.BoolVar(&options.fens, "fens", false, "list the FEN after each ply instead of the moves")
+	flags.BoolVar(&options.sloppy, "sloppy", false, "accept moves that aren't standard SAN, such as 0-0, e2e4 or Nge2")
+	notationName := notationFlag(flags)
+	err := parse(flags, args)
+	options.asJSON = *asJSON
+	if err == nil {
+		options.notation, err = lookupNotation(*notationName)
+	}
+	if err != nil {
+		return err
+	}
+
+	names := []string{"-"}
+	if flags.NArg() > 0 {
+		names = flags.Args()
+	}
+	skipped := 0
+	for cntr := 0; cntr < len(names) && err == nil; cntr++ {
+		var failed int
+		failed, err = cli.convertFile(names[cntr], options)
+		skipped += failed
+	}
+	if err == nil && skipped > 0 {
+		err = fmt.Errorf("%d games skipped", skipped)
+	}
+	return err
+}
+
+// convertFile converts the games in a file, or stdin for "-"
+func (cli *cli) convertFile(name string, options convertOptions) (int, error) {
+	if name == "-" {
+		return cli.convert("stdin", cli.stdin, options)
+	}
+	file, err := os.Open(name)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+	return cli.convert(name, file, options)
+}
+
+// convert converts the games in one input, returning how many were skipped
+func (cli *cli) convert(name string, input io.Reader, options convertOptions) (int, error) {
+	var err error
+	skipped := 0
+	reader := chess.NewPGNReader(input)
+	encoder := json.NewEncoder(cli.stdout)
+	for number := 1; err == nil; number++ {
+		var game *chess.PGNGame
+		game, err = reader.Next()
+		if err != nil {
+			break
+		}
+		replay := game.Replay
+		if options.sloppy {
+			replay = game.ReplaySloppy
+		}
+		replayed, replayErr := replay()
+		if replayErr != nil {
+			fmt.Fprintf(cli.stderr, "%s: game %d: %v\n", name, number, replayErr)
+			skipped++
+			continue
+		}
+
+		converted := replayed.PGNGame().InNotation(options.notation)
+		converted.Result = game.Result
+		var positions []string
+		if options.fens {
+			positions = fensOf(replayed)
+		}
+		switch {
+		case options.asJSON:
+			err = encoder.Encode(convertedGame{Tags: converted.Tags, Moves: converted.Moves, Result: converted.Result, FENs: positions})
+		case options.fens:
+			for _, fen := range positions {
+				fmt.Fprintln(cli.stdout, fen)
+			}
+			_, err = fmt.Fprintln(cli.stdout)
+		default:
+			err = converted.Write(cli.stdout)
+		}
+	}
+	if err == io.EOF {
+		err = nil
+	}
+	return skipped, err
+}
+
+// fensOf returns the FEN of the game's starting position and of the position after each move
+func fensOf(game *chess.Chess) []string {
+	replay := chess.New()
+	replay.Load(game.InitialFen())
+	retVal := []string{replay.GenerateFen()}
+	for _, move := range game.History() {
+		replay.MakeMove(move)
+		retVal = append(retVal, replay.GenerateFen())
+	}
+	return retVal
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rkitts/chess"
+)
+
+const playHelp = `Enter moves in SAN (Nf3), UCI (g1f3) or words (knight to f3).
+  undo    take back your last move
+  resign  give up
+  quit    stop without a result
+`
+
+// play plays a game against the bot on stdin and stdout, writing the game as
+// PGN when it's over
+func (cli *cli) play(args []string) error {
+	flags := flag.NewFlagSet("chess play", flag.ContinueOnError)
+	flags.SetOutput(cli.stderr)
+	color := flags.String("color", "w", "the colour you play, w or b")
+	fen := flags.String("fen", "", "the position to start from")
+	depth := flags.Int("depth", 6, "how many plies the bot searches")
+	moveTime := flags.Duration("movetime", 2*time.Second, "the longest the bot thinks about a move")
+	notationName := notationFlag(flags)
+	err := parse(flags, args)
+	if err == nil && *color != "w" && *color != "b" {
+		err = fmt.Errorf("The colour must be w or b")
+	}
+	var notation chess.Notation
+	if err == nil {
+		notation, err = lookupNotation(*notationName)
+	}
+	var game *chess.Chess
+	if err == nil {
+		game, err = load(strings.Fields(*fen))
+	}
+	if err != nil {
+		return err
+	}
+
+	human := chess.PieceColor((*color)[0])
+	searcher := chess.NewSearcher(chess.DefaultHashSize)
+	limits := chess.Limits{Depth: *depth, MoveTime: *moveTime}
+	input := bufio.NewScanner(cli.stdin)
+	fmt.Fprint(cli.stdout, playHelp)
+
+	resigned, quit := false, false
+	for !game.Outcome().Over() && !resigned && !quit {
+		if game.Turn() != human {
+			result := searcher.Search(game, limits)
+			fmt.Fprintf(cli.stdout, "Bot plays %s\n", game.SANWithOptions(result.BestMove, chess.SANOptions{Notation: notation}))
+			game.MakeMove(result.BestMove)
+			continue
+		}
+
+		fmt.Fprint(cli.stdout, "\n"+game.ASCII())
+		fmt.Fprintf(cli.stdout, "%s\n> ", describeTurn(game))
+		entered := "quit"
+		if input.Scan() {
+			entered = strings.TrimSpace(input.Text())
+		}
+		switch entered {
+		case "":
+		case "quit":
+			quit = true
+		case "resign":
+			resigned = true
+		case "undo":
+			if len(game.History()) < 2 {
+				fmt.Fprintln(cli.stdout, "Nothing to take back")
+			} else {
+				game.Undo()
+				game.Undo()
+			}
+		default:
+			move, moveErr := game.SloppySANToMoveIn(entered, notation)
+			if sanErr, ok := moveErr.(*chess.SANError); ok && sanErr.Kind == chess.SANUnparseable {
+				move, moveErr = game.SpokenToMove(entered)
+			}
+			if moveErr != nil {
+				fmt.Fprintln(cli.stdout, moveErr)
+			} else {
+				game.MakeMove(move)
+			}
+		}
+	}
+
+	if !quit {
+		played := game.PGNGame()
+		played.Tags["White"], played.Tags["Black"] = "You", "Bot"
+		if human == black {
+			played.Tags["White"], played.Tags["Black"] = "Bot", "You"
+		}
+		if resigned {
+			played.Result = "1-0"
+			if human == white {
+				played.Result = "0-1"
+			}
+		}
+		fmt.Fprint(cli.stdout, "\n"+game.ASCII())
+		fmt.Fprintln(cli.stdout, describeResult(played.Result, resigned, game))
+		fmt.Fprintln(cli.stdout)
+		err = played.InNotation(notation).Write(cli.stdout)
+	}
+	return err
+}
+
+// describeResult says how a game against the bot ended
+func describeResult(result string, resigned bool, game *chess.Chess) string {
+	retVal := result + " by " + game.Outcome().Reason
+	if resigned {
+		retVal = result + " by resignation"
+	}
+	return retVal
+}
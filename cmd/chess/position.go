@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rkitts/chess"
+)
+
+// validation is the JSON for fen validate
+type validation struct {
+	FEN   string `json:"fen"`
+	Valid bool   `json:"valid"`
+	Error string `json:"error,omitempty"`
+}
+
+// position is the JSON for fen show. Board has a string per rank from the
+// eighth down, with the pieces as in FEN and "." for empty squares.
+type position struct {
+	FEN     string   `json:"fen"`
+	Turn    string   `json:"turn"`
+	InCheck bool     `json:"inCheck"`
+	Outcome string   `json:"outcome"`
+	Board   []string `json:"board"`
+}
+
+// moveJSON is a legal move as moves lists it
+type moveJSON struct {
+	SAN string `json:"san"`
+	UCI string `json:"uci"`
+}
+
+func (cli *cli) fenValidate(args []string) error {
+	flags, asJSON := cli.flags("fen validate")
+	err := parse(flags, args)
+	if err == nil && flags.NArg() == 0 {
+		fmt.Fprintln(cli.stderr, "Usage: chess fen validate [-json] <fen>")
+		err = errUsage
+	}
+	if err != nil {
+		return err
+	}
+
+	result := validation{FEN: strings.Join(flags.Args(), " "), Valid: true}
+	if _, loadErr := load(flags.Args()); loadErr != nil {
+		result.Valid, result.Error = false, loadErr.Error()
+	}
+	if *asJSON {
+		err = cli.printJSON(result)
+	} else if result.Valid {
+		fmt.Fprintln(cli.stdout, "valid")
+	} else {
+		fmt.Fprintf(cli.stdout, "invalid: %s\n", result.Error)
+	}
+	if err == nil && !result.Valid {
+		err = errFailed
+	}
+	return err
+}
+
+func (cli *cli) fenShow(args []string) error {
+	flags, asJSON := cli.flags("fen show")
+	err := parse(flags, args)
+	var game *chess.Chess
+	if err == nil {
+		game, err = load(flags.Args())
+	}
+	if err != nil {
+		return err
+	}
+
+	if *asJSON {
+		shown := position{
+			FEN:     game.GenerateFen(),
+			Turn:    string(rune(game.Turn())),
+			InCheck: game.InCheck(),
+			Outcome: game.Outcome().Reason}
+		placement := strings.Fields(shown.FEN)[0]
+		for cntr := 1; cntr <= 8; cntr++ {
+			placement = strings.ReplaceAll(placement, fmt.Sprint(cntr), strings.Repeat(".", cntr))
+		}
+		shown.Board = strings.Split(placement, "/")
+		err = cli.printJSON(shown)
+	} else {
+		fmt.Fprint(cli.stdout, game.ASCII())
+		fmt.Fprintln(cli.stdout, describeTurn(game))
+	}
+	return err
+}
+
+func (cli *cli) moves(args []string) error {
+	flags, asJSON := cli.flags("moves")
+	err := parse(flags, args)
+	var game *chess.Chess
+	if err == nil {
+		game, err = load(flags.Args())
+	}
+	if err != nil {
+		return err
+	}
+
+	moves := []moveJSON{}
+	for _, move := range game.Moves(true, "") {
+		moves = append(moves, moveJSON{SAN: game.SAN(move), UCI: move.UCI()})
+	}
+	if *asJSON {
+		err = cli.printJSON(moves)
+	} else {
+		for _, move := range moves {
+			fmt.Fprintf(cli.stdout, "%-8s %s\n", move.SAN, move.UCI)
+		}
+	}
+	return err
+}
+
+// describeTurn says whose move it is, or how the game ended
+func describeTurn(game *chess.Chess) string {
+	retVal := "White to move"
+	if game.Turn() == black {
+		retVal = "Black to move"
+	}
+	if outcome := game.Outcome(); outcome.Over() {
+		retVal = outcome.Result + " by " + outcome.Reason
+	} else if game.InCheck() {
+		retVal += ", in check"
+	}
+	return retVal
+}
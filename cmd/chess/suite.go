@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/rkitts/chess"
+)
+
+// suiteResult is a test as suite prints it with -json
+type suiteResult struct {
+	ID     string   `json:"id"`
+	FEN    string   `json:"fen"`
+	Best   []string `json:"bm,omitempty"`
+	Avoid  []string `json:"am,omitempty"`
+	Found  string   `json:"found"`
+	Passed bool     `json:"passed"`
+}
+
+// suiteReport is the JSON for suite
+type suiteReport struct {
+	Tests   int           `json:"tests"`
+	Passed  int           `json:"passed"`
+	Results []suiteResult `json:"results"`
+}
+
+// suite runs an EPD test suite, printing each test as it's searched and then
+// the score
+func (cli *cli) suite(args []string) error {
+	flags, asJSON := cli.flags("suite")
+	depth := flags.Int("depth", 64, "how many plies to search each position")
+	moveTime := flags.Duration("movetime", time.Second, "the longest to search each position")
+	err := parse(flags, args)
+	if err == nil && flags.NArg() != 1 {
+		fmt.Fprintln(cli.stderr, "Expected one EPD file")
+		err = errUsage
+	}
+	var records []*chess.EPD
+	if err == nil {
+		records, err = readEPDFile(flags.Arg(0), cli.stdin)
+	}
+	if skipped, ok := err.(chess.EPDSkipped); ok {
+		// Run the tests that could be read
+		for _, record := range skipped {
+			fmt.Fprintf(cli.stderr, "%s: skipped %v\n", flags.Arg(0), record)
+		}
+		err = nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var onResult func(chess.SuiteResult)
+	if !*asJSON {
+		onResult = func(result chess.SuiteResult) {
+			status := "fail"
+			if result.Passed {
+				status = "pass"
+			}
+			fmt.Fprintf(cli.stdout, "%s  %-20s %-8s %s\n", status, result.ID, result.SAN, describeExpected(result.Record))
+		}
+	}
+	searcher := chess.NewSearcher(chess.DefaultHashSize)
+	report := searcher.RunSuite(records, chess.Limits{Depth: *depth, MoveTime: *moveTime}, onResult)
+
+	if *asJSON {
+		output := suiteReport{Tests: len(report.Results), Passed: report.Passed, Results: []suiteResult{}}
+		for _, result := range report.Results {
+			best, _ := result.Record.Operation("bm")
+			avoid, _ := result.Record.Operation("am")
+			output.Results = append(output.Results, suiteResult{
+				ID:     result.ID,
+				FEN:    result.Record.Position.GenerateFen(),
+				Best:   best.Operands,
+				Avoid:  avoid.Operands,
+				Found:  result.SAN,
+				Passed: result.Passed})
+		}
+		err = cli.printJSON(output)
+	} else {
+		fmt.Fprintf(cli.stdout, "\nPassed %d of %d\n", report.Passed, len(report.Results))
+	}
+	return err
+}
+
+// readEPDFile reads the records in a file, or stdin for "-"
+func readEPDFile(name string, stdin io.Reader) ([]*chess.EPD, error) {
+	if name == "-" {
+		return chess.ReadEPD(stdin)
+	}
+	file, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	records, err := chess.ReadEPD(file)
+	if _, skipped := err.(chess.EPDSkipped); err != nil && !skipped {
+		err = fmt.Errorf("%s: %v", name, err)
+	}
+	return records, err
+}
+
+// describeExpected says what a test wanted, as "bm Qxf7" or "am Ra8"
+func describeExpected(record *chess.EPD) string {
+	var retVal []string
+	for _, opcode := range []string{"bm", "am"} {
+		if operation, ok := record.Operation(opcode); ok {
+			retVal = append(retVal, opcode+" "+strings.Join(operation.Operands, " "))
+		}
+	}
+	return strings.Join(retVal, "; ")
+}
@@ -0,0 +1,37 @@
+// Command chessserver serves a board for playing against the package's own
+// search at /, and live games between people at /live/. Everything the
+// pages need is built in, so no internet access is required.
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/rkitts/chess"
+	"github.com/rkitts/chess/server"
+)
+
+func main() {
+	addr := flag.String("addr", "localhost:8080", "address to listen on")
+	depth := flag.Int("depth", server.DefaultBotLimits.Depth, "how many plies the bot searches")
+	moveTime := flag.Duration("movetime", server.DefaultBotLimits.MoveTime, "the longest the bot thinks about a move")
+	hash := flag.Int("hash", 4, "transposition table size per bot, in megabytes")
+	bots := flag.Int("bots", 4, "how many bot moves can be searched for at once")
+	maxGames := flag.Int("maxgames", 1000, "the most games kept at once")
+	idle := flag.Duration("idle", time.Hour, "how long a game is kept without requests")
+	flag.Parse()
+
+	mux := http.NewServeMux()
+	mux.Handle("/", server.New(server.Options{
+		BotLimits:   chess.Limits{Depth: *depth, MoveTime: *moveTime},
+		HashMB:      *hash,
+		Bots:        *bots,
+		MaxGames:    *maxGames,
+		IdleTimeout: *idle}))
+	mux.Handle("/live/", http.StripPrefix("/live", server.NewMultiplayer()))
+	httpServer := &http.Server{Addr: *addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	log.Printf("Listening on http://%s", *addr)
+	log.Fatal(httpServer.ListenAndServe())
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ANSI escape codes. Squares use the 256 colour palette, which every
+// terminal people are likely to SSH from supports.
+const (
+	escHome       = "\x1b[H"
+	escClearLine  = "\x1b[K"
+	escClearBelow = "\x1b[J"
+	escReset      = "\x1b[0m"
+	escBold       = "\x1b[1m"
+	escDim        = "\x1b[2m"
+	escReverse    = "\x1b[7m"
+	lightSquare   = "\x1b[48;5;180m"
+	darkSquare    = "\x1b[48;5;137m"
+	cursorSquare  = "\x1b[48;5;75m"
+	pickedSquare  = "\x1b[48;5;71m"
+	targetSquare  = "\x1b[48;5;108m"
+	lastMoveLight = "\x1b[48;5;186m"
+	lastMoveDark  = "\x1b[48;5;143m"
+	whitePiece    = "\x1b[1;97m"
+	blackPiece    = "\x1b[1;30m"
+)
+
+// The same glyph is used for both colours, which the foreground tells apart
+var glyphs = map[string]string{"k": "♚", "q": "♛", "r": "♜", "b": "♝", "n": "♞", "p": "♟"}
+
+// moveListRows is how many rows of the move list fit beside the board
+const moveListRows = 10
+
+const help = "arrows/hjkl move  enter pick/drop  m type move  u undo  r redo  f flip  s save  q quit"
+
+// draw returns the whole screen, from the top left corner
+func (ui *ui) draw() string {
+	var screen strings.Builder
+	screen.WriteString(escHome)
+	board := ui.boardLines()
+	moves := ui.moveListLines()
+	for cntr, line := range board {
+		screen.WriteString(line + "   ")
+		if cntr < len(moves) {
+			screen.WriteString(moves[cntr])
+		}
+		screen.WriteString(escClearLine + "\n")
+	}
+	screen.WriteString(escClearLine + "\n")
+	if clocks := ui.clockLine(); clocks != "" {
+		screen.WriteString(clocks + escClearLine + "\n")
+	}
+	screen.WriteString(escBold + ui.statusLine() + escReset + escClearLine + "\n")
+	screen.WriteString(ui.message + escClearLine + "\n")
+	if ui.prompt != "" {
+		screen.WriteString(ui.prompt + ui.input + "_" + escClearLine + "\n")
+	} else {
+		screen.WriteString(escDim + help + escReset + escClearLine + "\n")
+	}
+	screen.WriteString(escClearBelow)
+	return screen.String()
+}
+
+// boardLines draws the board with its coordinates, one string per line
+func (ui *ui) boardLines() []string {
+	files := "abcdefgh"
+	ranks := "87654321"
+	if ui.flipped {
+		files, ranks = "hgfedcba", "12345678"
+	}
+	var fileLabels strings.Builder
+	fileLabels.WriteString("  ")
+	for _, file := range files {
+		fileLabels.WriteString(" " + string(file) + " ")
+	}
+
+	lastFrom, lastTo := "", ""
+	if history := ui.game.History(); len(history) > 0 {
+		uci := history[len(history)-1].UCI()
+		lastFrom, lastTo = uci[0:2], uci[2:4]
+	}
+
+	pieces := placement(ui.game.GenerateFen())
+	retVal := []string{fileLabels.String()}
+	for _, rank := range ranks {
+		var line strings.Builder
+		line.WriteString(string(rank) + " ")
+		for _, file := range files {
+			square := string(file) + string(rank)
+			light := (file-'a'+rank-'1')%2 == 1
+			background := darkSquare
+			switch {
+			case square == ui.cursor:
+				background = cursorSquare
+			case square == ui.selected:
+				background = pickedSquare
+			case ui.targets[square]:
+				background = targetSquare
+			case (square == lastFrom || square == lastTo) && light:
+				background = lastMoveLight
+			case square == lastFrom || square == lastTo:
+				background = lastMoveDark
+			case light:
+				background = lightSquare
+			}
+			line.WriteString(background + ui.pieceOn(square, pieces) + escReset)
+		}
+		line.WriteString(" " + string(rank))
+		retVal = append(retVal, line.String())
+	}
+	return append(retVal, fileLabels.String())
+}
+
+// pieceOn draws what's on a square, three columns wide. pieces maps squares
+// to their FEN letters.
+func (ui *ui) pieceOn(square string, pieces map[string]string) string {
+	retVal := "   "
+	symbol, occupied := pieces[square]
+	if ui.targets[square] && !occupied {
+		retVal = " · "
+	} else if occupied {
+		color := blackPiece
+		if strings.ToUpper(symbol) == symbol {
+			color = whitePiece
+		}
+		shown := glyphs[strings.ToLower(symbol)]
+		if ui.ascii {
+			shown = symbol
+		}
+		retVal = color + " " + shown + " "
+	}
+	return retVal
+}
+
+// placement maps each occupied square to its piece's FEN letter
+func placement(fen string) map[string]string {
+	retVal := make(map[string]string)
+	for row, rank := range strings.Split(strings.Fields(fen)[0], "/") {
+		file := 0
+		for _, symbol := range rank {
+			if symbol >= '1' && symbol <= '8' {
+				file += int(symbol - '0')
+			} else {
+				retVal[fmt.Sprintf("%c%d", 'a'+file, 8-row)] = string(symbol)
+				file++
+			}
+		}
+	}
+	return retVal
+}
+
+// moveListLines numbers the moves played, with any that can be redone after
+// them dimmed. When they don't all fit the rows around the last move played
+// are shown.
+func (ui *ui) moveListLines() []string {
+	game := ui.game.PGNGame()
+	played := len(game.Moves)
+	sans := game.Moves
+	for cntr := len(ui.redo) - 1; cntr >= 0; cntr-- {
+		sans = append(sans, ui.redo[cntr].san)
+	}
+
+	moveNumber, blackFirst := 1, false
+	if fields := strings.Fields(ui.game.InitialFen()); len(fields) == 6 {
+		fmt.Sscan(fields[5], &moveNumber)
+		blackFirst = fields[1] == "b"
+	}
+	var retVal []string
+	var line strings.Builder
+	ply := 0
+	current := (played - 1) / 2
+	if blackFirst {
+		current = played / 2
+		line.WriteString(fmt.Sprintf("%3d. %-8s", moveNumber, "..."))
+		ply = 1
+	}
+	for cntr, san := range sans {
+		if ply%2 == 0 {
+			line.WriteString(fmt.Sprintf("%3d. ", moveNumber+ply/2))
+		}
+		shown := fmt.Sprintf("%-8s", san)
+		if cntr == played-1 {
+			shown = escReverse + shown + escReset
+		} else if cntr >= played {
+			shown = escDim + shown + escReset
+		}
+		line.WriteString(shown)
+		if ply%2 == 1 {
+			retVal = append(retVal, line.String())
+			line.Reset()
+		}
+		ply++
+	}
+	if line.Len() > 0 {
+		retVal = append(retVal, line.String())
+	}
+	if len(retVal) > moveListRows {
+		first := min(max(current-moveListRows/2, 0), len(retVal)-moveListRows)
+		retVal = retVal[first : first+moveListRows]
+	}
+	return retVal
+}
+
+// clockLine shows both players' time, or nothing in an untimed game
+func (ui *ui) clockLine() string {
+	retVal := ""
+	if ui.clock != nil {
+		retVal = "White " + formatClock(ui.clock.Remaining(white)) + "   Black " + formatClock(ui.clock.Remaining(black))
+	}
+	return retVal
+}
+
+// statusLine says whose move it is, or how the game ended
+func (ui *ui) statusLine() string {
+	retVal := "White to move"
+	if ui.game.Turn() == black {
+		retVal = "Black to move"
+	}
+	if outcome := ui.outcome(); outcome.Over() {
+		retVal = outcome.Result + " by " + outcome.Reason
+	} else if ui.thinking {
+		retVal += ", the bot is thinking"
+	} else if ui.game.InCheck() {
+		retVal += ", check"
+	}
+	return retVal
+}
+
+// formatClock shows time left as minutes and seconds, with tenths under ten seconds
+func formatClock(remaining time.Duration) string {
+	retVal := ""
+	if remaining < 0 {
+		remaining = 0
+	}
+	if remaining < 10*time.Second {
+		retVal = fmt.Sprintf("0:%04.1f", remaining.Seconds())
+	} else {
+		seconds := int(remaining.Seconds())
+		retVal = fmt.Sprintf("%d:%02d", seconds/60, seconds%60)
+	}
+	return retVal
+}
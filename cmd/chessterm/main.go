@@ -0,0 +1,158 @@
+// Command chessterm plays and reviews games full screen in a terminal, for
+// example over SSH. It draws with ANSI escape codes and needs nothing but a
+// terminal with 256 colours and stty.
+//
+// Move the cursor with the arrow keys or hjkl and press enter on a piece and
+// then on where it goes, or press m and type the move. u and r undo and redo,
+// f flips the board, s saves the game as PGN and q quits.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/rkitts/chess"
+)
+
+// How often the screen is redrawn when nothing happens, for the clocks
+const tickInterval = 100 * time.Millisecond
+
+// Escape codes for switching to and from the alternate screen, so the
+// terminal is left as it was
+const (
+	enterScreen = "\x1b[?1049h\x1b[?25l\x1b[H\x1b[2J"
+	leaveScreen = "\x1b[?25h\x1b[?1049l"
+)
+
+func main() {
+	var opts options
+	flag.StringVar(&opts.fen, "fen", "", "the position to start from")
+	flag.StringVar(&opts.pgn, "pgn", "", "a PGN file whose first game to review or carry on")
+	flag.StringVar(&opts.timeControl, "tc", "", "a time control as in the PGN tag, for example 300+3")
+	flag.StringVar(&opts.bot, "bot", "", "the colour the bot plays, w or b; leave it out to play both sides")
+	flag.IntVar(&opts.limits.Depth, "depth", 8, "how many plies the bot searches")
+	flag.DurationVar(&opts.limits.MoveTime, "movetime", 3*time.Second, "the longest the bot thinks about a move")
+	flag.BoolVar(&opts.ascii, "ascii", false, "draw pieces as letters, for fonts without chess symbols")
+	flag.Parse()
+
+	ui, err := newUI(opts)
+	if err == nil {
+		err = runTerminal(ui)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "chessterm: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// runTerminal runs the UI until it quits, putting the terminal back however it ends
+func runTerminal(ui *ui) error {
+	restore, err := cbreak()
+	if err != nil {
+		return err
+	}
+	defer restore()
+	fmt.Print(enterScreen)
+	defer fmt.Print(leaveScreen)
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+	keys := readKeys(os.Stdin)
+	botMoves := make(chan chess.Move, 1)
+	ticker := time.NewTicker(tickInterval)
+	defer ticker.Stop()
+
+	for !ui.quit {
+		ui.startBot(botMoves)
+		fmt.Print(ui.draw())
+		select {
+		case key, ok := <-keys:
+			if ok {
+				ui.handleKey(key)
+			}
+			ui.quit = ui.quit || !ok
+		case move := <-botMoves:
+			ui.botMoved(move)
+		case <-ticker.C:
+		case <-signals:
+			ui.quit = true
+		}
+	}
+	return nil
+}
+
+// cbreak turns off line buffering and echo on the terminal, and returns a
+// function that turns them back on
+func cbreak() (func(), error) {
+	saved, err := stty("-g")
+	if err == nil {
+		_, err = stty("-icanon", "-echo", "min", "1")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("stdin has to be a terminal: %v", err)
+	}
+	return func() { stty(strings.TrimSpace(saved)) }, nil
+}
+
+func stty(args ...string) (string, error) {
+	command := exec.Command("stty", args...)
+	command.Stdin = os.Stdin
+	output, err := command.Output()
+	return string(output), err
+}
+
+// readKeys reads keys from the terminal until it closes
+func readKeys(reader io.Reader) <-chan string {
+	retVal := make(chan string)
+	go func() {
+		defer close(retVal)
+		buffer := make([]byte, 64)
+		for {
+			count, err := reader.Read(buffer)
+			for _, key := range decodeKeys(buffer[:count]) {
+				retVal <- key
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+	return retVal
+}
+
+// decodeKeys splits what one read from the terminal returned into keys. An
+// escape sequence comes in one read, so an escape on its own is the key.
+func decodeKeys(input []byte) []string {
+	var retVal []string
+	arrows := map[byte]string{'A': keyUp, 'B': keyDown, 'C': keyRight, 'D': keyLeft}
+	for cntr := 0; cntr < len(input); cntr++ {
+		switch currByte := input[cntr]; {
+		case currByte == 0x1b && cntr+1 < len(input) && (input[cntr+1] == '[' || input[cntr+1] == 'O'):
+			// Skip any parameters to the final byte, which says which key it is
+			cntr += 2
+			for cntr < len(input) && input[cntr] >= '0' && input[cntr] <= '?' {
+				cntr++
+			}
+			if cntr < len(input) && arrows[input[cntr]] != "" {
+				retVal = append(retVal, arrows[input[cntr]])
+			}
+		case currByte == 0x1b:
+			retVal = append(retVal, keyEscape)
+		case currByte == '\r' || currByte == '\n':
+			retVal = append(retVal, keyEnter)
+		case currByte == 0x7f || currByte == 0x08:
+			retVal = append(retVal, keyBackspace)
+		case currByte >= ' ' && currByte <= '~':
+			retVal = append(retVal, string(rune(currByte)))
+		}
+	}
+	return retVal
+}
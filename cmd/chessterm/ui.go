@@ -0,0 +1,383 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/rkitts/chess"
+)
+
+// The colours as chess.Chess.Turn returns them
+const (
+	white = chess.PieceColor('w')
+	black = chess.PieceColor('b')
+)
+
+// Keys that aren't a single printable character
+const (
+	keyUp        = "up"
+	keyDown      = "down"
+	keyLeft      = "left"
+	keyRight     = "right"
+	keyEnter     = "enter"
+	keyEscape    = "esc"
+	keyBackspace = "backspace"
+)
+
+// options are the command line settings the UI starts from
+type options struct {
+	fen         string
+	pgn         string
+	timeControl string
+	bot         string
+	limits      chess.Limits
+	ascii       bool
+	now         func() time.Time
+}
+
+// redoEntry is an undone move, kept with its SAN so the move list can show it
+type redoEntry struct {
+	move chess.Move
+	san  string
+}
+
+// ui is the state of the terminal program. Keys and clock ticks change it
+// and draw shows it; it never touches the terminal itself.
+type ui struct {
+	game     *chess.Chess
+	clock    *chess.Clock
+	bot      chess.PieceColor
+	limits   chess.Limits
+	searcher *chess.Searcher
+	thinking bool
+	ascii    bool
+	flipped  bool
+	cursor   string
+	selected string
+	targets  map[string]bool
+	redo     []redoEntry
+	prompt   string
+	input    string
+	message  string
+	quit     bool
+}
+
+// newUI sets up the game to play or review from the options
+func newUI(opts options) (*ui, error) {
+	var err error
+	retVal := &ui{
+		game:     chess.New(),
+		limits:   opts.limits,
+		searcher: chess.NewSearcher(chess.DefaultHashSize),
+		ascii:    opts.ascii,
+		cursor:   "e2"}
+	if opts.fen != "" {
+		err = retVal.game.Load(opts.fen)
+	}
+	if err == nil && opts.pgn != "" {
+		retVal.game, err = loadPGN(opts.pgn)
+	}
+	if err == nil && opts.bot != "" {
+		if opts.bot != "w" && opts.bot != "b" {
+			err = fmt.Errorf("The bot plays w or b, not '%s'", opts.bot)
+		}
+		retVal.bot = chess.PieceColor(opts.bot[0])
+		retVal.flipped = retVal.bot == white
+	}
+	if err == nil && opts.timeControl != "" {
+		var control chess.TimeControl
+		control, err = chess.ParseTimeControl(opts.timeControl)
+		if err == nil {
+			retVal.clock, err = chess.NewClock(retVal.game, control, opts.now)
+		}
+		if err == nil {
+			retVal.clock.Start()
+		}
+	}
+	if retVal.flipped {
+		retVal.cursor = "e7"
+	}
+	return retVal, err
+}
+
+// loadPGN reads the first game in a PGN file
+func loadPGN(path string) (*chess.Chess, error) {
+	var retVal *chess.Chess
+	file, err := os.Open(path)
+	if err == nil {
+		defer file.Close()
+		var game *chess.PGNGame
+		game, err = chess.NewPGNReader(file).Next()
+		if err == nil {
+			retVal, err = game.Replay()
+		}
+	}
+	if err != nil {
+		err = fmt.Errorf("Couldn't load %s: %v", path, err)
+	}
+	return retVal, err
+}
+
+// outcome is how the game has ended, on the board or the clock
+func (ui *ui) outcome() chess.Outcome {
+	retVal := ui.game.Outcome()
+	if ui.clock != nil {
+		retVal = ui.clock.Outcome()
+	}
+	return retVal
+}
+
+// botToMove returns true if it's the bot's turn in a game that's still going
+func (ui *ui) botToMove() bool {
+	return ui.bot != 0 && ui.game.Turn() == ui.bot && !ui.outcome().Over()
+}
+
+// handleKey acts on a key, either editing the prompt or as a command
+func (ui *ui) handleKey(key string) {
+	if ui.prompt != "" {
+		ui.promptKey(key)
+		return
+	}
+	ui.message = ""
+	switch key {
+	case keyUp, "k":
+		ui.moveCursor(0, 1)
+	case keyDown, "j":
+		ui.moveCursor(0, -1)
+	case keyLeft, "h":
+		ui.moveCursor(-1, 0)
+	case keyRight, "l":
+		ui.moveCursor(1, 0)
+	case keyEnter, " ":
+		ui.choose()
+	case keyEscape:
+		ui.deselect()
+	case "f":
+		ui.flipped = !ui.flipped
+	case "m":
+		ui.prompt, ui.input = "Move: ", ""
+	case "s":
+		ui.prompt, ui.input = "Save to: ", "game.pgn"
+	case "u":
+		ui.undo()
+	case "r":
+		ui.redoMove()
+	case "q":
+		ui.quit = true
+	}
+}
+
+// promptKey edits the text being typed at the prompt, and acts on it on enter
+func (ui *ui) promptKey(key string) {
+	switch key {
+	case keyEscape:
+		ui.prompt = ""
+	case keyBackspace:
+		if len(ui.input) > 0 {
+			ui.input = ui.input[:len(ui.input)-1]
+		}
+	case keyEnter:
+		prompt, input := ui.prompt, strings.TrimSpace(ui.input)
+		ui.prompt = ""
+		if prompt == "Move: " && input != "" {
+			ui.typedMove(input)
+		} else if input != "" {
+			ui.save(input)
+		}
+	default:
+		if len(key) == 1 && key[0] >= ' ' && key[0] <= '~' {
+			ui.input += key
+		}
+	}
+}
+
+// moveCursor moves the cursor by files and ranks as the board is shown
+func (ui *ui) moveCursor(files int, ranks int) {
+	if ui.flipped {
+		files, ranks = -files, -ranks
+	}
+	file := int(ui.cursor[0]-'a') + files
+	rank := int(ui.cursor[1]-'1') + ranks
+	if file >= 0 && file < 8 && rank >= 0 && rank < 8 {
+		ui.cursor = fmt.Sprintf("%c%c", 'a'+file, '1'+rank)
+	}
+}
+
+// choose picks up the piece under the cursor, or plays the picked up piece
+// to the cursor. Promotions from the board are to a queen; type the move
+// for anything else.
+func (ui *ui) choose() {
+	if !ui.canMove() {
+		return
+	}
+	if ui.selected == "" || ui.selected == ui.cursor {
+		if ui.selected == ui.cursor {
+			ui.deselect()
+			return
+		}
+		ui.selected = ui.cursor
+		ui.targets = make(map[string]bool)
+		for _, move := range ui.game.Moves(true, ui.cursor) {
+			ui.targets[move.UCI()[2:4]] = true
+		}
+		if len(ui.targets) == 0 {
+			ui.deselect()
+		}
+		return
+	}
+	if !ui.targets[ui.cursor] {
+		// Picking up another piece instead
+		ui.deselect()
+		ui.choose()
+		return
+	}
+	for _, move := range ui.game.Moves(true, ui.selected) {
+		if uci := move.UCI(); uci[2:4] == ui.cursor && (len(uci) == 4 || uci[4] == 'q') {
+			ui.play(move)
+			break
+		}
+	}
+}
+
+func (ui *ui) deselect() {
+	ui.selected, ui.targets = "", nil
+}
+
+// typedMove plays a typed move, read however it's written
+func (ui *ui) typedMove(typed string) {
+	if !ui.canMove() {
+		return
+	}
+	move, err := ui.game.SloppySANToMove(typed)
+	if err != nil {
+		ui.message = err.Error()
+	} else {
+		ui.play(move)
+	}
+}
+
+// canMove checks that a person may move now, saying why not if they can't
+func (ui *ui) canMove() bool {
+	retVal := false
+	switch {
+	case ui.outcome().Over():
+		ui.message = "The game is over"
+	case ui.thinking || ui.botToMove():
+		ui.message = "Wait for the bot to move"
+	default:
+		retVal = true
+	}
+	return retVal
+}
+
+// play makes a move, through the clock if there is one
+func (ui *ui) play(move chess.Move) {
+	var err error
+	if ui.clock != nil {
+		err = ui.clock.MakeMove(move)
+	} else {
+		ui.game.MakeMove(move)
+	}
+	if err != nil {
+		ui.message = err.Error()
+	}
+	ui.redo = nil
+	ui.deselect()
+}
+
+// undo takes back a move, or against the bot the bot's reply and the move
+// before it. Timed games can't be taken back.
+func (ui *ui) undo() {
+	plies := 1
+	if ui.bot != 0 && ui.game.Turn() != ui.bot && len(ui.game.History()) >= 2 {
+		plies = 2
+	}
+	switch {
+	case ui.clock != nil:
+		ui.message = "Moves can't be taken back in a timed game"
+	case ui.thinking:
+		ui.message = "Wait for the bot to move"
+	case len(ui.game.History()) == 0:
+		ui.message = "Nothing to undo"
+	default:
+		for cntr := 0; cntr < plies; cntr++ {
+			move, _ := ui.game.Undo()
+			ui.redo = append(ui.redo, redoEntry{move: move, san: ui.game.SAN(move)})
+		}
+	}
+	ui.deselect()
+}
+
+// redoMove plays the last move taken back, and the bot's reply after it
+func (ui *ui) redoMove() {
+	plies := 1
+	if ui.bot != 0 && len(ui.redo) >= 2 {
+		plies = 2
+	}
+	switch {
+	case ui.clock != nil:
+		ui.message = "Moves can't be taken back in a timed game"
+	case len(ui.redo) == 0:
+		ui.message = "Nothing to redo"
+	default:
+		for cntr := 0; cntr < plies; cntr++ {
+			ui.game.MakeMove(ui.redo[len(ui.redo)-1].move)
+			ui.redo = ui.redo[:len(ui.redo)-1]
+		}
+	}
+	ui.deselect()
+}
+
+// save writes the game to a PGN file
+func (ui *ui) save(path string) {
+	game := ui.game.PGNGame()
+	if outcome := ui.outcome(); outcome.Over() {
+		game.Result = outcome.Result
+	}
+	file, err := os.Create(path)
+	if err == nil {
+		err = game.Write(file)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	ui.message = "Saved to " + path
+	if err != nil {
+		ui.message = err.Error()
+	}
+}
+
+// startBot searches for the bot's move on a copy of the game, so drawing
+// can carry on, and sends the move on the channel
+func (ui *ui) startBot(moves chan<- chess.Move) {
+	if ui.thinking || !ui.botToMove() {
+		return
+	}
+	ui.thinking = true
+	copied := chess.New()
+	copied.Load(ui.game.InitialFen())
+	for _, move := range ui.game.History() {
+		copied.MakeMove(move)
+	}
+	limits := ui.limits
+	if ui.clock != nil {
+		// Leave time for the rest of the game
+		budget := ui.clock.Remaining(ui.bot) / 30
+		if limits.MoveTime == 0 || budget < limits.MoveTime {
+			limits.MoveTime = budget
+		}
+	}
+	go func() {
+		moves <- ui.searcher.Search(copied, limits).BestMove
+	}()
+}
+
+// botMoved plays the move the bot found
+func (ui *ui) botMoved(move chess.Move) {
+	ui.thinking = false
+	if ui.botToMove() {
+		ui.play(move)
+	}
+}
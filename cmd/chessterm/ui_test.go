@@ -0,0 +1,228 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rkitts/chess"
+)
+
+func pressKeys(ui *ui, keys ...string) {
+	for _, key := range keys {
+		ui.handleKey(key)
+	}
+}
+
+func typeText(ui *ui, text string) {
+	for _, char := range text {
+		ui.handleKey(string(char))
+	}
+}
+
+func TestDecodeKeys(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []string
+	}{
+		{"\x1b[A\x1b[B\x1bOC\x1b[D", []string{keyUp, keyDown, keyRight, keyLeft}},
+		{"\x1b", []string{keyEscape}},
+		{"\x1b[1;5A", []string{keyUp}},
+		{"\x1b[3~x", []string{"x"}},
+		{"e4\n\x7f\r", []string{"e", "4", keyEnter, keyBackspace, keyEnter}},
+	}
+	for _, test := range tests {
+		if keys := decodeKeys([]byte(test.input)); !reflect.DeepEqual(keys, test.expected) {
+			t.Errorf("Expected %v for %q, got %v", test.expected, test.input, keys)
+		}
+	}
+}
+
+func TestCursorFollowsTheBoard(t *testing.T) {
+	ui, _ := newUI(options{})
+	pressKeys(ui, keyUp, keyUp, "l", keyLeft, keyLeft)
+	if ui.cursor != "d4" {
+		t.Errorf("Expected d4, got %s", ui.cursor)
+	}
+	pressKeys(ui, "f", keyUp, keyRight)
+	if ui.cursor != "c3" {
+		t.Errorf("Expected the flipped board to move the other way, got %s", ui.cursor)
+	}
+	pressKeys(ui, "f", keyLeft, keyLeft, keyLeft, keyLeft)
+	if ui.cursor != "a3" {
+		t.Errorf("Expected the cursor to stop at the edge, got %s", ui.cursor)
+	}
+}
+
+func TestMovingWithTheCursor(t *testing.T) {
+	ui, _ := newUI(options{})
+	pressKeys(ui, keyEnter)
+	if ui.selected != "e2" || !reflect.DeepEqual(ui.targets, map[string]bool{"e3": true, "e4": true}) {
+		t.Errorf("Expected e2 picked up with e3 and e4 highlighted, got %s %v", ui.selected, ui.targets)
+	}
+	if screen := ui.draw(); !strings.Contains(screen, targetSquare+" · ") {
+		t.Errorf("Expected the empty targets marked")
+	}
+	pressKeys(ui, keyUp, keyUp, keyEnter)
+	if history := ui.game.History(); len(history) != 1 || history[0].UCI() != "e2e4" || ui.selected != "" {
+		t.Errorf("Expected e4 played, got %v", history)
+	}
+
+	// Picking another piece switches to it, unless it can't move
+	pressKeys(ui, keyUp, keyUp, keyUp, keyEnter)
+	if ui.selected != "e7" {
+		t.Errorf("Expected e7 picked up, got %s", ui.selected)
+	}
+	pressKeys(ui, keyUp, "l", "l", keyEnter)
+	if ui.selected != "g8" || len(ui.targets) != 2 {
+		t.Errorf("Expected the knight picked up instead, got %s %v", ui.selected, ui.targets)
+	}
+	pressKeys(ui, "h", keyEnter)
+	if ui.selected != "" {
+		t.Errorf("Expected the bishop, which can't move, not to be picked up, got %s", ui.selected)
+	}
+	pressKeys(ui, "l", keyEnter)
+	pressKeys(ui, keyEscape)
+	if ui.selected != "" {
+		t.Errorf("Expected escape to put the piece down")
+	}
+}
+
+func TestTypedMovesUndoAndRedo(t *testing.T) {
+	ui, _ := newUI(options{})
+	for _, san := range []string{"e4", "e5", "Nf3"} {
+		pressKeys(ui, "m")
+		typeText(ui, san)
+		pressKeys(ui, keyEnter)
+	}
+	pressKeys(ui, "m")
+	typeText(ui, "Qxx")
+	pressKeys(ui, keyBackspace, keyBackspace, keyEnter)
+	if ui.message != "Can't read 'Q' as a move" || len(ui.game.History()) != 3 {
+		t.Errorf("Expected the bad move refused, got %s", ui.message)
+	}
+
+	pressKeys(ui, "u", "u")
+	if len(ui.game.History()) != 1 || len(ui.redo) != 2 {
+		t.Errorf("Expected two moves undone, got %d", len(ui.game.History()))
+	}
+	if moves := strings.Join(ui.moveListLines(), ""); !strings.Contains(moves, escDim+"e5") || !strings.Contains(moves, escReverse+"e4") {
+		t.Errorf("Expected undone moves dimmed in %q", moves)
+	}
+	pressKeys(ui, "r")
+	if len(ui.game.History()) != 2 || len(ui.redo) != 1 {
+		t.Errorf("Expected e5 redone")
+	}
+	pressKeys(ui, "m")
+	typeText(ui, "g1f3")
+	pressKeys(ui, keyEnter)
+	if len(ui.redo) != 0 || ui.game.PGNGame().Moves[2] != "Nf3" {
+		t.Errorf("Expected a new move to clear redo, got %v", ui.redo)
+	}
+	pressKeys(ui, "r")
+	if ui.message != "Nothing to redo" {
+		t.Errorf("Expected nothing to redo, got %s", ui.message)
+	}
+}
+
+func TestSaveAndLoadPGN(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "saved.pgn")
+	ui, _ := newUI(options{})
+	for _, san := range []string{"f3", "e5", "g4", "Qh4"} {
+		ui.typedMove(san)
+	}
+	pressKeys(ui, "s")
+	for range "game.pgn" {
+		pressKeys(ui, keyBackspace)
+	}
+	typeText(ui, path)
+	pressKeys(ui, keyEnter)
+	saved, err := os.ReadFile(path)
+	if err != nil || !strings.Contains(string(saved), "1. f3 e5 2. g4 Qh4# 0-1") {
+		t.Errorf("Expected the game saved, got %s %v", saved, err)
+	}
+
+	loaded, err := newUI(options{pgn: path})
+	if err != nil || len(loaded.game.History()) != 4 || loaded.statusLine() != "0-1 by checkmate" {
+		t.Errorf("Expected the saved game to load, got %v", err)
+	}
+	if _, err := newUI(options{pgn: filepath.Join(t.TempDir(), "missing.pgn")}); err == nil {
+		t.Errorf("Expected an error for a missing file")
+	}
+}
+
+func TestClocks(t *testing.T) {
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	ui, err := newUI(options{timeControl: "60+1", now: func() time.Time { return now }})
+	if err != nil {
+		t.Fatalf("Got an error %v", err)
+	}
+	now = now.Add(5 * time.Second)
+	ui.typedMove("e4")
+	now = now.Add(52 * time.Second)
+	if clocks := ui.clockLine(); clocks != "White 0:56   Black 0:08.0" {
+		t.Errorf("Unexpected clocks %s", clocks)
+	}
+	pressKeys(ui, "u")
+	if len(ui.game.History()) != 1 {
+		t.Errorf("Expected no takebacks in a timed game")
+	}
+	now = now.Add(10 * time.Second)
+	if status := ui.statusLine(); status != "1-0 by timeout" {
+		t.Errorf("Expected black to lose on time, got %s", status)
+	}
+	ui.typedMove("e5")
+	if ui.message != "The game is over" {
+		t.Errorf("Expected no moves after the flag fell, got %s", ui.message)
+	}
+}
+
+func TestBot(t *testing.T) {
+	ui, err := newUI(options{bot: "b", limits: chess.Limits{Depth: 1}})
+	if err != nil {
+		t.Fatalf("Got an error %v", err)
+	}
+	moves := make(chan chess.Move, 1)
+	ui.startBot(moves)
+	if ui.thinking {
+		t.Errorf("Expected the bot to wait for white")
+	}
+	ui.typedMove("e4")
+	ui.startBot(moves)
+	ui.typedMove("d4")
+	if ui.message != "Wait for the bot to move" || !strings.Contains(ui.statusLine(), "thinking") {
+		t.Errorf("Expected to wait for the bot, got %s", ui.message)
+	}
+	ui.botMoved(<-moves)
+	if len(ui.game.History()) != 2 || ui.thinking {
+		t.Errorf("Expected the bot to have replied")
+	}
+	pressKeys(ui, "u")
+	if len(ui.game.History()) != 0 {
+		t.Errorf("Expected undo to take back the bot's reply too, got %d", len(ui.game.History()))
+	}
+	if _, err := newUI(options{bot: "x"}); err == nil {
+		t.Errorf("Expected an error for a bad bot colour")
+	}
+}
+
+func TestDraw(t *testing.T) {
+	ui, _ := newUI(options{ascii: true})
+	ui.typedMove("e4")
+	screen := ui.draw()
+	for _, expected := range []string{"   a  b  c  d  e  f  g  h", "  1. " + escReverse + "e4", "Black to move", help} {
+		if !strings.Contains(screen, expected) {
+			t.Errorf("Expected %q on the screen", expected)
+		}
+	}
+	if !strings.Contains(screen, lastMoveLight+whitePiece+" P ") {
+		t.Errorf("Expected the last move highlighted")
+	}
+	pressKeys(ui, "f")
+	if screen := ui.draw(); !strings.Contains(screen, "   h  g  f  e  d  c  b  a") {
+		t.Errorf("Expected the board flipped")
+	}
+}
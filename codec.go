@@ -0,0 +1,250 @@
+package chess
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+	"sort"
+)
+
+// PackedPositionSize is the size of a PackedPosition in bytes
+const PackedPositionSize = 30
+
+// PackedPosition is a position in a fixed 30 bytes:
+//
+//	0-7    a bitboard of the occupied squares, a1 the lowest bit and h8 the highest
+//	8-23   a nibble per occupied square, in the bitboard's order, high nibble
+//	       first: the piece's shift, plus 8 for black
+//	24     bit 0 set for black to move, bits 1-4 castling rights as in KQkq
+//	25     the en passant file, or 0xff if there's no legal en passant capture
+//	26-27  the half move clock, big endian
+//	28-29  the full move number, big endian
+type PackedPosition [PackedPositionSize]byte
+
+const noEnpassant = 0xff
+
+// pieceShifts is the piece type for each shift, the reverse of shifts
+var pieceShifts = []PieceType{pawn, knight, bishop, rook, queen, king}
+
+// Pack encodes the current position. It fails if there are more than 32
+// pieces on the board, or a move counter doesn't fit in 16 bits.
+func (chess *Chess) Pack() (PackedPosition, error) {
+	var retVal PackedPosition
+	var err error
+	var occupied uint64
+	pieces := 0
+	for bit := 0; bit < 64 && err == nil; bit++ {
+		piece := chess.board[bitToSquare(bit)]
+		if piece.IsUnspecified() {
+			continue
+		}
+		if pieces == 32 {
+			err = fmt.Errorf("Can't pack more than 32 pieces")
+			break
+		}
+		nibble := byte(shifts[piece.ptype])
+		if piece.pcolor == black {
+			nibble |= 8
+		}
+		if pieces%2 == 0 {
+			nibble <<= 4
+		}
+		retVal[8+pieces/2] |= nibble
+		occupied |= 1 << uint(bit)
+		pieces++
+	}
+	if err == nil && (chess.halfMoves > 0xffff || chess.moveNumber > 0xffff || chess.halfMoves < 0 || chess.moveNumber < 0) {
+		err = fmt.Errorf("Can't pack move counters %d and %d", chess.halfMoves, chess.moveNumber)
+	}
+	binary.BigEndian.PutUint64(retVal[0:8], occupied)
+	if chess.turn == black {
+		retVal[24] |= 1
+	}
+	for cntr, right := range []bool{
+		chess.castling[white]&ksideCastleMove != 0, chess.castling[white]&qsideCastleMove != 0,
+		chess.castling[black]&ksideCastleMove != 0, chess.castling[black]&qsideCastleMove != 0} {
+		if right {
+			retVal[24] |= 2 << uint(cntr)
+		}
+	}
+	// Only a capture that can be made changes the position, so the same
+	// position always packs the same
+	retVal[25] = noEnpassant
+	if chess.enpassantCaptureLegal() {
+		retVal[25] = byte(file(chess.enpassantSquare))
+	}
+	binary.BigEndian.PutUint16(retVal[26:28], uint16(chess.halfMoves))
+	binary.BigEndian.PutUint16(retVal[28:30], uint16(chess.moveNumber))
+	return retVal, err
+}
+
+// Unpack decodes the position into a new game
+func (packed PackedPosition) Unpack() (*Chess, error) {
+	var err error
+	retVal := new(Chess)
+	retVal.Clear()
+	occupied := binary.BigEndian.Uint64(packed[0:8])
+	if bits.OnesCount64(occupied) > 32 {
+		err = fmt.Errorf("Packed position has %d pieces", bits.OnesCount64(occupied))
+	}
+	for pieces := 0; occupied != 0 && err == nil; pieces++ {
+		bit := bits.TrailingZeros64(occupied)
+		occupied &= occupied - 1
+		nibble := packed[8+pieces/2]
+		if pieces%2 == 0 {
+			nibble >>= 4
+		}
+		nibble &= 0xf
+		piece := Piece{ptype: king, pcolor: white}
+		if nibble&8 != 0 {
+			piece.pcolor = black
+		}
+		if int(nibble&7) >= len(pieceShifts) {
+			err = fmt.Errorf("Packed position has an unknown piece %x", nibble)
+		} else {
+			piece.ptype = pieceShifts[nibble&7]
+			err = retVal.maybeUpdateKings(piece, bitToSquare(bit))
+			retVal.board[bitToSquare(bit)] = piece
+		}
+	}
+
+	flags := packed[24]
+	if flags&0xe0 != 0 {
+		err = fmt.Errorf("Packed position has unknown flags %x", flags)
+	}
+	if flags&1 != 0 {
+		retVal.turn = black
+	}
+	for cntr, castle := range []struct {
+		color PieceColor
+		flag  int
+	}{{white, ksideCastleMove}, {white, qsideCastleMove}, {black, ksideCastleMove}, {black, qsideCastleMove}} {
+		if flags&(2<<uint(cntr)) != 0 {
+			retVal.castling[castle.color] |= castle.flag
+		}
+	}
+	if enpassant := packed[25]; enpassant != noEnpassant && enpassant > 7 {
+		err = fmt.Errorf("Packed position has an unknown en passant file %d", enpassant)
+	} else if enpassant != noEnpassant {
+		// The pawn that can be taken has just moved, so it's the other side's
+		retVal.enpassantSquare = rank6*16 + int(enpassant)
+		if retVal.turn == black {
+			retVal.enpassantSquare = rank3*16 + int(enpassant)
+		}
+	}
+	retVal.halfMoves = int(binary.BigEndian.Uint16(packed[26:28]))
+	retVal.moveNumber = int(binary.BigEndian.Uint16(packed[28:30]))
+	if err != nil {
+		return nil, err
+	}
+	retVal.updateSetup(retVal.GenerateFen())
+	return retVal, nil
+}
+
+// MarshalBinary returns the packed position's bytes
+func (packed PackedPosition) MarshalBinary() ([]byte, error) {
+	return packed[:], nil
+}
+
+// UnmarshalBinary sets the packed position from bytes, checking that they
+// are a position
+func (packed *PackedPosition) UnmarshalBinary(data []byte) error {
+	var decoded PackedPosition
+	if len(data) != PackedPositionSize {
+		return fmt.Errorf("A packed position is %d bytes, not %d", PackedPositionSize, len(data))
+	}
+	copy(decoded[:], data)
+	_, err := decoded.Unpack()
+	if err == nil {
+		*packed = decoded
+	}
+	return err
+}
+
+// MarshalBinary encodes the game: the position it started from, packed, the
+// number of moves as a uvarint, and each move as a byte, its index in the
+// sorted list of legal moves. The header isn't included.
+func (chess *Chess) MarshalBinary() ([]byte, error) {
+	start := chess.Clone()
+	for start.history.Len() > 0 {
+		start.Undo()
+	}
+	packed, err := start.Pack()
+	if err != nil {
+		return nil, err
+	}
+	history := chess.History()
+	retVal := append(packed[:], binary.AppendUvarint(nil, uint64(len(history)))...)
+	for cntr := 0; cntr < len(history) && err == nil; cntr++ {
+		index := -1
+		for moveNum, move := range start.sortedMoves() {
+			if move == history[cntr] {
+				index = moveNum
+			}
+		}
+		if index < 0 {
+			err = fmt.Errorf("Move %d isn't legal", cntr+1)
+		} else {
+			retVal = append(retVal, byte(index))
+			start.makeMove(history[cntr])
+		}
+	}
+	return retVal, err
+}
+
+// UnmarshalBinary replaces the game with one encoded by MarshalBinary
+func (chess *Chess) UnmarshalBinary(data []byte) error {
+	var packed PackedPosition
+	var decoded *Chess
+	var moves []byte
+	err := packed.UnmarshalBinary(data[:min(len(data), PackedPositionSize)])
+	if err == nil {
+		decoded, err = packed.Unpack()
+	}
+	if err == nil {
+		count, read := binary.Uvarint(data[PackedPositionSize:])
+		moves = data[PackedPositionSize+max(read, 0):]
+		if read <= 0 || count != uint64(len(moves)) {
+			err = fmt.Errorf("Encoded game has the wrong number of moves")
+		}
+	}
+	for cntr := 0; cntr < len(moves) && err == nil; cntr++ {
+		legal := decoded.sortedMoves()
+		if int(moves[cntr]) >= len(legal) {
+			err = fmt.Errorf("Move %d is number %d of %d legal moves", cntr+1, moves[cntr], len(legal))
+		} else {
+			decoded.makeMove(legal[moves[cntr]])
+		}
+	}
+	if err == nil {
+		*chess = *decoded
+	}
+	return err
+}
+
+// sortedMoves returns the legal moves in the order the game encoding
+// numbers them: by from square, then to square, then promotion
+func (chess *Chess) sortedMoves() []Move {
+	retVal := chess.Moves(true, "")
+	sort.Slice(retVal, func(i, j int) bool {
+		first, second := retVal[i], retVal[j]
+		if first.from != second.from {
+			return first.from < second.from
+		}
+		if first.to != second.to {
+			return first.to < second.to
+		}
+		return first.promotedType < second.promotedType
+	})
+	return retVal
+}
+
+// bitToSquare converts a bitboard bit, a1 = 0 to h8 = 63, to a board square
+func bitToSquare(bit int) int {
+	return (7-bit/8)*16 + bit%8
+}
+
+// squareToBit is the reverse of bitToSquare
+func squareToBit(square int) int {
+	return (7-square/16)*8 + square%16
+}
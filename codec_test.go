@@ -0,0 +1,126 @@
+package chess
+
+import (
+	"bytes"
+	"encoding"
+	"testing"
+)
+
+var _ encoding.BinaryMarshaler = PackedPosition{}
+var _ encoding.BinaryUnmarshaler = &PackedPosition{}
+var _ encoding.BinaryMarshaler = New()
+var _ encoding.BinaryUnmarshaler = New()
+
+func TestPackRoundTrip(t *testing.T) {
+	for _, fen := range []string{
+		defaultPosition,
+		"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1",
+		"rnbqkbnr/ppp1p1pp/8/3pPp2/8/8/PPPP1PPP/RNBQKBNR w KQkq f6 0 3",
+		"rnbqkbnr/pppp1ppp/8/8/3Pp3/8/PPP1PPPP/RNBQKBNR b Kq d3 0 3",
+		"8/8/8/8/8/8/6k1/4K3 b - - 57 300",
+	} {
+		chess := New()
+		chess.Load(fen)
+		packed, err := chess.Pack()
+		if err != nil {
+			t.Errorf("Couldn't pack %s: %v", fen, err)
+			continue
+		}
+		data, _ := packed.MarshalBinary()
+		var decoded PackedPosition
+		if err = decoded.UnmarshalBinary(data); err != nil || len(data) != PackedPositionSize {
+			t.Errorf("Couldn't unmarshal %s: %v", fen, err)
+			continue
+		}
+		unpacked, err := decoded.Unpack()
+		if err != nil || unpacked.GenerateFen() != fen {
+			t.Errorf("Expected %s, got %v %v", fen, unpacked, err)
+		}
+	}
+}
+
+func TestPackPromotedPieces(t *testing.T) {
+	// Two white queens, which a FEN can't load
+	chess := New()
+	chess.Load("4k3/1P6/8/8/8/8/8/3QK3 w - - 0 1")
+	chess.Move("b8=Q")
+	packed, _ := chess.Pack()
+	unpacked, err := packed.Unpack()
+	if err != nil || unpacked.GenerateFen() != "1Q2k3/8/8/8/8/8/8/3QK3 b - - 0 1" {
+		t.Errorf("Unexpected position %v %v", unpacked, err)
+	}
+}
+
+func TestPackOnlyCapturableEnpassant(t *testing.T) {
+	for _, test := range []struct {
+		fen     string
+		without string
+	}{
+		// Nothing next to the pawn that moved
+		{"rnbqkbnr/pppp1ppp/8/4p3/4P3/8/PPPP1PPP/RNBQKBNR w KQkq e6 0 2",
+			"rnbqkbnr/pppp1ppp/8/4p3/4P3/8/PPPP1PPP/RNBQKBNR w KQkq - 0 2"},
+		// Taking would leave the king in check along the rank
+		{"8/8/8/K2pP2r/8/8/8/7k w - d6 0 1", "8/8/8/K2pP2r/8/8/8/7k w - - 0 1"},
+	} {
+		chess := New()
+		chess.Load(test.fen)
+		packed, _ := chess.Pack()
+		chess.Load(test.without)
+		expected, _ := chess.Pack()
+		if packed != expected {
+			t.Errorf("Expected %s to pack like %s", test.fen, test.without)
+		}
+	}
+}
+
+func TestUnpackErrors(t *testing.T) {
+	var packed PackedPosition
+	if err := packed.UnmarshalBinary(make([]byte, 29)); err == nil {
+		t.Errorf("Expected an error for the wrong size")
+	}
+	bad := make([]byte, PackedPositionSize)
+	bad[7], bad[8] = 1, 0x70
+	if err := packed.UnmarshalBinary(bad); err == nil {
+		t.Errorf("Expected an error for an unknown piece")
+	}
+	bad[7], bad[8], bad[25] = 0, 0, 9
+	if err := packed.UnmarshalBinary(bad); err == nil {
+		t.Errorf("Expected an error for a bad en passant file")
+	}
+}
+
+func TestGameRoundTrip(t *testing.T) {
+	chess := New()
+	chess.Load("r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1")
+	for _, san := range []string{"a4", "bxa3", "O-O", "O-O-O", "Nxf7", "axb2", "Nxd8", "bxa1=N"} {
+		if err := chess.Move(san); err != nil {
+			t.Fatalf("Couldn't play %s: %v", san, err)
+		}
+	}
+	data, err := chess.MarshalBinary()
+	if err != nil || len(data) != PackedPositionSize+1+8 {
+		t.Fatalf("Unexpected encoding %v %v", data, err)
+	}
+
+	decoded := New()
+	if err = decoded.UnmarshalBinary(data); err != nil {
+		t.Fatalf("Couldn't decode the game: %v", err)
+	}
+	if decoded.GenerateFen() != chess.GenerateFen() || decoded.InitialFen() != chess.InitialFen() {
+		t.Errorf("Expected %s, got %s", chess.GenerateFen(), decoded.GenerateFen())
+	}
+	if again, _ := decoded.MarshalBinary(); !bytes.Equal(again, data) {
+		t.Errorf("Expected the same encoding again")
+	}
+	if len(decoded.History()) != 8 || decoded.History()[7].UCI() != "b2a1n" {
+		t.Errorf("Unexpected history %v", decoded.History())
+	}
+
+	if err = decoded.UnmarshalBinary(data[:len(data)-1]); err == nil {
+		t.Errorf("Expected an error for a truncated game")
+	}
+	data[len(data)-1] = 250
+	if err = decoded.UnmarshalBinary(data); err == nil {
+		t.Errorf("Expected an error for a move past the legal moves")
+	}
+}
@@ -0,0 +1,419 @@
+// Package db stores PGN games on disk, indexed by every position they reach
+// and by their main headers, so large collections can be searched without
+// replaying the games.
+//
+// A database is a directory of three files. games.pgn has the games in PGN
+// export format. games.jsonl has a line per game with its headers and where
+// its PGN is, and is read into memory to index the headers when the database
+// is opened. positions.idx has a record per position and game, sorted by the
+// position's Polyglot key, and is binary searched on disk.
+package db
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/rkitts/chess"
+)
+
+const (
+	gamesFile     = "games.pgn"
+	headersFile   = "games.jsonl"
+	positionsFile = "positions.idx"
+)
+
+// maxPendingPositions is how many position records an import holds in
+// memory before merging them into the index. It's a variable so tests can
+// make imports merge more often.
+var maxPendingPositions = 1 << 22
+
+// GameInfo is what the database knows about a game without reading it. IDs
+// count up from 0 in the order games were imported.
+type GameInfo struct {
+	ID       int    `json:"id"`
+	White    string `json:"white"`
+	Black    string `json:"black"`
+	WhiteElo int    `json:"whiteElo,omitempty"`
+	BlackElo int    `json:"blackElo,omitempty"`
+	Event    string `json:"event"`
+	Site     string `json:"site"`
+	Date     string `json:"date"`
+	Round    string `json:"round"`
+	ECO      string `json:"eco,omitempty"`
+	Result   string `json:"result"`
+	Plies    int    `json:"plies"`
+}
+
+// ImportResult counts the games an import added, and the ones it skipped
+// because their moves couldn't be replayed
+type ImportResult struct {
+	Imported  int
+	Skipped   int
+	Positions int
+}
+
+// record is a line of games.jsonl
+type record struct {
+	GameInfo
+	Offset int64 `json:"offset"`
+	Length int   `json:"length"`
+}
+
+// DB is an open database. It's safe for concurrent use; searches wait while
+// games are being imported.
+type DB struct {
+	mutex     sync.RWMutex
+	dir       string
+	records   []record
+	fields    map[string]map[string][]int
+	dates     sortedIndex
+	ecos      sortedIndex
+	games     *os.File
+	positions *positionIndex
+}
+
+// sortedIndex indexes a header by its value in order, for finding ranges of
+// dates and prefixes of ECO codes
+type sortedIndex []sortedEntry
+
+type sortedEntry struct {
+	value string
+	id    int
+}
+
+// Open opens the database in dir, creating it if it doesn't exist
+func Open(dir string) (*DB, error) {
+	retVal := &DB{dir: dir}
+	err := os.MkdirAll(dir, 0755)
+	if err == nil {
+		retVal.games, err = os.OpenFile(filepath.Join(dir, gamesFile), os.O_RDONLY|os.O_CREATE, 0644)
+	}
+	if err == nil {
+		retVal.positions, err = openPositionIndex(filepath.Join(dir, positionsFile))
+	}
+	if err == nil {
+		err = retVal.loadHeaders()
+	}
+	if err != nil {
+		retVal.Close()
+		retVal = nil
+	}
+	return retVal, err
+}
+
+// Close closes the database's files
+func (db *DB) Close() error {
+	var err error
+	if db.games != nil {
+		err = db.games.Close()
+	}
+	if db.positions != nil {
+		if closeErr := db.positions.close(); err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+// Len returns how many games there are
+func (db *DB) Len() int {
+	db.mutex.RLock()
+	defer db.mutex.RUnlock()
+	return len(db.records)
+}
+
+// loadHeaders reads games.jsonl and indexes the headers
+func (db *DB) loadHeaders() error {
+	db.fields = make(map[string]map[string][]int)
+	db.records, db.dates, db.ecos = nil, nil, nil
+	file, err := os.Open(filepath.Join(db.dir, headersFile))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() && err == nil {
+		var loaded record
+		if err = json.Unmarshal(scanner.Bytes(), &loaded); err == nil {
+			db.add(loaded)
+		}
+	}
+	if err == nil {
+		err = scanner.Err()
+	}
+	db.dates.sort()
+	db.ecos.sort()
+	if err != nil {
+		err = fmt.Errorf("Reading %s: %v", headersFile, err)
+	}
+	return err
+}
+
+// add adds a game's record and indexes its headers. The sorted indexes have
+// to be sorted again after adding.
+func (db *DB) add(added record) {
+	db.records = append(db.records, added)
+	for field, value := range map[string]string{
+		"white": added.White, "black": added.Black, "event": added.Event,
+		"site": added.Site, "result": added.Result} {
+		if db.fields[field] == nil {
+			db.fields[field] = make(map[string][]int)
+		}
+		key := normalize(value)
+		db.fields[field][key] = append(db.fields[field][key], added.ID)
+	}
+	db.dates = append(db.dates, sortedEntry{normalizeDate(added.Date), added.ID})
+	db.ecos = append(db.ecos, sortedEntry{strings.ToUpper(added.ECO), added.ID})
+}
+
+// sort puts the index in order of value, keeping games with the same value
+// in the order they were added
+func (index sortedIndex) sort() {
+	sort.SliceStable(index, func(i, j int) bool { return index[i].value < index[j].value })
+}
+
+// between returns the IDs of the games with values from first to last, in
+// order. An empty last has no upper bound.
+func (index sortedIndex) between(first string, last string) []int {
+	start := sort.Search(len(index), func(cntr int) bool { return index[cntr].value >= first })
+	end := len(index)
+	if last != "" {
+		end = sort.Search(len(index), func(cntr int) bool { return index[cntr].value > last })
+	}
+	return index.ids(start, end)
+}
+
+// prefixed returns the IDs of the games with values starting with prefix, in
+// order
+func (index sortedIndex) prefixed(prefix string) []int {
+	start := sort.Search(len(index), func(cntr int) bool { return index[cntr].value >= prefix })
+	end := start
+	for end < len(index) && strings.HasPrefix(index[end].value, prefix) {
+		end++
+	}
+	return index.ids(start, end)
+}
+
+// ids returns the IDs of the entries from start up to end, in order
+func (index sortedIndex) ids(start int, end int) []int {
+	var retVal []int
+	for cntr := start; cntr < end; cntr++ {
+		retVal = append(retVal, index[cntr].id)
+	}
+	sort.Ints(retVal)
+	return retVal
+}
+
+// Import reads PGN and adds every game in it. Games whose moves can't be
+// replayed are skipped. The games are stored in export format, so comments
+// and variations aren't kept. If anything goes wrong, none of the games are
+// added, so the files stay in step with each other.
+func (db *DB) Import(reader io.Reader) (ImportResult, error) {
+	var retVal ImportResult
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+
+	games, err := os.OpenFile(filepath.Join(db.dir, gamesFile), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return retVal, err
+	}
+	defer games.Close()
+	headers, err := os.OpenFile(filepath.Join(db.dir, headersFile), os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return retVal, err
+	}
+	defer headers.Close()
+	offset, err := games.Seek(0, io.SeekEnd)
+	startOffset, startCount, startPositions := offset, len(db.records), db.positions.count
+	var startHeaders int64
+	if err == nil {
+		startHeaders, err = headers.Seek(0, io.SeekEnd)
+	}
+
+	var pending []positionRecord
+	pgnReader := chess.NewPGNReader(reader)
+	for err == nil {
+		var game *chess.PGNGame
+		game, err = pgnReader.Next()
+		if err != nil {
+			break
+		}
+		keys, exported, replayErr := replay(game)
+		if replayErr != nil {
+			retVal.Skipped++
+			continue
+		}
+
+		var text bytes.Buffer
+		exported.Write(&text)
+		added := record{GameInfo: gameInfo(len(db.records), exported), Offset: offset, Length: text.Len()}
+		added.Plies = len(exported.Moves)
+		line, _ := json.Marshal(added)
+		if _, err = games.Write(text.Bytes()); err == nil {
+			_, err = headers.Write(append(line, '\n'))
+		}
+		if err == nil {
+			offset += int64(text.Len())
+			db.add(added)
+			for _, key := range keys {
+				pending = append(pending, positionRecord{key: key, game: uint32(added.ID)})
+			}
+			retVal.Imported++
+			retVal.Positions += len(keys)
+		}
+		if err == nil && len(pending) >= maxPendingPositions {
+			err = db.positions.merge(pending)
+			pending = pending[:0]
+		}
+	}
+	if err == io.EOF {
+		err = nil
+	}
+	if err == nil && len(pending) > 0 {
+		err = db.positions.merge(pending)
+	}
+	db.dates.sort()
+	db.ecos.sort()
+	if err != nil {
+		retVal = ImportResult{}
+		merged := db.positions.count != startPositions
+		if rollbackErr := db.rollback(games, headers, startOffset, startHeaders, startCount, merged); rollbackErr != nil {
+			err = fmt.Errorf("%v, and undoing the import failed: %v", err, rollbackErr)
+		}
+	}
+	return retVal, err
+}
+
+// rollback undoes an import that failed part way through, cutting the files
+// back to their sizes before it and taking its games out of the indexes. The
+// position index only needs rewriting if some of its records were merged.
+func (db *DB) rollback(games *os.File, headers *os.File, gamesSize int64, headersSize int64, count int, merged bool) error {
+	err := games.Truncate(gamesSize)
+	if err == nil {
+		err = headers.Truncate(headersSize)
+	}
+	if err == nil && merged {
+		err = db.positions.dropGames(uint32(count))
+	}
+	if err == nil {
+		err = db.loadHeaders()
+	}
+	return err
+}
+
+// replay plays through a game, returning the distinct keys of the positions
+// it reaches, from the start, and the game with its moves in standard SAN
+func replay(game *chess.PGNGame) ([]uint64, *chess.PGNGame, error) {
+	replayed, err := startGame(game)
+	retVal := &chess.PGNGame{Tags: game.Tags, Result: game.Result}
+	seen := make(map[uint64]bool)
+	var keys []uint64
+	for cntr := 0; err == nil && cntr <= len(game.Moves); cntr++ {
+		if key := replayed.PolyglotKey(); !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+		if cntr < len(game.Moves) {
+			var move chess.Move
+			move, err = replayed.SANToMove(game.Moves[cntr])
+			if err == nil {
+				retVal.Moves = append(retVal.Moves, replayed.SAN(move))
+				replayed.MakeMove(move)
+			}
+		}
+	}
+	return keys, retVal, err
+}
+
+// startGame returns a game at the position a PGN game starts from
+func startGame(game *chess.PGNGame) (*chess.Chess, error) {
+	var err error
+	retVal := chess.New()
+	if fen, ok := game.Tags["FEN"]; ok {
+		err = retVal.Load(fen)
+	}
+	return retVal, err
+}
+
+// gameInfo pulls the indexed headers out of a game's tags
+func gameInfo(id int, game *chess.PGNGame) GameInfo {
+	retVal := GameInfo{
+		ID:     id,
+		White:  game.Tags["White"],
+		Black:  game.Tags["Black"],
+		Event:  game.Tags["Event"],
+		Site:   game.Tags["Site"],
+		Date:   game.Tags["Date"],
+		Round:  game.Tags["Round"],
+		ECO:    game.Tags["ECO"],
+		Result: game.Result}
+	retVal.WhiteElo, _ = strconv.Atoi(game.Tags["WhiteElo"])
+	retVal.BlackElo, _ = strconv.Atoi(game.Tags["BlackElo"])
+	return retVal
+}
+
+// Info returns what the index has on a game
+func (db *DB) Info(id int) (GameInfo, error) {
+	var retVal GameInfo
+	var err error
+	db.mutex.RLock()
+	defer db.mutex.RUnlock()
+	if id < 0 || id >= len(db.records) {
+		err = fmt.Errorf("No game %d", id)
+	} else {
+		retVal = db.records[id].GameInfo
+	}
+	return retVal, err
+}
+
+// Game reads a game
+func (db *DB) Game(id int) (*chess.PGNGame, error) {
+	db.mutex.RLock()
+	defer db.mutex.RUnlock()
+	if id < 0 || id >= len(db.records) {
+		return nil, fmt.Errorf("No game %d", id)
+	}
+	return db.readGame(id)
+}
+
+// readGame reads a game that's known to be there, for callers already
+// holding the lock
+func (db *DB) readGame(id int) (*chess.PGNGame, error) {
+	var retVal *chess.PGNGame
+	found := db.records[id]
+	text := make([]byte, found.Length)
+	_, err := db.games.ReadAt(text, found.Offset)
+	if err == nil {
+		retVal, err = chess.NewPGNReader(bytes.NewReader(text)).Next()
+	}
+	if err != nil {
+		err = fmt.Errorf("Reading game %d: %v", id, err)
+	}
+	return retVal, err
+}
+
+// normalize is how names and other header values are compared
+func normalize(value string) string {
+	return strings.ToLower(strings.TrimSpace(value))
+}
+
+// normalizeDate is how dates are compared. PGN writes unknown parts of a date
+// as ?, which sorts after the digits, so they're counted as 0 to put
+// "1852.??.??" before "1852.01.01" rather than after "1852.12.31".
+func normalizeDate(date string) string {
+	return strings.ReplaceAll(strings.TrimSpace(date), "?", "0")
+}
@@ -0,0 +1,301 @@
+package db
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/rkitts/chess"
+)
+
+const testGames = `[Event "Club"]
+[Site "Leeds"]
+[Date "2023.04.01"]
+[White "Adams, Ann"]
+[Black "Brown, Bob"]
+[WhiteElo "2100"]
+[ECO "C20"]
+[Result "1-0"]
+
+1. e4 e5 2. Nf3 Nc6 {a comment} 3. Bc4 Nf6 1-0
+
+[Event "Club"]
+[Site "Leeds"]
+[Date "2023.05.01"]
+[White "Brown, Bob"]
+[Black "Adams, Ann"]
+[ECO "C46"]
+[Result "0-1"]
+
+1. Nf3 Nc6 2. e4 e5 3. Nc3 0-1
+
+[Event "Open"]
+[Site "York"]
+[Date "2024.01.10"]
+[White "Clark, Cy"]
+[Black "Brown, Bob"]
+[ECO "B20"]
+[Result "1/2-1/2"]
+
+1. e4 c5 1/2-1/2
+
+[Event "Broken"]
+
+1. e4 e4 *
+`
+
+func openTestDB(t *testing.T) *DB {
+	db, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatalf("Couldn't open the database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	result, err := db.Import(strings.NewReader(testGames))
+	if err != nil || result.Imported != 3 || result.Skipped != 1 {
+		t.Fatalf("Unexpected import %v %v", result, err)
+	}
+	return db
+}
+
+func ids(results Results) []int {
+	var retVal []int
+	for _, game := range results.Games {
+		retVal = append(retVal, game.ID)
+	}
+	return retVal
+}
+
+func sameIDs(found []int, expected ...int) bool {
+	retVal := len(found) == len(expected)
+	for cntr := 0; retVal && cntr < len(found); cntr++ {
+		retVal = found[cntr] == expected[cntr]
+	}
+	return retVal
+}
+
+func TestPositionSearch(t *testing.T) {
+	db := openTestDB(t)
+	// Reached by the first two games in different orders
+	results, err := db.Search(Query{FEN: "r1bqkbnr/pppp1ppp/2n5/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R w KQkq - 2 3"})
+	if err != nil || results.Total != 2 || !sameIDs(ids(results), 0, 1) {
+		t.Errorf("Expected the transposition in both games, got %v %v", results, err)
+	}
+	results, _ = db.Search(Query{FEN: "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"})
+	if results.Total != 3 {
+		t.Errorf("Expected every game to start from the initial position, got %v", results)
+	}
+	results, _ = db.Search(Query{FEN: "8/8/8/8/8/8/6k1/4K3 w - - 0 1"})
+	if results.Total != 0 || len(results.Games) != 0 {
+		t.Errorf("Expected no games, got %v", results)
+	}
+	if _, err = db.Search(Query{FEN: "nonsense"}); err == nil {
+		t.Errorf("Expected an error for a bad FEN")
+	}
+}
+
+func TestHeaderSearch(t *testing.T) {
+	db := openTestDB(t)
+	tests := []struct {
+		query    Query
+		expected []int
+	}{
+		{Query{Black: "brown, bob"}, []int{0, 2}},
+		{Query{Player: "Adams, Ann"}, []int{0, 1}},
+		{Query{Player: "Brown, Bob", Result: "1/2-1/2"}, []int{2}},
+		{Query{Event: "club", White: "Brown, Bob"}, []int{1}},
+		{Query{ECO: "C"}, []int{0, 1}},
+		{Query{DateFrom: "2023.05.01", DateTo: "2023.12.31"}, []int{1}},
+		{Query{ECO: "b2"}, []int{2}},
+		{Query{ECO: "C4", Player: "Adams, Ann"}, []int{1}},
+		{Query{DateFrom: "2024"}, []int{2}},
+		{Query{DateTo: "2023.04.30"}, []int{0}},
+		{Query{Site: "York", FEN: "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq - 0 1"}, []int{2}},
+	}
+	for _, test := range tests {
+		if results, err := db.Search(test.query); err != nil || !sameIDs(ids(results), test.expected...) {
+			t.Errorf("Expected %v for %v, got %v %v", test.expected, test.query, ids(results), err)
+		}
+	}
+}
+
+func TestDateAndECOIndexes(t *testing.T) {
+	db := openTestDB(t)
+	tests := []struct {
+		query    Query
+		expected []int
+	}{
+		{Query{ECO: "C"}, []int{0, 1}},
+		{Query{ECO: "C46"}, []int{1}},
+		{Query{ECO: "D"}, nil},
+		{Query{DateFrom: "2023.04.02"}, []int{1, 2}},
+		{Query{DateFrom: "2023", DateTo: "2023.12.31", ECO: "B"}, []int{2}},
+	}
+	for _, test := range tests {
+		// Only the games the index finds are checked, rather than all of them
+		if found, err := db.candidates(test.query); err != nil || !sameIDs(found, test.expected...) {
+			t.Errorf("Expected candidates %v for %v, got %v %v", test.expected, test.query, found, err)
+		}
+	}
+}
+
+func TestPositionSearchChecksForCollisions(t *testing.T) {
+	db := openTestDB(t)
+	fen := "8/8/8/8/8/8/6k1/4K3 w - - 0 1"
+	position := chess.New()
+	position.Load(fen)
+	// As though game 2 reached another position with the same key
+	db.positions.merge([]positionRecord{{key: position.PolyglotKey(), game: 2}})
+	if found, _ := db.candidates(Query{FEN: fen}); !sameIDs(found, 2) {
+		t.Fatalf("Expected game 2 from the index, got %v", found)
+	}
+	if results, err := db.Search(Query{FEN: fen}); err != nil || results.Total != 0 {
+		t.Errorf("Expected no games, got %v %v", ids(results), err)
+	}
+}
+
+func TestUnknownDateParts(t *testing.T) {
+	dir := t.TempDir()
+	db, _ := Open(dir)
+	defer db.Close()
+	for _, date := range []string{"1852.12.31", "1852.??.??", "1853.01.??", "????.??.??"} {
+		pgn := fmt.Sprintf("[Date \"%s\"]\n\n1. e4 *\n\n", date)
+		if _, err := db.Import(strings.NewReader(pgn)); err != nil {
+			t.Fatalf("Couldn't import %s: %v", date, err)
+		}
+	}
+	tests := []struct {
+		query    Query
+		expected []int
+	}{
+		{Query{DateFrom: "1852", DateTo: "1852.12.31"}, []int{0, 1}},
+		{Query{DateFrom: "1852.01.01", DateTo: "1852.12.31"}, []int{0}},
+		{Query{DateTo: "1852.06.30"}, []int{1, 3}},
+		{Query{DateFrom: "1853.01.??"}, []int{2}},
+		{Query{DateFrom: "1853", DateTo: "1853.01.31"}, []int{2}},
+	}
+	for _, test := range tests {
+		if results, err := db.Search(test.query); err != nil || !sameIDs(ids(results), test.expected...) {
+			t.Errorf("Expected %v for %v, got %v %v", test.expected, test.query, ids(results), err)
+		}
+	}
+}
+
+func TestPagination(t *testing.T) {
+	db := openTestDB(t)
+	results, _ := db.Search(Query{Player: "Brown, Bob", Offset: 1, Limit: 1})
+	if results.Total != 3 || !sameIDs(ids(results), 1) {
+		t.Errorf("Expected the second of three games, got %v", results)
+	}
+	results, _ = db.Search(Query{Offset: 5})
+	if results.Total != 3 || len(results.Games) != 0 {
+		t.Errorf("Expected an empty page, got %v", results)
+	}
+}
+
+func TestGame(t *testing.T) {
+	db := openTestDB(t)
+	game, err := db.Game(0)
+	if err != nil || game.Tags["White"] != "Adams, Ann" || len(game.Moves) != 6 || game.Result != "1-0" {
+		t.Errorf("Unexpected game %v %v", game, err)
+	}
+	info, _ := db.Info(0)
+	if info.WhiteElo != 2100 || info.Plies != 6 || info.ECO != "C20" {
+		t.Errorf("Unexpected info %v", info)
+	}
+	if _, err = db.Game(3); err == nil {
+		t.Errorf("Expected no game 3")
+	}
+}
+
+func TestReopen(t *testing.T) {
+	dir := t.TempDir()
+	db, _ := Open(dir)
+	db.Import(strings.NewReader(testGames))
+	db.Close()
+
+	db, err := Open(dir)
+	if err != nil || db.Len() != 3 {
+		t.Fatalf("Expected 3 games after reopening, got %v", err)
+	}
+	defer db.Close()
+	// A second import is merged into the existing position index
+	db.Import(strings.NewReader(testGames))
+	results, _ := db.Search(Query{FEN: "rnbqkbnr/pp1ppppp/8/2p5/4P3/8/PPPP1PPP/RNBQKBNR w KQkq c6 0 2"})
+	if results.Total != 2 || !sameIDs(ids(results), 2, 5) {
+		t.Errorf("Expected games 2 and 5, got %v", results)
+	}
+	game, err := db.Game(4)
+	if err != nil || game.Tags["White"] != "Brown, Bob" {
+		t.Errorf("Unexpected game %v %v", game, err)
+	}
+	results, _ = db.Search(Query{ECO: "C", DateFrom: "2023.04.15"})
+	if !sameIDs(ids(results), 1, 4) {
+		t.Errorf("Expected games 1 and 4, got %v", ids(results))
+	}
+}
+
+func TestFailedImportIsUndone(t *testing.T) {
+	dir := t.TempDir()
+	db, _ := Open(dir)
+	defer db.Close()
+	db.Import(strings.NewReader(testGames))
+	pgnBefore, _ := os.ReadFile(filepath.Join(dir, gamesFile))
+	headersBefore, _ := os.ReadFile(filepath.Join(dir, headersFile))
+	positionsBefore, _ := os.ReadFile(filepath.Join(dir, positionsFile))
+
+	// Merge after every game, so some positions are in the index when reading fails
+	defer func(saved int) { maxPendingPositions = saved }(maxPendingPositions)
+	maxPendingPositions = 1
+	failing := io.MultiReader(strings.NewReader(testGames), iotest.ErrReader(errors.New("disk on fire")))
+	if result, err := db.Import(failing); err == nil || result.Imported != 0 {
+		t.Errorf("Expected the import to fail with nothing imported, got %v %v", result, err)
+	}
+
+	pgnAfter, _ := os.ReadFile(filepath.Join(dir, gamesFile))
+	headersAfter, _ := os.ReadFile(filepath.Join(dir, headersFile))
+	positionsAfter, _ := os.ReadFile(filepath.Join(dir, positionsFile))
+	if db.Len() != 3 || !bytes.Equal(pgnBefore, pgnAfter) || !bytes.Equal(headersBefore, headersAfter) ||
+		!bytes.Equal(positionsBefore, positionsAfter) {
+		t.Errorf("Expected the database as it was, got %d games", db.Len())
+	}
+	if results, _ := db.Search(Query{ECO: "C"}); !sameIDs(ids(results), 0, 1) {
+		t.Errorf("Expected games 0 and 1, got %v", ids(results))
+	}
+
+	// The next import carries on from where the database really is
+	if result, err := db.Import(strings.NewReader(testGames)); err != nil || result.Imported != 3 {
+		t.Errorf("Unexpected import %v %v", result, err)
+	}
+	results, _ := db.Search(Query{FEN: "rnbqkbnr/pp1ppppp/8/2p5/4P3/8/PPPP1PPP/RNBQKBNR w KQkq c6 0 2"})
+	if !sameIDs(ids(results), 2, 5) {
+		t.Errorf("Expected games 2 and 5, got %v", ids(results))
+	}
+}
+
+func TestFailedMergeKeepsTheIndex(t *testing.T) {
+	dir := t.TempDir()
+	db, _ := Open(dir)
+	db.Import(strings.NewReader(testGames))
+	fen := "rnbqkbnr/pp1ppppp/8/2p5/4P3/8/PPPP1PPP/RNBQKBNR w KQkq c6 0 2"
+
+	// A directory where the merged file should go stops it being written
+	os.MkdirAll(filepath.Join(dir, positionsFile+".tmp", "blocked"), 0755)
+	if _, err := db.Import(strings.NewReader(testGames)); err == nil {
+		t.Errorf("Expected the import to fail")
+	}
+	if results, err := db.Search(Query{FEN: fen}); err != nil || !sameIDs(ids(results), 2) {
+		t.Errorf("Expected game 2 from the old index, got %v %v", ids(results), err)
+	}
+
+	// Once it's closed, searching is an error rather than a crash
+	db.Close()
+	if _, err := db.Search(Query{FEN: fen}); err == nil {
+		t.Errorf("Expected an error searching a closed database")
+	}
+}
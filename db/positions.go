@@ -0,0 +1,176 @@
+package db
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+// positionRecordSize is the size of a record in positions.idx: the
+// position's Polyglot key and the game's ID, both big endian
+const positionRecordSize = 12
+
+type positionRecord struct {
+	key  uint64
+	game uint32
+}
+
+// positionIndex is positions.idx, with its records sorted by key and then game
+type positionIndex struct {
+	path  string
+	file  *os.File
+	count int64
+}
+
+func openPositionIndex(path string) (*positionIndex, error) {
+	retVal := &positionIndex{path: path}
+	err := retVal.open()
+	return retVal, err
+}
+
+func (index *positionIndex) open() error {
+	file, err := os.OpenFile(index.path, os.O_RDONLY|os.O_CREATE, 0644)
+	if err == nil {
+		var info os.FileInfo
+		if info, err = file.Stat(); err == nil {
+			index.file = file
+			index.count = info.Size() / positionRecordSize
+		} else {
+			file.Close()
+		}
+	}
+	return err
+}
+
+func (index *positionIndex) close() error {
+	var err error
+	if index.file != nil {
+		err = index.file.Close()
+		index.file = nil
+	}
+	return err
+}
+
+// read reads the record at a position in the file
+func (index *positionIndex) read(position int64) (positionRecord, error) {
+	var buffer [positionRecordSize]byte
+	if index.file == nil {
+		return positionRecord{}, fmt.Errorf("The position index %s isn't open", index.path)
+	}
+	_, err := index.file.ReadAt(buffer[:], position*positionRecordSize)
+	return decodePositionRecord(buffer[:]), err
+}
+
+// games returns the IDs of the games that reach a position, in order
+func (index *positionIndex) games(key uint64) ([]int, error) {
+	var retVal []int
+	var err error
+	first := sort.Search(int(index.count), func(position int) bool {
+		found, readErr := index.read(int64(position))
+		if readErr != nil && err == nil {
+			err = readErr
+		}
+		return found.key >= key
+	})
+	for position := int64(first); err == nil && position < index.count; position++ {
+		var found positionRecord
+		found, err = index.read(position)
+		if err == nil && found.key != key {
+			break
+		}
+		if err == nil {
+			retVal = append(retVal, int(found.game))
+		}
+	}
+	return retVal, err
+}
+
+// merge adds records to the index. They're sorted and merged with the
+// records already there into a new file, which then replaces the old one.
+func (index *positionIndex) merge(added []positionRecord) error {
+	return index.rewrite(added, func(positionRecord) bool { return true })
+}
+
+// dropGames takes the records of games from first on out of the index
+func (index *positionIndex) dropGames(first uint32) error {
+	return index.rewrite(nil, func(existing positionRecord) bool { return existing.game < first })
+}
+
+// rewrite writes a new file of the records already there that keep says to
+// keep, merged with added, and replaces the old one with it. The new file
+// stays open to become the index, so the index is never left without a file
+// to read: if anything fails the old one is still in place and open.
+func (index *positionIndex) rewrite(added []positionRecord, keep func(positionRecord) bool) error {
+	if index.file == nil {
+		return fmt.Errorf("The position index %s isn't open", index.path)
+	}
+	sort.Slice(added, func(i, j int) bool { return added[i].less(added[j]) })
+	merged, err := os.Create(index.path + ".tmp")
+	if err != nil {
+		return err
+	}
+	var count int64
+	writer := bufio.NewWriterSize(merged, 1<<20)
+	reader := bufio.NewReaderSize(io.NewSectionReader(index.file, 0, index.count*positionRecordSize), 1<<20)
+	var buffer [positionRecordSize]byte
+	existing, haveExisting := positionRecord{}, false
+	readNext := func() {
+		_, readErr := io.ReadFull(reader, buffer[:])
+		haveExisting = readErr == nil
+		existing = decodePositionRecord(buffer[:])
+		if readErr != nil && readErr != io.EOF && err == nil {
+			err = readErr
+		}
+	}
+	readNext()
+	for err == nil && (haveExisting || len(added) > 0) {
+		next := existing
+		if haveExisting && (len(added) == 0 || existing.less(added[0])) {
+			readNext()
+			if !keep(next) {
+				continue
+			}
+		} else {
+			next = added[0]
+			added = added[1:]
+		}
+		_, err = writer.Write(next.encode())
+		count++
+	}
+	if err == nil {
+		err = writer.Flush()
+	}
+	if err == nil {
+		err = merged.Sync()
+	}
+	if err == nil {
+		err = os.Rename(index.path+".tmp", index.path)
+	}
+	if err != nil {
+		merged.Close()
+		os.Remove(index.path + ".tmp")
+		return err
+	}
+	index.close()
+	index.file = merged
+	index.count = count
+	return nil
+}
+
+func (record positionRecord) less(other positionRecord) bool {
+	return record.key < other.key || (record.key == other.key && record.game < other.game)
+}
+
+func (record positionRecord) encode() []byte {
+	retVal := make([]byte, positionRecordSize)
+	binary.BigEndian.PutUint64(retVal, record.key)
+	binary.BigEndian.PutUint32(retVal[8:], record.game)
+	return retVal
+}
+
+func decodePositionRecord(encoded []byte) positionRecord {
+	return positionRecord{key: binary.BigEndian.Uint64(encoded), game: binary.BigEndian.Uint32(encoded[8:])}
+}
@@ -0,0 +1,190 @@
+package db
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/rkitts/chess"
+)
+
+// Query picks games. Every field that's set has to match: names, events and
+// sites ignoring case, ECO codes by prefix so "B2" finds the B20s, and dates
+// as PGN dates compared as text, with unknown parts such as "??" counted as
+// "00". Player matches either colour. Offset and Limit pick a page of the
+// games found; a Limit of 0 means all of them.
+type Query struct {
+	FEN      string
+	Player   string
+	White    string
+	Black    string
+	Event    string
+	Site     string
+	ECO      string
+	Result   string
+	DateFrom string
+	DateTo   string
+	Offset   int
+	Limit    int
+}
+
+// Results is a page of the games a query found, in the order they were
+// imported, and how many it found in all
+type Results struct {
+	Total int
+	Games []GameInfo
+}
+
+// Search finds the games matching a query
+func (db *DB) Search(query Query) (Results, error) {
+	var retVal Results
+	db.mutex.RLock()
+	defer db.mutex.RUnlock()
+
+	ids, err := db.candidates(query)
+	var target chess.PackedPosition
+	if err == nil && query.FEN != "" {
+		target, err = packFEN(query.FEN)
+	}
+	var found []int
+	for cntr := 0; err == nil && cntr < len(ids); cntr++ {
+		id := ids[cntr]
+		matched := db.matches(db.records[id].GameInfo, query)
+		if matched && query.FEN != "" {
+			// Games are indexed by the position's key alone, so check the
+			// game really reaches it rather than another with the same key
+			matched, err = db.reaches(id, target)
+		}
+		if matched {
+			found = append(found, id)
+		}
+	}
+	retVal.Total = len(found)
+	if query.Offset > 0 {
+		found = found[min(query.Offset, len(found)):]
+	}
+	if query.Limit > 0 && query.Limit < len(found) {
+		found = found[:query.Limit]
+	}
+	for _, id := range found {
+		retVal.Games = append(retVal.Games, db.records[id].GameInfo)
+	}
+	return retVal, err
+}
+
+// candidates narrows down the games to check against the query, using the
+// position index if there's a position, or else the smallest of the header
+// indexes that apply
+func (db *DB) candidates(query Query) ([]int, error) {
+	var retVal []int
+	var err error
+	narrowed := false
+	if query.FEN != "" {
+		position := chess.New()
+		if err = position.Load(query.FEN); err == nil {
+			retVal, err = db.positions.games(position.PolyglotKey())
+			narrowed = true
+		}
+	}
+	narrow := func(ids []int) {
+		if query.FEN == "" && (!narrowed || len(ids) < len(retVal)) {
+			retVal, narrowed = ids, true
+		}
+	}
+	for field, value := range map[string]string{
+		"white": query.White, "black": query.Black, "event": query.Event,
+		"site": query.Site, "result": query.Result} {
+		if value != "" {
+			narrow(db.fields[field][normalize(value)])
+		}
+	}
+	if query.ECO != "" {
+		narrow(db.ecos.prefixed(strings.ToUpper(query.ECO)))
+	}
+	if query.DateFrom != "" || query.DateTo != "" {
+		narrow(db.dates.between(normalizeDate(query.DateFrom), normalizeDate(query.DateTo)))
+	}
+	if query.Player != "" && !narrowed {
+		retVal = union(db.fields["white"][normalize(query.Player)], db.fields["black"][normalize(query.Player)])
+		narrowed = true
+	}
+	if !narrowed {
+		retVal = make([]int, len(db.records))
+		for cntr := range retVal {
+			retVal[cntr] = cntr
+		}
+	}
+	return retVal, err
+}
+
+// matches checks a game against every part of a query but the position
+func (db *DB) matches(info GameInfo, query Query) bool {
+	retVal := true
+	for _, pair := range [][2]string{{info.White, query.White}, {info.Black, query.Black},
+		{info.Event, query.Event}, {info.Site, query.Site}, {info.Result, query.Result}} {
+		retVal = retVal && (pair[1] == "" || normalize(pair[0]) == normalize(pair[1]))
+	}
+	if query.Player != "" {
+		player := normalize(query.Player)
+		retVal = retVal && (normalize(info.White) == player || normalize(info.Black) == player)
+	}
+	retVal = retVal && strings.HasPrefix(strings.ToUpper(info.ECO), strings.ToUpper(query.ECO))
+	date := normalizeDate(info.Date)
+	retVal = retVal && (query.DateFrom == "" || date >= normalizeDate(query.DateFrom))
+	retVal = retVal && (query.DateTo == "" || date <= normalizeDate(query.DateTo))
+	return retVal
+}
+
+// reaches replays a game to see whether it reaches the position
+func (db *DB) reaches(id int, target chess.PackedPosition) (bool, error) {
+	retVal := false
+	game, err := db.readGame(id)
+	var replayed *chess.Chess
+	if err == nil {
+		replayed, err = startGame(game)
+	}
+	for cntr := 0; err == nil && !retVal && cntr <= len(game.Moves); cntr++ {
+		var packed chess.PackedPosition
+		if packed, err = replayed.Pack(); err == nil {
+			retVal = samePosition(packed, target)
+		}
+		if err == nil && !retVal && cntr < len(game.Moves) {
+			err = replayed.Move(game.Moves[cntr])
+		}
+	}
+	if err != nil {
+		err = fmt.Errorf("Replaying game %d: %v", id, err)
+	}
+	return retVal, err
+}
+
+// packFEN packs the position of a FEN
+func packFEN(fen string) (chess.PackedPosition, error) {
+	var retVal chess.PackedPosition
+	position := chess.New()
+	err := position.Load(fen)
+	if err == nil {
+		retVal, err = position.Pack()
+	}
+	return retVal, err
+}
+
+// samePosition compares two packed positions without their move counters,
+// which are the last four bytes
+func samePosition(first chess.PackedPosition, second chess.PackedPosition) bool {
+	return bytes.Equal(first[:chess.PackedPositionSize-4], second[:chess.PackedPositionSize-4])
+}
+
+// union merges two sorted lists of IDs
+func union(first []int, second []int) []int {
+	retVal := append(append([]int(nil), first...), second...)
+	sort.Ints(retVal)
+	unique := retVal[:0]
+	for cntr, id := range retVal {
+		if cntr == 0 || id != retVal[cntr-1] {
+			unique = append(unique, id)
+		}
+	}
+	return unique
+}
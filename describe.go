@@ -0,0 +1,192 @@
+package chess
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// describeOrder is the order pieces are listed in
+var describeOrder = []PieceType{king, queen, rook, bishop, knight, pawn}
+
+// Describe returns the position in words, for people who can't see the
+// board: each side's pieces by type with their squares, then who is to move,
+// as in "White: King g1, Rook f1, pawns a2 b2 c2. Black: King g8, pawns f7
+// g7 h7. White to move."
+func Describe(chess *Chess) string {
+	var sentences []string
+	for _, color := range []PieceColor{white, black} {
+		var groups []string
+		for _, ptype := range describeOrder {
+			var squares []string
+			for _, name := range describeSquares() {
+				if piece := chess.Get(name); piece.ptype == ptype && piece.pcolor == color {
+					squares = append(squares, name)
+				}
+			}
+			if len(squares) > 0 {
+				groups = append(groups, describeGroup(ptype, squares))
+			}
+		}
+		sentences = append(sentences, colorName(color)+": "+strings.Join(groups, ", ")+".")
+	}
+	toMove := colorName(chess.turn) + " to move"
+	if chess.InCheck() {
+		toMove += ", in check"
+	}
+	sentences = append(sentences, toMove+".")
+	return strings.Join(sentences, " ")
+}
+
+// describeSquares returns the names of the squares in reading order for a
+// listing: a1 to h1, then up the board to h8
+func describeSquares() []string {
+	var retVal []string
+	for rankNum := '1'; rankNum <= '8'; rankNum++ {
+		for fileName := 'a'; fileName <= 'h'; fileName++ {
+			retVal = append(retVal, string([]rune{fileName, rankNum}))
+		}
+	}
+	return retVal
+}
+
+// describeGroup names a side's pieces of one type, as in "Rooks a1 f1" or
+// "pawn e4"
+func describeGroup(ptype PieceType, squares []string) string {
+	name := pieceNames[ptype]
+	if len(squares) > 1 {
+		name += "s"
+	}
+	if ptype != pawn {
+		name = string(unicode.ToUpper(rune(name[0]))) + name[1:]
+	}
+	return name + " " + strings.Join(squares, " ")
+}
+
+// describePiece names the piece on a square, as in "Black knight c6"
+func describePiece(chess *Chess, square int) string {
+	piece := chess.board[square]
+	return colorName(piece.pcolor) + " " + pieceNames[piece.ptype] + " " + algebraic(square)
+}
+
+// describeList joins things to be read out with commas and a final "and"
+func describeList(items []string) string {
+	retVal := strings.Join(items, ", ")
+	if len(items) > 1 {
+		retVal = strings.Join(items[:len(items)-1], ", ") + " and " + items[len(items)-1]
+	}
+	return retVal
+}
+
+// DescribeSquare says what is on a square, as in "e4: White pawn" or "e5 is
+// empty"
+func DescribeSquare(chess *Chess, square string) (string, error) {
+	if _, ok := squareNameToID[square]; !ok {
+		return "", fmt.Errorf("%s is not a legal square name", square)
+	}
+	retVal := square + " is empty"
+	if piece := chess.Get(square); !piece.IsUnspecified() {
+		retVal = square + ": " + colorName(piece.pcolor) + " " + pieceNames[piece.ptype]
+	}
+	return retVal, nil
+}
+
+// DescribeRank says what is on a rank, 1 to 8, from the a file to the h file
+func DescribeRank(chess *Chess, rankNum int) (string, error) {
+	if rankNum < 1 || rankNum > 8 {
+		return "", fmt.Errorf("%d is not a rank", rankNum)
+	}
+	var squares []string
+	for fileName := 'a'; fileName <= 'h'; fileName++ {
+		squares = append(squares, fmt.Sprintf("%c%d", fileName, rankNum))
+	}
+	return describeLine(chess, fmt.Sprintf("Rank %d", rankNum), squares), nil
+}
+
+// DescribeFile says what is on a file, a to h, from the first rank to the
+// eighth
+func DescribeFile(chess *Chess, fileName string) (string, error) {
+	if len(fileName) != 1 || !isFile(fileName[0]) {
+		return "", fmt.Errorf("%s is not a file", fileName)
+	}
+	var squares []string
+	for rankNum := 1; rankNum <= 8; rankNum++ {
+		squares = append(squares, fmt.Sprintf("%s%d", fileName, rankNum))
+	}
+	return describeLine(chess, fileName+" file", squares), nil
+}
+
+// describeLine lists the pieces on some squares, as in "Rank 7: Black pawn
+// a7, Black bishop e7", or says they're empty
+func describeLine(chess *Chess, name string, squares []string) string {
+	var pieces []string
+	for _, square := range squares {
+		if piece := chess.Get(square); !piece.IsUnspecified() {
+			pieces = append(pieces, describePiece(chess, squareNameToID[square]))
+		}
+	}
+	retVal := name + " is empty"
+	if len(pieces) > 0 {
+		retVal = name + ": " + strings.Join(pieces, ", ")
+	}
+	return retVal
+}
+
+// DescribeAttackers says which pieces of either side attack a square, as in
+// "e5 is attacked by White knight f3 and Black knight c6"
+func DescribeAttackers(chess *Chess, square string) (string, error) {
+	squareNum, ok := squareNameToID[square]
+	if !ok {
+		return "", fmt.Errorf("%s is not a legal square name", square)
+	}
+	var pieces []string
+	for _, color := range []PieceColor{white, black} {
+		for _, attacker := range chess.attackers(color, squareNum) {
+			pieces = append(pieces, describePiece(chess, attacker))
+		}
+	}
+	retVal := "Nothing attacks " + square
+	if len(pieces) > 0 {
+		retVal = square + " is attacked by " + describeList(pieces)
+	}
+	return retVal, nil
+}
+
+// DescribeHanging says which pieces are hanging: attacked by the other side
+// and not defended by their own
+func DescribeHanging(chess *Chess) string {
+	var pieces []string
+	for _, name := range describeSquares() {
+		square := squareNameToID[name]
+		piece := chess.board[square]
+		if piece.IsUnspecified() || piece.ptype == king {
+			continue
+		}
+		if chess.attacked(swapColor(piece.pcolor), square) && !chess.attacked(piece.pcolor, square) {
+			pieces = append(pieces, describePiece(chess, square))
+		}
+	}
+	retVal := "Nothing is hanging"
+	if len(pieces) > 0 {
+		retVal = "Hanging: " + describeList(pieces)
+	}
+	return retVal
+}
+
+// DescribeLastMove announces the last move and what it did: the piece that
+// moved, anything it took, castling, promotion, check or mate, and how the
+// game ended if it's over, as in "Black pawn from e7 to e5" or "White queen
+// from h5 takes pawn on f7, checkmate"
+func DescribeLastMove(chess *Chess) string {
+	retVal := "No moves have been made"
+	history := chess.History()
+	if len(history) > 0 {
+		before := chess.Clone()
+		before.Undo()
+		retVal = before.Spoken(history[len(history)-1])
+		if outcome := chess.Outcome(); outcome.Over() && outcome.Reason != "checkmate" {
+			retVal += ", draw by " + outcome.Reason
+		}
+	}
+	return retVal
+}
@@ -0,0 +1,89 @@
+package chess
+
+import "testing"
+
+// ruyLopez is the position after 1. e4 e5 2. Nf3 Nc6 3. Bb5 a6
+const ruyLopez = "r1bqkbnr/1ppp1ppp/p1n5/1B2p3/4P3/5N2/PPPP1PPP/RNBQK2R w KQkq - 0 4"
+
+func TestDescribe(t *testing.T) {
+	chess := New()
+	chess.Load("6k1/5ppp/8/8/8/8/PPP5/5RK1 b - - 0 1")
+	expected := "White: King g1, Rook f1, pawns a2 b2 c2. Black: King g8, pawns f7 g7 h7. Black to move."
+	if description := Describe(chess); description != expected {
+		t.Errorf("Expected '%s', got '%s'", expected, description)
+	}
+	chess.Load("4k3/8/8/8/8/8/8/R3K1R1 b - - 0 1")
+	chess.Move("Kf7")
+	chess.Move("Rf1")
+	if description := Describe(chess); description != "White: King e1, Rooks a1 f1. Black: King f7. Black to move, in check." {
+		t.Errorf("Unexpected description '%s'", description)
+	}
+}
+
+func TestDescribeQueries(t *testing.T) {
+	chess := New()
+	chess.Load(ruyLopez)
+	tests := []struct {
+		describe func() (string, error)
+		expected string
+	}{
+		{func() (string, error) { return DescribeSquare(chess, "b5") }, "b5: White bishop"},
+		{func() (string, error) { return DescribeSquare(chess, "d4") }, "d4 is empty"},
+		{func() (string, error) { return DescribeRank(chess, 6) }, "Rank 6: Black pawn a6, Black knight c6"},
+		{func() (string, error) { return DescribeRank(chess, 4) }, "Rank 4: White pawn e4"},
+		{func() (string, error) { return DescribeFile(chess, "b") }, "b file: White knight b1, White pawn b2, White bishop b5, Black pawn b7"},
+		{func() (string, error) { return DescribeAttackers(chess, "e5") }, "e5 is attacked by White knight f3 and Black knight c6"},
+		{func() (string, error) { return DescribeAttackers(chess, "h5") }, "Nothing attacks h5"},
+	}
+	for _, test := range tests {
+		if description, err := test.describe(); err != nil || description != test.expected {
+			t.Errorf("Expected '%s', got '%s', %v", test.expected, description, err)
+		}
+	}
+	if description := DescribeHanging(chess); description != "Hanging: White bishop b5" {
+		t.Errorf("Unexpected hanging pieces '%s'", description)
+	}
+	if description := DescribeHanging(New()); description != "Nothing is hanging" {
+		t.Errorf("Unexpected hanging pieces '%s'", description)
+	}
+
+	for _, describe := range []func() (string, error){
+		func() (string, error) { return DescribeSquare(chess, "i9") },
+		func() (string, error) { return DescribeRank(chess, 9) },
+		func() (string, error) { return DescribeFile(chess, "z") },
+		func() (string, error) { return DescribeAttackers(chess, "") },
+	} {
+		if _, err := describe(); err == nil {
+			t.Errorf("Expected an error for a square, rank or file that doesn't exist")
+		}
+	}
+}
+
+func TestDescribeLastMove(t *testing.T) {
+	chess := New()
+	if description := DescribeLastMove(chess); description != "No moves have been made" {
+		t.Errorf("Unexpected description '%s'", description)
+	}
+	tests := []struct {
+		fen      string
+		san      string
+		expected string
+	}{
+		{defaultPosition, "e4", "White pawn from e2 to e4"},
+		{ruyLopez, "Bxc6", "White bishop from b5 takes knight on c6"},
+		{ruyLopez, "O-O", "White castles kingside"},
+		{"8/P6k/8/8/8/8/8/K7 w - - 0 1", "a8=Q", "White pawn from a7 to a8, promotes to queen"},
+		{"6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1", "Ra8#", "White rook from a1 to a8, checkmate"},
+		{"7k/8/5KQ1/8/8/8/8/8 w - - 0 1", "Qg5", "White queen from g6 to g5"},
+		{"7k/8/5K2/8/8/8/8/6Q1 w - - 0 1", "Qg6", "White queen from g1 to g6, draw by stalemate"},
+	}
+	for _, test := range tests {
+		chess.Load(test.fen)
+		if err := chess.Move(test.san); err != nil {
+			t.Errorf("Got an error %v", err)
+		}
+		if description := DescribeLastMove(chess); description != test.expected {
+			t.Errorf("Expected '%s', got '%s'", test.expected, description)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package chess
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// descriptiveFiles are the files, a to h, as descriptive notation names them
+var descriptiveFiles = []string{"QR", "QN", "QB", "Q", "K", "KB", "KN", "KR"}
+
+// What's dropped from a descriptive move before it's matched, and how the
+// other ways of writing a promotion are turned into =Q
+var (
+	descriptiveSuffixRegexp    = regexp.MustCompile(`((DIS|DBL)?CH|MATE|E\.?P\.?|[+#!?])*$`)
+	descriptivePromotionRegexp = regexp.MustCompile(`([1-8])[(/=]?([QRBN])\)?$`)
+)
+
+// Descriptive returns the move in English descriptive notation, such as
+// P-K4, N-KB3 or PxP, with " ch" or " mate" if it checks or mates. Squares
+// are named from the side of the player moving, and captures by the piece
+// taken. The shortest form that no other legal move could be written as is
+// used, so N-B3 when only one B3 can be reached, N-KB3 otherwise. When that
+// isn't enough, the piece moving or taken gets its square in brackets, as in
+// R(R1)-Q1 or NxP(Q4), and pawns can be named by their file, as in BPxP.
+func (chess *Chess) Descriptive(move Move) string {
+	forms := descriptiveForms(move)
+	taken := make(map[string]bool)
+	for _, other := range chess.Moves(true, "") {
+		if other != move {
+			for _, form := range descriptiveForms(other) {
+				taken[form] = true
+			}
+		}
+	}
+	retVal := forms[len(forms)-1]
+	for cntr := len(forms) - 1; cntr >= 0; cntr-- {
+		if !taken[forms[cntr]] {
+			retVal = forms[cntr]
+		}
+	}
+	switch chess.checkSuffix(move) {
+	case "+":
+		retVal += " ch"
+	case "#":
+		retVal += " mate"
+	}
+	return retVal
+}
+
+// DescriptiveToMove converts a move in English descriptive notation into a
+// Move, if it's a legal move, or returns an error. Any of the forms
+// Descriptive uses are accepted as long as only one legal move fits, as are
+// Kt for the knight, lower case, 0-0 for castling and promotions written as
+// P-K8(Q), P-K8/Q or P-K8Q.
+func (chess *Chess) DescriptiveToMove(text string) (Move, error) {
+	var retVal Move
+	var err error
+	normalized := normalizeDescriptive(text)
+	var matches []Move
+	for _, move := range chess.Moves(true, "") {
+		for _, form := range descriptiveForms(move) {
+			if strings.ToUpper(form) == normalized {
+				matches = append(matches, move)
+				break
+			}
+		}
+	}
+	switch len(matches) {
+	case 0:
+		err = fmt.Errorf("%s not a legal move", text)
+	case 1:
+		retVal = matches[0]
+	default:
+		var candidates []string
+		for _, match := range matches {
+			candidates = append(candidates, chess.Descriptive(match))
+		}
+		err = fmt.Errorf("%s is ambiguous, it could be %s", text, strings.Join(candidates, " or "))
+	}
+	return retVal, err
+}
+
+// normalizeDescriptive puts a descriptive move into the upper case form
+// descriptiveForms uses, without checks or annotations
+func normalizeDescriptive(text string) string {
+	retVal := strings.ToUpper(strings.Join(strings.Fields(text), ""))
+	retVal = descriptiveSuffixRegexp.ReplaceAllString(retVal, "")
+	retVal = strings.NewReplacer("KT", "N", "0", "O").Replace(retVal)
+	return descriptivePromotionRegexp.ReplaceAllString(retVal, "$1=$2")
+}
+
+// descriptiveForms returns every way the move can be written in descriptive
+// notation, shortest first, ignoring the other legal moves
+func descriptiveForms(move Move) []string {
+	if (move.flags & ksideCastleMove) != 0 {
+		return []string{"O-O"}
+	} else if (move.flags & qsideCastleMove) != 0 {
+		return []string{"O-O-O"}
+	}
+	var targets []string
+	if (move.flags & (captureMove | enpassantMove)) != 0 {
+		square, captured := move.to, move.capturedType
+		if (move.flags & enpassantMove) != 0 {
+			// The pawn taken is beside the one taking it, not where it goes
+			square, captured = move.to+16, pawn
+			if move.turn == black {
+				square = move.to - 16
+			}
+		}
+		for _, piece := range descriptivePieces(captured, square, move.turn) {
+			targets = append(targets, "x"+piece)
+		}
+	} else {
+		for _, square := range descriptiveSquares(move.to, move.turn) {
+			targets = append(targets, "-"+square)
+		}
+	}
+	promotion := ""
+	if (move.flags & promotionMove) != 0 {
+		promotion = "=" + string(unicode.ToUpper(rune(move.promotedType)))
+	}
+
+	var retVal []string
+	for _, piece := range descriptivePieces(move.ptype, move.from, move.turn) {
+		for _, target := range targets {
+			retVal = append(retVal, piece+target+promotion)
+		}
+	}
+	sort.SliceStable(retVal, func(i, j int) bool {
+		return len(retVal[i]) < len(retVal[j])
+	})
+	return retVal
+}
+
+// descriptivePieces returns the ways a piece on a square can be named: its
+// letter, its letter with its square in brackets, and for pawns, its file
+func descriptivePieces(ptype PieceType, square int, color PieceColor) []string {
+	letter := string(unicode.ToUpper(rune(ptype)))
+	retVal := []string{letter}
+	squares := descriptiveSquares(square, color)
+	if ptype == pawn {
+		for _, name := range squares {
+			retVal = append(retVal, strings.TrimRight(name, "12345678")+letter)
+		}
+	}
+	for _, name := range squares {
+		retVal = append(retVal, letter+"("+name+")")
+	}
+	return retVal
+}
+
+// descriptiveSquares returns the names of a square as the player of color
+// sees it, shortest first: B3 and KB3, or just K4 on the king and queen files
+func descriptiveSquares(square int, color PieceColor) []string {
+	number := 8 - rank(square)
+	if color == black {
+		number = rank(square) + 1
+	}
+	full := descriptiveFiles[file(square)] + strconv.Itoa(number)
+	retVal := []string{full}
+	if len(full) == 3 {
+		retVal = []string{full[1:], full}
+	}
+	return retVal
+}
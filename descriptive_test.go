@@ -0,0 +1,75 @@
+package chess
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDescriptive(t *testing.T) {
+	tests := []struct {
+		fen      string
+		uci      string
+		expected string
+	}{
+		{defaultPosition, "e2e4", "P-K4"},
+		{defaultPosition, "g1f3", "N-KB3"},
+		{defaultPosition, "g1h3", "N-KR3"},
+		{"rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq - 0 1", "e7e5", "P-K4"},
+		{"rnbqkbnr/ppp1pppp/8/3p4/4P3/8/PPPP1PPP/RNBQKBNR w KQkq - 0 2", "e4d5", "PxP"},
+		{"4k3/8/8/3pP3/8/8/8/4K3 w - d6 0 1", "e5d6", "PxP"},
+		{"4k3/8/8/2p1p3/3P4/8/8/4K3 w - - 0 1", "d4c5", "PxBP"},
+		{"4k3/8/8/8/8/8/8/R4RK1 w - - 0 1", "a1d1", "R(R1)-Q1"},
+		{"6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1", "a1a8", "R-R8 mate"},
+		{"4k3/8/8/8/8/8/8/R3K3 w - - 0 1", "a1a8", "R-R8 ch"},
+		{"8/P6k/8/8/8/8/8/K7 w - - 0 1", "a7a8q", "P-R8=Q"},
+		{"r3k3/8/8/8/8/8/8/4K3 b q - 0 1", "e8c8", "O-O-O"},
+	}
+	for _, test := range tests {
+		chess := New()
+		chess.Load(test.fen)
+		move, err := chess.UCIToMove(test.uci)
+		if descriptive := chess.Descriptive(move); err != nil || descriptive != test.expected {
+			t.Errorf("Expected %s for %s, got %s, %v", test.expected, test.uci, descriptive, err)
+		}
+		if parsed, err := chess.DescriptiveToMove(test.expected); err != nil || parsed != move {
+			t.Errorf("Expected %s to read back, got %v", test.expected, err)
+		}
+	}
+}
+
+func TestDescriptiveToMove(t *testing.T) {
+	tests := []struct {
+		fen         string
+		descriptive string
+		expected    string
+	}{
+		{defaultPosition, "p-k4", "e2e4"},
+		{defaultPosition, "Kt-KB3", "g1f3"},
+		{defaultPosition, "KP-K4", "e2e4"},
+		{defaultPosition, "P(K2)-K4", "e2e4"},
+		{"rnbqkbnr/ppp1pppp/8/3p4/4P3/8/PPPP1PPP/RNBQKBNR w KQkq - 0 2", "PxQP", "e4d5"},
+		{"4k3/8/8/3pP3/8/8/8/4K3 w - d6 0 1", "PxP e.p.", "e5d6"},
+		{"8/P6k/8/8/8/8/8/K7 w - - 0 1", "P-R8(Q)", "a7a8q"},
+		{"8/P6k/8/8/8/8/8/K7 w - - 0 1", "P-QR8/N", "a7a8n"},
+		{"8/P6k/8/8/8/8/8/K7 w - - 0 1", "P-R8R", "a7a8r"},
+		{"4k3/8/8/8/8/8/8/R3K2R w KQ - 0 1", "0-0", "e1g1"},
+		{"6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1", "R-QR8 mate", "a1a8"},
+	}
+	for _, test := range tests {
+		chess := New()
+		chess.Load(test.fen)
+		if move, err := chess.DescriptiveToMove(test.descriptive); err != nil || move.UCI() != test.expected {
+			t.Errorf("Expected %s to be %s, got %s, %v", test.descriptive, test.expected, move.UCI(), err)
+		}
+	}
+
+	chess := New()
+	if _, err := chess.DescriptiveToMove("N-B3"); err == nil || !strings.Contains(err.Error(), "N-KB3 or N-QB3") && !strings.Contains(err.Error(), "N-QB3 or N-KB3") {
+		t.Errorf("Expected N-B3 to be ambiguous, got %v", err)
+	}
+	for _, descriptive := range []string{"P-K5", "N-K2", "PxP", "nonsense", ""} {
+		if _, err := chess.DescriptiveToMove(descriptive); err == nil {
+			t.Errorf("Expected %s not to be a legal move", descriptive)
+		}
+	}
+}
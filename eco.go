@@ -0,0 +1,97 @@
+package chess
+
+import (
+	_ "embed"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// ecoTable has a line per opening: its ECO code, its name, and the moves
+// reaching it, separated by tabs. Every ECO code from A00 to E99 has at
+// least one line, but not every named sub-variation is listed.
+//
+//go:embed eco.tsv
+var ecoTable string
+
+// Opening is an opening as named in the ECO table. Variation is the part
+// of the name after the colon, if there is one.
+type Opening struct {
+	ECO       string
+	Name      string
+	Variation string
+	// Ply is how far into the game the opening was recognised
+	Ply int
+}
+
+var ecoOnce sync.Once
+var ecoPositions map[uint64]Opening
+
+// loadECO replays every line of the table and keys its opening by the
+// position it reaches
+func loadECO() {
+	ecoPositions = make(map[uint64]Opening)
+	for lineNum, line := range strings.Split(strings.TrimSpace(ecoTable), "\n") {
+		fields := strings.Split(line, "\t")
+		if len(fields) != 3 {
+			panic(fmt.Sprintf("eco.tsv line %d: expected 3 fields, got %d", lineNum+1, len(fields)))
+		}
+		opening := Opening{ECO: fields[0], Name: fields[1]}
+		if before, after, found := strings.Cut(fields[1], ": "); found {
+			opening.Name, opening.Variation = before, after
+		}
+		chess := New()
+		for _, token := range strings.Fields(fields[2]) {
+			if strings.HasSuffix(token, ".") {
+				continue
+			}
+			if err := chess.Move(token); err != nil {
+				panic(fmt.Sprintf("eco.tsv line %d: %v", lineNum+1, err))
+			}
+		}
+		// The first line reaching a position names it
+		if _, ok := ecoPositions[chess.hash()]; !ok {
+			ecoPositions[chess.hash()] = opening
+		}
+	}
+}
+
+// Classify finds the opening of a game from the last position in its history
+// that the ECO table knows. Positions are matched rather than move orders, so
+// transpositions are recognised. It returns false if no position is known.
+// Ply is set to how many plies into the game the opening's position was.
+func Classify(chess *Chess) (Opening, bool) {
+	ecoOnce.Do(loadECO)
+	var retVal Opening
+	found := false
+	replayed := New()
+	replayed.Load(chess.InitialFen())
+	history := chess.History()
+	for cntr := 0; cntr <= len(history); cntr++ {
+		if opening, ok := ecoPositions[replayed.hash()]; ok {
+			retVal, found = opening, true
+			retVal.Ply = cntr
+		}
+		if cntr < len(history) {
+			replayed.makeMove(history[cntr])
+		}
+	}
+	return retVal, found
+}
+
+// SetTags sets the ECO, Opening and Variation tags from the opening. The
+// Variation tag is removed if the opening doesn't have one.
+func (opening Opening) SetTags(tags map[string]string) {
+	tags["ECO"] = opening.ECO
+	tags["Opening"] = opening.Name
+	if opening.Variation != "" {
+		tags["Variation"] = opening.Variation
+	} else {
+		delete(tags, "Variation")
+	}
+}
+
+// SetOpening sets the game's ECO, Opening and Variation headers from the opening
+func (chess *Chess) SetOpening(opening Opening) {
+	opening.SetTags(chess.header)
+}
@@ -0,0 +1,113 @@
+package chess
+
+import (
+	"fmt"
+	"testing"
+)
+
+func playMoves(t *testing.T, sans ...string) *Chess {
+	retVal := New()
+	for _, san := range sans {
+		if err := retVal.Move(san); err != nil {
+			t.Fatalf("Couldn't play %s: %v", san, err)
+		}
+	}
+	return retVal
+}
+
+func TestECOTableLoads(t *testing.T) {
+	ecoOnce.Do(loadECO)
+	if len(ecoPositions) < 500 {
+		t.Errorf("Expected at least 500 positions, got %d", len(ecoPositions))
+	}
+}
+
+func TestECOTableCoversEveryCode(t *testing.T) {
+	ecoOnce.Do(loadECO)
+	codes := make(map[string]bool)
+	for _, opening := range ecoPositions {
+		codes[opening.ECO] = true
+	}
+	for _, volume := range "ABCDE" {
+		for cntr := 0; cntr < 100; cntr++ {
+			if code := fmt.Sprintf("%c%02d", volume, cntr); !codes[code] {
+				t.Errorf("Expected a position for %s", code)
+			}
+		}
+	}
+}
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		moves     []string
+		eco       string
+		name      string
+		variation string
+		ply       int
+	}{
+		{[]string{"e4", "c5", "Nf3", "d6", "d4", "cxd4", "Nxd4", "Nf6", "Nc3", "a6", "Be3", "e5"}, "B90", "Sicilian Defense", "Najdorf Variation, English Attack", 11},
+		{[]string{"e4", "e5", "Nf3", "Nc6", "Bb5", "h6"}, "C60", "Ruy Lopez", "", 5},
+		// The Four Knights by a Vienna move order
+		{[]string{"e4", "e5", "Nc3", "Nf6", "Nf3", "Nc6"}, "C47", "Four Knights Game", "", 6},
+		// The Catalan reached from the English
+		{[]string{"c4", "Nf6", "g3", "e6", "d4"}, "E00", "Catalan Opening", "", 5},
+	}
+	for _, test := range tests {
+		opening, found := Classify(playMoves(t, test.moves...))
+		if !found || opening.ECO != test.eco || opening.Name != test.name || opening.Variation != test.variation || opening.Ply != test.ply {
+			t.Errorf("Expected %s %s: %s at ply %d for %v, got %v", test.eco, test.name, test.variation, test.ply, test.moves, opening)
+		}
+	}
+
+	chess := New()
+	chess.Load("4k3/8/8/8/8/8/8/4K3 w - - 0 1")
+	if _, found := Classify(chess); found {
+		t.Errorf("Expected no opening for a bare position")
+	}
+}
+
+func TestClassifyDeepTranspositions(t *testing.T) {
+	tests := []struct {
+		moves []string
+		eco   string
+		ply   int
+	}{
+		// The Najdorf main line with ...Qc7 before ...Be7
+		{[]string{"e4", "c5", "Nf3", "d6", "d4", "cxd4", "Nxd4", "Nf6", "Nc3", "a6", "Bg5", "e6", "f4", "Qc7", "Qf3", "Be7", "O-O-O", "Nbd7"}, "B99", 18},
+		// The Orthodox QGD from a Reti move order
+		{[]string{"Nf3", "d5", "d4", "Nf6", "c4", "e6", "Nc3", "Be7", "Bg5", "O-O", "e3", "Nbd7", "Rc1"}, "D63", 13},
+		// The Mar del Plata from the English
+		{[]string{"Nf3", "Nf6", "c4", "g6", "Nc3", "Bg7", "e4", "d6", "d4", "O-O", "Be2", "e5", "O-O", "Nc6", "d5", "Ne7", "Ne1", "Nd7", "f3", "f5"}, "E99", 20},
+		// The Breyer with ...d6 before castling
+		{[]string{"e4", "e5", "Nf3", "Nc6", "Bb5", "a6", "Ba4", "Nf6", "O-O", "Be7", "Re1", "b5", "Bb3", "d6", "c3", "O-O", "h3", "Nb8", "d4"}, "C95", 19},
+		// A Sämisch Nimzo-Indian with the moves after the exchange swapped
+		{[]string{"d4", "Nf6", "c4", "e6", "Nc3", "Bb4", "a3", "Bxc3+", "bxc3", "c5", "e3", "O-O", "Bd3", "Nc6"}, "E29", 14},
+	}
+	for _, test := range tests {
+		opening, found := Classify(playMoves(t, test.moves...))
+		if !found || opening.ECO != test.eco || opening.Ply != test.ply {
+			t.Errorf("Expected %s at ply %d for %v, got %v", test.eco, test.ply, test.moves, opening)
+		}
+	}
+}
+
+func TestSetOpening(t *testing.T) {
+	chess := playMoves(t, "d4", "d5", "c4", "c6")
+	opening, _ := Classify(chess)
+	chess.SetOpening(opening)
+	game := chess.PGNGame()
+	if game.Tags["ECO"] != "D10" || game.Tags["Opening"] != "Slav Defense" {
+		t.Errorf("Unexpected tags %v", game.Tags)
+	}
+	if _, ok := game.Tags["Variation"]; ok {
+		t.Errorf("Expected no Variation tag, got %v", game.Tags)
+	}
+
+	chess.Move("cxd5")
+	chess.Move("cxd5")
+	opening, _ = Classify(chess)
+	opening.SetTags(game.Tags)
+	if game.Tags["ECO"] != "D10" || game.Tags["Variation"] != "Exchange Variation" {
+		t.Errorf("Unexpected tags %v", game.Tags)
+	}
+}
@@ -0,0 +1,291 @@
+package chess
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// EPD is an Extended Position Description record: a position and the
+// operations that describe it. See
+// https://www.chessprogramming.org/Extended_Position_Description
+type EPD struct {
+	Position   *Chess
+	Operations []EPDOperation
+}
+
+// EPDOperation is an opcode and its operands. For the opcodes whose operands
+// are moves, Moves has them resolved: bm, am, pm and sm from the position,
+// and pv as a line played from it.
+type EPDOperation struct {
+	Opcode   string
+	Operands []string
+	Moves    []Move
+}
+
+// ParseEPD parses an EPD record. The hmvc and fmvn operations, if there are
+// any, set the position's move counters.
+func ParseEPD(record string) (*EPD, error) {
+	fields := strings.Fields(record)
+	if len(fields) < 4 {
+		return nil, fmt.Errorf("Invalid EPD, expected at least 4 fields, got %d", len(fields))
+	}
+	// The operations are whatever follows the fourth field
+	rest := strings.TrimSpace(record)
+	for cntr := 0; cntr < 4; cntr++ {
+		rest = strings.TrimSpace(rest[len(strings.Fields(rest)[0]):])
+	}
+
+	retVal := &EPD{Position: New()}
+	operations, err := parseEPDOperations(rest)
+	halfMoves, fullMoves := "0", "1"
+	for _, operation := range operations {
+		if operation.Opcode == "hmvc" && len(operation.Operands) == 1 {
+			halfMoves = operation.Operands[0]
+		} else if operation.Opcode == "fmvn" && len(operation.Operands) == 1 {
+			fullMoves = operation.Operands[0]
+		}
+	}
+	if err == nil {
+		err = retVal.Position.Load(strings.Join(append(fields[:4:4], halfMoves, fullMoves), " "))
+	}
+	for cntr := 0; err == nil && cntr < len(operations); cntr++ {
+		err = retVal.Position.resolveEPDMoves(&operations[cntr])
+	}
+	if err != nil {
+		return nil, err
+	}
+	retVal.Operations = operations
+	return retVal, nil
+}
+
+// parseEPDOperations splits operations into opcodes and operands. Operands
+// in double quotes can have spaces and semicolons in them.
+func parseEPDOperations(text string) ([]EPDOperation, error) {
+	var retVal []EPDOperation
+	var err error
+	var tokens []string
+	var token strings.Builder
+	inQuotes, quoted := false, false
+	endToken := func() {
+		if token.Len() > 0 || quoted {
+			tokens = append(tokens, token.String())
+		}
+		token.Reset()
+		quoted = false
+	}
+	for _, char := range text {
+		switch {
+		case char == '"':
+			inQuotes = !inQuotes
+			quoted = true
+		case inQuotes:
+			token.WriteRune(char)
+		case char == ';':
+			endToken()
+			if len(tokens) > 0 {
+				retVal = append(retVal, EPDOperation{Opcode: tokens[0], Operands: tokens[1:]})
+			}
+			tokens = nil
+		case char == ' ' || char == '\t':
+			endToken()
+		default:
+			token.WriteRune(char)
+		}
+	}
+	endToken()
+	if inQuotes {
+		err = fmt.Errorf("Unterminated string in EPD operations '%s'", text)
+	} else if len(tokens) > 0 {
+		err = fmt.Errorf("EPD operation '%s' doesn't end with a semicolon", strings.Join(tokens, " "))
+	}
+	return retVal, err
+}
+
+// resolveEPDMoves turns an operation's SAN operands into moves, if its
+// opcode takes moves. Suites are written by hand and by many programs, so
+// the moves are read the way SloppySANToMove reads them.
+func (chess *Chess) resolveEPDMoves(operation *EPDOperation) error {
+	var err error
+	switch operation.Opcode {
+	case "bm", "am", "pm", "sm":
+		for cntr := 0; err == nil && cntr < len(operation.Operands); cntr++ {
+			var move Move
+			if move, err = chess.SloppySANToMove(operation.Operands[cntr]); err == nil {
+				operation.Moves = append(operation.Moves, move)
+			}
+		}
+	case "pv":
+		copied := chess.Clone()
+		for cntr := 0; err == nil && cntr < len(operation.Operands); cntr++ {
+			var move Move
+			if move, err = copied.SloppySANToMove(operation.Operands[cntr]); err == nil {
+				operation.Moves = append(operation.Moves, move)
+				copied.makeMove(move)
+			}
+		}
+	}
+	if err != nil {
+		err = fmt.Errorf("%s: %v", operation.Opcode, err)
+	}
+	return err
+}
+
+// EPDRecordError is a record ReadEPD couldn't parse and the line it's on
+type EPDRecordError struct {
+	Line int
+	Err  error
+}
+
+func (err EPDRecordError) Error() string {
+	return fmt.Sprintf("line %d: %v", err.Line, err.Err)
+}
+
+// EPDSkipped is the error from ReadEPD when some records couldn't be parsed.
+// They're left out, and the rest of the records are still returned.
+type EPDSkipped []EPDRecordError
+
+func (err EPDSkipped) Error() string {
+	var retVal []string
+	for _, skipped := range err {
+		retVal = append(retVal, skipped.Error())
+	}
+	return strings.Join(retVal, "; ")
+}
+
+// ReadEPD reads a file of EPD records, one per line. Blank lines and lines
+// starting with # are skipped. A record that can't be parsed doesn't stop
+// the others being read: the error is then an EPDSkipped listing them.
+func ReadEPD(reader io.Reader) ([]*EPD, error) {
+	var retVal []*EPD
+	var skipped EPDSkipped
+	scanner := bufio.NewScanner(reader)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if record, err := ParseEPD(line); err == nil {
+			retVal = append(retVal, record)
+		} else {
+			skipped = append(skipped, EPDRecordError{Line: lineNum, Err: err})
+		}
+	}
+	err := scanner.Err()
+	if err == nil && len(skipped) > 0 {
+		err = skipped
+	}
+	return retVal, err
+}
+
+// Operation returns the first operation with the opcode
+func (epd *EPD) Operation(opcode string) (EPDOperation, bool) {
+	for _, operation := range epd.Operations {
+		if operation.Opcode == opcode {
+			return operation, true
+		}
+	}
+	return EPDOperation{}, false
+}
+
+// ID returns the operand of the id operation, or "" if there isn't one
+func (epd *EPD) ID() string {
+	retVal := ""
+	if operation, ok := epd.Operation("id"); ok && len(operation.Operands) > 0 {
+		retVal = operation.Operands[0]
+	}
+	return retVal
+}
+
+// String writes the record as EPD, with move operands in SAN
+func (epd *EPD) String() string {
+	var buffer strings.Builder
+	fields := strings.Fields(epd.Position.GenerateFen())
+	buffer.WriteString(strings.Join(fields[:4], " "))
+	for _, operation := range epd.Operations {
+		buffer.WriteString(" " + operation.Opcode)
+		operands := operation.Operands
+		if operation.Opcode == "pv" {
+			operands = epd.Position.lineToSAN(operation.Moves)
+		} else if operation.Moves != nil {
+			operands = nil
+			for _, move := range operation.Moves {
+				operands = append(operands, epd.Position.moveToSAN(move))
+			}
+		}
+		for _, operand := range operands {
+			buffer.WriteString(" " + quoteEPDOperand(operation.Opcode, operand))
+		}
+		buffer.WriteString(";")
+	}
+	return buffer.String()
+}
+
+// quoteEPDOperand puts the operand in quotes if it's a string, which id and
+// the comment opcodes always take. EPD has no way to escape a quote inside
+// a string.
+func quoteEPDOperand(opcode string, operand string) string {
+	retVal := operand
+	isComment := len(opcode) == 2 && opcode[0] == 'c' && opcode[1] >= '0' && opcode[1] <= '9'
+	if opcode == "id" || isComment || operand == "" || strings.ContainsAny(operand, " \t;\"") {
+		retVal = `"` + operand + `"`
+	}
+	return retVal
+}
+
+// SuiteResult is how the search did on one record of a test suite
+type SuiteResult struct {
+	ID     string
+	Record *EPD
+	Search SearchResult
+	SAN    string
+	Passed bool
+}
+
+// SuiteReport is how the search did on a test suite. Records without a bm or
+// am operation aren't tests, and are left out.
+type SuiteReport struct {
+	Results []SuiteResult
+	Passed  int
+}
+
+// RunSuite searches each record's position with the limits and checks the
+// move found against its bm and am operations: it has to be one of the best
+// moves, if there are any, and none of the moves to avoid. The hash is
+// cleared before each position. onResult, if it isn't nil, is called after
+// each search.
+func (searcher *Searcher) RunSuite(records []*EPD, limits Limits, onResult func(SuiteResult)) SuiteReport {
+	var retVal SuiteReport
+	for _, record := range records {
+		best, hasBest := record.Operation("bm")
+		avoid, hasAvoid := record.Operation("am")
+		if !hasBest && !hasAvoid {
+			continue
+		}
+		searcher.ClearHash()
+		result := SuiteResult{ID: record.ID(), Record: record}
+		result.Search = searcher.Search(record.Position.Clone(), limits)
+		result.SAN = record.Position.moveToSAN(result.Search.BestMove)
+		result.Passed = !hasBest || containsMove(best.Moves, result.Search.BestMove)
+		result.Passed = result.Passed && !(hasAvoid && containsMove(avoid.Moves, result.Search.BestMove))
+		if result.Passed {
+			retVal.Passed++
+		}
+		retVal.Results = append(retVal.Results, result)
+		if onResult != nil {
+			onResult(result)
+		}
+	}
+	return retVal
+}
+
+func containsMove(moves []Move, move Move) bool {
+	retVal := false
+	for _, candidate := range moves {
+		retVal = retVal || candidate == move
+	}
+	return retVal
+}
@@ -0,0 +1,110 @@
+package chess
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseEPD(t *testing.T) {
+	record, err := ParseEPD(`r1bqkbnr/pppp1ppp/2n5/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R w KQkq - bm Bb5 Bc4; am Ng5; id "test; 1"; c0 "a comment"; acd 12; ce +35; pv Bb5 a6 Ba4; hmvc 2; fmvn 3;`)
+	if err != nil {
+		t.Fatalf("Couldn't parse the EPD: %v", err)
+	}
+	if fen := record.Position.GenerateFen(); fen != "r1bqkbnr/pppp1ppp/2n5/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R w KQkq - 2 3" {
+		t.Errorf("Unexpected position %s", fen)
+	}
+	if len(record.Operations) != 9 || record.ID() != "test; 1" {
+		t.Errorf("Unexpected operations %v", record.Operations)
+	}
+	best, _ := record.Operation("bm")
+	if len(best.Moves) != 2 || best.Moves[0].UCI() != "f1b5" || best.Moves[1].UCI() != "f1c4" {
+		t.Errorf("Unexpected best moves %v", best)
+	}
+	pv, _ := record.Operation("pv")
+	if len(pv.Moves) != 3 || pv.Moves[2].UCI() != "b5a4" {
+		t.Errorf("Unexpected pv %v", pv)
+	}
+	if score, _ := record.Operation("ce"); len(score.Operands) != 1 || score.Operands[0] != "+35" || score.Moves != nil {
+		t.Errorf("Unexpected ce %v", score)
+	}
+	expected := `r1bqkbnr/pppp1ppp/2n5/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R w KQkq - bm Bb5 Bc4; am Ng5; id "test; 1"; c0 "a comment"; acd 12; ce +35; pv Bb5 a6 Ba4; hmvc 2; fmvn 3;`
+	if written := record.String(); written != expected {
+		t.Errorf("Expected\n%s\ngot\n%s", expected, written)
+	}
+}
+
+func TestParseEPDErrors(t *testing.T) {
+	for _, record := range []string{
+		"8/8/8/8 w -",
+		"4k3/8/8/8/8/8/8/4K3 w - - bm Kd9;",
+		"4k3/8/8/8/8/8/8/4K3 w - - id \"open;",
+		"4k3/8/8/8/8/8/8/4K3 w - - id \"x\"",
+	} {
+		if _, err := ParseEPD(record); err == nil {
+			t.Errorf("Expected an error for %s", record)
+		}
+	}
+}
+
+const testSuite = `# Two mates in one and a position that isn't a test
+6k1/5ppp/8/8/8/8/8/R5K1 w - - bm Ra8; id "mate.1";
+r1bqkbnr/pppp1ppp/2n5/4p2Q/2B1P3/8/PPPP1PPP/RNB1K1NR w KQkq - bm Qxf7; id "mate.2";
+6k1/5ppp/8/8/8/8/8/R5K1 w - - am Ra8; id "avoid.1";
+
+4k3/8/8/8/8/8/8/4K3 w - - id "no test";
+`
+
+func TestRunSuite(t *testing.T) {
+	records, err := ReadEPD(strings.NewReader(testSuite))
+	if err != nil || len(records) != 4 {
+		t.Fatalf("Expected 4 records, got %d, %v", len(records), err)
+	}
+	var seen []string
+	report := NewSearcher(1).RunSuite(records, Limits{Depth: 3}, func(result SuiteResult) {
+		seen = append(seen, result.ID)
+	})
+	if len(report.Results) != 3 || report.Passed != 2 || strings.Join(seen, " ") != "mate.1 mate.2 avoid.1" {
+		t.Errorf("Unexpected report %v", report)
+	}
+	if result := report.Results[2]; result.Passed || result.SAN != "Ra8#" {
+		t.Errorf("Expected the move to avoid to fail, got %v", result)
+	}
+
+	if _, err = ReadEPD(strings.NewReader("\n8/8/8/8/8/8/8/9 w - - ;\n")); err == nil || !strings.HasPrefix(err.Error(), "line 2:") {
+		t.Errorf("Expected an error on line 2, got %v", err)
+	}
+}
+
+func TestEPDMovesAreReadSloppily(t *testing.T) {
+	record, err := ParseEPD(`r3k2r/8/8/8/8/8/8/R3K2R w KQkq - bm 0-0 Ra1-a8; am Ke1-f1; pv Ra1xa8+ Ke7;`)
+	if err != nil {
+		t.Fatalf("Got an error %v", err)
+	}
+	best, _ := record.Operation("bm")
+	avoid, _ := record.Operation("am")
+	line, _ := record.Operation("pv")
+	if len(best.Moves) != 2 || record.Position.SAN(best.Moves[0]) != "O-O" || record.Position.SAN(best.Moves[1]) != "Rxa8+" ||
+		len(avoid.Moves) != 1 || len(line.Moves) != 2 {
+		t.Errorf("Unexpected moves %v %v %v", best.Moves, avoid.Moves, line.Moves)
+	}
+}
+
+func TestReadEPDSkipsBadRecords(t *testing.T) {
+	suite := `6k1/5ppp/8/8/8/8/8/R5K1 w - - bm Ra8; id "mate.1";
+6k1/5ppp/8/8/8/8/8/R5K1 w - - bm Qa8; id "no queen";
+# A comment
+4k3/8/8/8/8/8/8/4K3 w - - id "open;
+6k1/5ppp/8/8/8/8/8/R5K1 w - - am Ra8; id "avoid.1";
+`
+	records, err := ReadEPD(strings.NewReader(suite))
+	skipped, ok := err.(EPDSkipped)
+	if !ok || len(skipped) != 2 || skipped[0].Line != 2 || skipped[1].Line != 4 {
+		t.Fatalf("Expected lines 2 and 4 skipped, got %v", err)
+	}
+	if len(records) != 2 || records[0].ID() != "mate.1" || records[1].ID() != "avoid.1" {
+		t.Errorf("Expected the other records, got %d", len(records))
+	}
+	if !strings.HasPrefix(err.Error(), "line 2: bm: ") || !strings.Contains(err.Error(), "; line 4: ") {
+		t.Errorf("Unexpected error %v", err)
+	}
+}
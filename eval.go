@@ -0,0 +1,136 @@
+package chess
+
+// Piece values in centipawns, the same proportions the browser bot in
+// movecalculator.js uses
+var pieceValues = map[PieceType]int{
+	pawn:   100,
+	knight: 300,
+	bishop: 300,
+	rook:   500,
+	queen:  900,
+	king:   0}
+
+// Piece square tables from white's point of view, a8 first. Black uses the
+// mirror image.
+var pieceSquareTables = map[PieceType][]int{
+	pawn: {
+		0, 0, 0, 0, 0, 0, 0, 0,
+		50, 50, 50, 50, 50, 50, 50, 50,
+		10, 10, 20, 30, 30, 20, 10, 10,
+		5, 5, 10, 25, 25, 10, 5, 5,
+		0, 0, 0, 20, 20, 0, 0, 0,
+		5, -5, -10, 0, 0, -10, -5, 5,
+		5, 10, 10, -20, -20, 10, 10, 5,
+		0, 0, 0, 0, 0, 0, 0, 0},
+	knight: {
+		-50, -40, -30, -30, -30, -30, -40, -50,
+		-40, -20, 0, 0, 0, 0, -20, -40,
+		-30, 0, 10, 15, 15, 10, 0, -30,
+		-30, 5, 15, 20, 20, 15, 5, -30,
+		-30, 0, 15, 20, 20, 15, 0, -30,
+		-30, 5, 10, 15, 15, 10, 5, -30,
+		-40, -20, 0, 5, 5, 0, -20, -40,
+		-50, -40, -30, -30, -30, -30, -40, -50},
+	bishop: {
+		-20, -10, -10, -10, -10, -10, -10, -20,
+		-10, 0, 0, 0, 0, 0, 0, -10,
+		-10, 0, 5, 10, 10, 5, 0, -10,
+		-10, 5, 5, 10, 10, 5, 5, -10,
+		-10, 0, 10, 10, 10, 10, 0, -10,
+		-10, 10, 10, 10, 10, 10, 10, -10,
+		-10, 5, 0, 0, 0, 0, 5, -10,
+		-20, -10, -10, -10, -10, -10, -10, -20},
+	rook: {
+		0, 0, 0, 0, 0, 0, 0, 0,
+		5, 10, 10, 10, 10, 10, 10, 5,
+		-5, 0, 0, 0, 0, 0, 0, -5,
+		-5, 0, 0, 0, 0, 0, 0, -5,
+		-5, 0, 0, 0, 0, 0, 0, -5,
+		-5, 0, 0, 0, 0, 0, 0, -5,
+		-5, 0, 0, 0, 0, 0, 0, -5,
+		0, 0, 0, 5, 5, 0, 0, 0},
+	queen: {
+		-20, -10, -10, -5, -5, -10, -10, -20,
+		-10, 0, 0, 0, 0, 0, 0, -10,
+		-10, 0, 5, 5, 5, 5, 0, -10,
+		-5, 0, 5, 5, 5, 5, 0, -5,
+		0, 0, 5, 5, 5, 5, 0, -5,
+		-10, 5, 5, 5, 5, 5, 0, -10,
+		-10, 0, 5, 0, 0, 0, 0, -10,
+		-20, -10, -10, -5, -5, -10, -10, -20},
+	king: {
+		-30, -40, -40, -50, -50, -40, -40, -30,
+		-30, -40, -40, -50, -50, -40, -40, -30,
+		-30, -40, -40, -50, -50, -40, -40, -30,
+		-30, -40, -40, -50, -50, -40, -40, -30,
+		-20, -30, -30, -40, -40, -30, -30, -20,
+		-10, -20, -20, -20, -20, -20, -20, -10,
+		20, 20, 0, 0, 0, 0, 20, 20,
+		20, 30, 10, 0, 0, 10, 30, 20}}
+
+// In the endgame the king should head for the centre
+var kingEndgameTable = []int{
+	-50, -40, -30, -20, -20, -30, -40, -50,
+	-30, -20, -10, 0, 0, -10, -20, -30,
+	-30, -10, 20, 30, 30, 20, -10, -30,
+	-30, -10, 30, 40, 40, 30, -10, -30,
+	-30, -10, 30, 40, 40, 30, -10, -30,
+	-30, -10, 20, 30, 30, 20, -10, -30,
+	-30, -30, 0, 0, 0, 0, -30, -30,
+	-50, -30, -30, -30, -30, -30, -30, -50}
+
+// Once the material other than kings and pawns drops to this, it's an endgame
+const endgameMaterial = 1300
+
+// evaluate returns a static score for the position in centipawns, from the
+// point of view of the side to move
+func (chess *Chess) evaluate() int {
+	// Indexed by colorIndex
+	var scores [2]int
+	var kingSquares = [2]int{emptySquare, emptySquare}
+	nonPawnMaterial := 0
+
+	for cntr := squareNameToID["a8"]; cntr <= squareNameToID["h1"]; cntr++ {
+		if cntr&0x88 != 0 {
+			cntr += 7
+			continue
+		}
+		piece := chess.board[cntr]
+		if piece.IsUnspecified() {
+			continue
+		}
+		if piece.ptype == king {
+			kingSquares[colorIndex(piece.pcolor)] = cntr
+			continue
+		}
+		if piece.ptype != pawn {
+			nonPawnMaterial += pieceValues[piece.ptype]
+		}
+		scores[colorIndex(piece.pcolor)] += pieceValues[piece.ptype] + pieceSquareTables[piece.ptype][tableIndex(cntr, piece.pcolor)]
+	}
+
+	kingTable := pieceSquareTables[king]
+	if nonPawnMaterial <= endgameMaterial {
+		kingTable = kingEndgameTable
+	}
+	for index, color := range []PieceColor{white, black} {
+		if kingSquares[index] != emptySquare {
+			scores[index] += kingTable[tableIndex(kingSquares[index], color)]
+		}
+	}
+
+	retVal := scores[0] - scores[1]
+	if chess.turn == black {
+		retVal = -retVal
+	}
+	return retVal
+}
+
+// tableIndex converts a 0x88 square into an index into a piece square table
+func tableIndex(square int, color PieceColor) int {
+	r := rank(square)
+	if color == black {
+		r = 7 - r
+	}
+	return r*8 + file(square)
+}
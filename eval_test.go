@@ -0,0 +1,30 @@
+package chess
+
+import "testing"
+
+func TestEvaluateStartIsLevel(t *testing.T) {
+	chess := New()
+	if chess.evaluate() != 0 {
+		t.Errorf("Expected 0, got %d", chess.evaluate())
+	}
+}
+
+func TestEvaluateIsFromSideToMove(t *testing.T) {
+	chess := New()
+	chess.Load("4k3/8/8/8/8/8/8/3QK3 w - - 0 1")
+	whiteScore := chess.evaluate()
+	if whiteScore < pieceValues[queen]-100 {
+		t.Errorf("Expected about a queen for white, got %d", whiteScore)
+	}
+
+	chess.Load("4k3/8/8/8/8/8/8/3QK3 b - - 0 1")
+	if chess.evaluate() != -whiteScore {
+		t.Errorf("Expected %d for black, got %d", -whiteScore, chess.evaluate())
+	}
+}
+
+func TestTableIndexMirrorsForBlack(t *testing.T) {
+	if tableIndex(squareNameToID["e2"], white) != tableIndex(squareNameToID["e7"], black) {
+		t.Errorf("Expected e2 for white to match e7 for black")
+	}
+}
@@ -0,0 +1,264 @@
+package chess
+
+import (
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ExplorerOptions controls how much an Explorer keeps
+type ExplorerOptions struct {
+	// MaxPly is how many plies of each game are recorded. Zero means all of them.
+	MaxPly int
+	// RecentGames is how many of the latest games are kept for each position. Zero means 5.
+	RecentGames int
+}
+
+// ExplorerStats counts how the games reaching a position, or playing a
+// move, ended
+type ExplorerStats struct {
+	Games     int
+	WhiteWins int
+	Draws     int
+	BlackWins int
+	ratingSum int
+	rated     int
+}
+
+// ExplorerGame is a game as the explorer lists it
+type ExplorerGame struct {
+	White    string
+	Black    string
+	WhiteElo int
+	BlackElo int
+	Event    string
+	Date     string
+	Result   string
+}
+
+// ExplorerMove is a move played from a position and how it turned out. Its
+// average rating is of the players who played it.
+type ExplorerMove struct {
+	ExplorerStats
+	Move Move
+	SAN  string
+}
+
+// ExplorerPosition is what the explorer knows about a position: how the games
+// reaching it ended, the moves played from it, most played first, and the
+// latest games to reach it, newest first. Its average rating is of both
+// players in those games.
+type ExplorerPosition struct {
+	ExplorerStats
+	Moves  []ExplorerMove
+	Recent []ExplorerGame
+}
+
+// explorerEntry is what's recorded for a position. recent holds indexes
+// into the explorer's games.
+type explorerEntry struct {
+	stats  ExplorerStats
+	moves  map[Move]*ExplorerStats
+	recent []int
+}
+
+// Explorer collects opening statistics from games. Positions are looked up
+// by the same key that repetitions are counted by, so move orders that
+// transpose to the same position share their statistics.
+type Explorer struct {
+	options   ExplorerOptions
+	positions map[uint64]*explorerEntry
+	games     []ExplorerGame
+}
+
+const defaultRecentGames = 5
+
+// NewExplorer creates an Explorer with no games
+func NewExplorer(options ExplorerOptions) *Explorer {
+	if options.RecentGames <= 0 {
+		options.RecentGames = defaultRecentGames
+	}
+	return &Explorer{options: options, positions: make(map[uint64]*explorerEntry)}
+}
+
+// Games returns how many games have been added
+func (explorer *Explorer) Games() int {
+	return len(explorer.games)
+}
+
+// AddPGN adds every game read from reader. Games with illegal moves are
+// skipped; the number of games added is returned.
+func (explorer *Explorer) AddPGN(reader io.Reader) (int, error) {
+	added := 0
+	pgnReader := NewPGNReader(reader)
+	game, err := pgnReader.Next()
+	for err == nil {
+		if explorer.AddGame(game) == nil {
+			added++
+		}
+		game, err = pgnReader.Next()
+	}
+	if err == io.EOF {
+		err = nil
+	}
+	return added, err
+}
+
+// AddGame records the positions a game reached and the moves played from
+// them. A position the game repeats is only counted once. Nothing is
+// recorded if any of the moves is illegal.
+func (explorer *Explorer) AddGame(game *PGNGame) error {
+	chess, err := game.start()
+
+	type record struct {
+		key  uint64
+		move Move
+		last bool
+	}
+	var records []record
+	seen := make(map[uint64]bool)
+	for cntr := 0; err == nil && cntr <= len(game.Moves) && (explorer.options.MaxPly <= 0 || cntr <= explorer.options.MaxPly); cntr++ {
+		rec := record{key: chess.hash(), last: cntr == len(game.Moves) || (cntr == explorer.options.MaxPly && cntr > 0)}
+		if !rec.last {
+			rec.move, err = chess.SANToMove(game.Moves[cntr])
+		}
+		if err == nil && !seen[rec.key] {
+			seen[rec.key] = true
+			records = append(records, rec)
+		}
+		if err == nil && !rec.last {
+			chess.makeMove(rec.move)
+		}
+	}
+
+	if err == nil {
+		info := explorerGame(game)
+		index := len(explorer.games)
+		explorer.games = append(explorer.games, info)
+		for _, rec := range records {
+			entry, ok := explorer.positions[rec.key]
+			if !ok {
+				entry = &explorerEntry{moves: make(map[Move]*ExplorerStats)}
+				explorer.positions[rec.key] = entry
+			}
+			entry.stats.add(game.Result, info.WhiteElo, info.BlackElo)
+			explorer.addRecent(entry, index)
+			if !rec.last {
+				stats, ok := entry.moves[rec.move]
+				if !ok {
+					stats = new(ExplorerStats)
+					entry.moves[rec.move] = stats
+				}
+				rating := info.WhiteElo
+				if rec.move.turn == black {
+					rating = info.BlackElo
+				}
+				stats.add(game.Result, rating)
+			}
+		}
+	}
+	return err
+}
+
+// Position returns what's been recorded for the current position of chess
+func (explorer *Explorer) Position(chess *Chess) ExplorerPosition {
+	var retVal ExplorerPosition
+	if entry, ok := explorer.positions[chess.hash()]; ok {
+		retVal.ExplorerStats = entry.stats
+		for move, stats := range entry.moves {
+			retVal.Moves = append(retVal.Moves, ExplorerMove{ExplorerStats: *stats, Move: move, SAN: chess.moveToSAN(move)})
+		}
+		sort.Slice(retVal.Moves, func(i, j int) bool {
+			first, second := retVal.Moves[i], retVal.Moves[j]
+			return first.Games > second.Games || (first.Games == second.Games && first.SAN < second.SAN)
+		})
+		for _, index := range entry.recent {
+			retVal.Recent = append(retVal.Recent, explorer.games[index])
+		}
+	}
+	return retVal
+}
+
+// addRecent keeps the game if it's one of the latest to reach the position.
+// Games are ordered by date, with unknown parts of dates as early as can be,
+// and games added later count as newer.
+func (explorer *Explorer) addRecent(entry *explorerEntry, index int) {
+	sortable := func(date string) string { return strings.ReplaceAll(date, "?", "0") }
+	date := sortable(explorer.games[index].Date)
+	at := sort.Search(len(entry.recent), func(position int) bool {
+		return sortable(explorer.games[entry.recent[position]].Date) <= date
+	})
+	if at < explorer.options.RecentGames {
+		entry.recent = append(entry.recent, 0)
+		copy(entry.recent[at+1:], entry.recent[at:])
+		entry.recent[at] = index
+		if len(entry.recent) > explorer.options.RecentGames {
+			entry.recent = entry.recent[:explorer.options.RecentGames]
+		}
+	}
+}
+
+func explorerGame(game *PGNGame) ExplorerGame {
+	retVal := ExplorerGame{
+		White:  game.Tags["White"],
+		Black:  game.Tags["Black"],
+		Event:  game.Tags["Event"],
+		Date:   game.Tags["Date"],
+		Result: game.Result}
+	retVal.WhiteElo, _ = strconv.Atoi(game.Tags["WhiteElo"])
+	retVal.BlackElo, _ = strconv.Atoi(game.Tags["BlackElo"])
+	return retVal
+}
+
+// add counts a game, with the ratings of whoever's being averaged. Ratings
+// of zero are missing and left out of the average.
+func (stats *ExplorerStats) add(result string, ratings ...int) {
+	stats.Games++
+	switch result {
+	case "1-0":
+		stats.WhiteWins++
+	case "1/2-1/2":
+		stats.Draws++
+	case "0-1":
+		stats.BlackWins++
+	}
+	for _, rating := range ratings {
+		if rating > 0 {
+			stats.ratingSum += rating
+			stats.rated++
+		}
+	}
+}
+
+// WhitePercent returns the percentage of the games white won
+func (stats ExplorerStats) WhitePercent() float64 {
+	return stats.percent(stats.WhiteWins)
+}
+
+// DrawPercent returns the percentage of the games drawn
+func (stats ExplorerStats) DrawPercent() float64 {
+	return stats.percent(stats.Draws)
+}
+
+// BlackPercent returns the percentage of the games black won
+func (stats ExplorerStats) BlackPercent() float64 {
+	return stats.percent(stats.BlackWins)
+}
+
+// AverageRating returns the average rating, or 0 if none of the games had ratings
+func (stats ExplorerStats) AverageRating() int {
+	retVal := 0
+	if stats.rated > 0 {
+		retVal = stats.ratingSum / stats.rated
+	}
+	return retVal
+}
+
+func (stats ExplorerStats) percent(count int) float64 {
+	retVal := 0.0
+	if stats.Games > 0 {
+		retVal = 100 * float64(count) / float64(stats.Games)
+	}
+	return retVal
+}
@@ -0,0 +1,101 @@
+package chess
+
+import (
+	"strings"
+	"testing"
+)
+
+const explorerGames = `[White "A"]
+[Black "B"]
+[WhiteElo "2400"]
+[BlackElo "2200"]
+[Date "2020.01.01"]
+[Result "1-0"]
+
+1. e4 e5 2. Nf3 Nc6 1-0
+
+[White "C"]
+[Black "D"]
+[WhiteElo "2000"]
+[BlackElo "2300"]
+[Date "2022.06.01"]
+[Result "1/2-1/2"]
+
+1. Nf3 Nc6 2. e4 e5 3. Bb5 1/2-1/2
+
+[White "E"]
+[Black "F"]
+[Date "2021.??.??"]
+[Result "0-1"]
+
+1. e4 c5 0-1
+
+[White "G"]
+[Black "H"]
+[Result "1-0"]
+
+1. e4 e4 1-0
+`
+
+func TestExplorerStats(t *testing.T) {
+	explorer := NewExplorer(ExplorerOptions{})
+	added, err := explorer.AddPGN(strings.NewReader(explorerGames))
+	if err != nil || added != 3 || explorer.Games() != 3 {
+		t.Fatalf("Expected 3 games, got %d, %v", added, err)
+	}
+
+	start := explorer.Position(New())
+	if start.Games != 3 || start.WhiteWins != 1 || start.Draws != 1 || start.BlackWins != 1 {
+		t.Errorf("Unexpected stats for the initial position %v", start.ExplorerStats)
+	}
+	if len(start.Moves) != 2 || start.Moves[0].SAN != "e4" || start.Moves[0].Games != 2 || start.Moves[1].SAN != "Nf3" {
+		t.Errorf("Unexpected moves %v", start.Moves)
+	}
+	if e4 := start.Moves[0]; e4.WhitePercent() != 50 || e4.BlackPercent() != 50 || e4.AverageRating() != 2400 {
+		t.Errorf("Unexpected e4 stats %v", e4)
+	}
+	if start.AverageRating() != 2225 {
+		t.Errorf("Expected an average rating of 2225, got %d", start.AverageRating())
+	}
+	if len(start.Recent) != 3 || start.Recent[0].White != "C" || start.Recent[1].White != "E" || start.Recent[2].White != "A" {
+		t.Errorf("Unexpected recent games %v", start.Recent)
+	}
+}
+
+func TestExplorerTranspositions(t *testing.T) {
+	explorer := NewExplorer(ExplorerOptions{RecentGames: 1})
+	explorer.AddPGN(strings.NewReader(explorerGames))
+
+	chess := New()
+	for _, san := range []string{"e4", "e5", "Nf3", "Nc6"} {
+		chess.Move(san)
+	}
+	position := explorer.Position(chess)
+	if position.Games != 2 || position.DrawPercent() != 50 || len(position.Moves) != 1 || position.Moves[0].SAN != "Bb5" {
+		t.Errorf("Expected both move orders to reach the position, got %v", position)
+	}
+	if len(position.Recent) != 1 || position.Recent[0].White != "C" {
+		t.Errorf("Expected only the latest game, got %v", position.Recent)
+	}
+
+	chess.Move("a3")
+	if position = explorer.Position(chess); position.Games != 0 || position.Moves != nil {
+		t.Errorf("Expected nothing for an unseen position, got %v", position)
+	}
+}
+
+func TestExplorerMaxPly(t *testing.T) {
+	explorer := NewExplorer(ExplorerOptions{MaxPly: 1})
+	explorer.AddPGN(strings.NewReader(explorerGames))
+
+	chess := New()
+	chess.Move("e4")
+	// The illegal move in the last game is past MaxPly, so it's added
+	if position := explorer.Position(chess); position.Games != 3 || len(position.Moves) != 0 {
+		t.Errorf("Expected positions but no moves at MaxPly, got %v", position)
+	}
+	chess.Move("e5")
+	if position := explorer.Position(chess); position.Games != 0 {
+		t.Errorf("Expected nothing recorded past MaxPly, got %v", position)
+	}
+}
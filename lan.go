@@ -0,0 +1,110 @@
+package chess
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+var lanRegexp = regexp.MustCompile(`^([NBRQK])?([a-h][1-8])([-x])([a-h][1-8])(=[NBRQ])?$`)
+
+// iccfRegexp is a move in ICCF numeric notation: the file and rank of the
+// square moved from and to, with a digit for the piece promoted to
+var iccfRegexp = regexp.MustCompile(`^[1-8]{4}[1-4]?$`)
+
+// iccfPromotions is the piece each ICCF promotion digit, 1 to 4, stands for
+var iccfPromotions = []PieceType{queen, rook, bishop, knight}
+
+// LAN returns the move in long algebraic notation: the piece, the square it
+// moves from, - or x, and the square it moves to, as in Ng1-f3 or e4xd5, with
+// + or # if it checks or mates
+func (chess *Chess) LAN(move Move) string {
+	retVal := ""
+	if (move.flags & ksideCastleMove) != 0 {
+		retVal = "O-O"
+	} else if (move.flags & qsideCastleMove) != 0 {
+		retVal = "O-O-O"
+	} else {
+		if move.ptype != pawn {
+			retVal = string(unicode.ToUpper(rune(move.ptype)))
+		}
+		separator := "-"
+		if (move.flags & (captureMove | enpassantMove)) != 0 {
+			separator = "x"
+		}
+		retVal += algebraic(move.from) + separator + algebraic(move.to)
+		if (move.flags & promotionMove) != 0 {
+			retVal += "=" + string(unicode.ToUpper(rune(move.promotedType)))
+		}
+	}
+	return retVal + chess.checkSuffix(move)
+}
+
+// LANToMove converts a move in long algebraic notation into a Move, if it's a
+// legal move, or returns an error. Castling is written as in SAN.
+func (chess *Chess) LANToMove(lan string) (Move, error) {
+	var retVal Move
+	var err error
+	cleaned := cleanSAN(lan)
+	groups := lanRegexp.FindStringSubmatch(cleaned)
+	switch {
+	case cleaned == "O-O" || cleaned == "O-O-O":
+		retVal, err = chess.SANToMove(cleaned)
+	case groups != nil:
+		ptype := pawn
+		if groups[1] != "" {
+			ptype = pieceFromLetter(groups[1])
+		}
+		var promotion PieceType
+		if groups[5] != "" {
+			promotion = pieceFromLetter(groups[5][1:])
+		}
+		for _, move := range chess.Moves(true, groups[2]) {
+			capture := (move.flags & (captureMove | enpassantMove)) != 0
+			if move.ptype == ptype && algebraic(move.to) == groups[4] && move.promotedType == promotion && capture == (groups[3] == "x") {
+				retVal = move
+			}
+		}
+	}
+	if err == nil && retVal.ptype == 0 {
+		err = fmt.Errorf("%s not a legal move", lan)
+	}
+	return retVal, err
+}
+
+// ICCF returns the move in ICCF numeric notation, used in correspondence
+// chess: the file and rank of the square moved from and to as digits, a1
+// being 11, and a digit for a promotion, 1 for a queen to 4 for a knight.
+// Castling is the king's move, 5171 for white castling king side.
+func (move Move) ICCF() string {
+	var buffer strings.Builder
+	for _, square := range []int{move.from, move.to} {
+		name := algebraic(square)
+		buffer.WriteByte(name[0] - 'a' + '1')
+		buffer.WriteByte(name[1])
+	}
+	for cntr, promotion := range iccfPromotions {
+		if move.flags&promotionMove != 0 && move.promotedType == promotion {
+			buffer.WriteByte(byte('1' + cntr))
+		}
+	}
+	return buffer.String()
+}
+
+// ICCFToMove converts a move in ICCF numeric notation into a Move, if it's a
+// legal move, or returns an error
+func (chess *Chess) ICCFToMove(iccf string) (Move, error) {
+	var retVal Move
+	err := fmt.Errorf("%s not a legal move", iccf)
+	if iccfRegexp.MatchString(iccf) {
+		uci := string([]byte{iccf[0] - '1' + 'a', iccf[1], iccf[2] - '1' + 'a', iccf[3]})
+		if len(iccf) == 5 {
+			uci += string(rune(iccfPromotions[iccf[4]-'1']))
+		}
+		if move, uciErr := chess.UCIToMove(uci); uciErr == nil {
+			retVal, err = move, nil
+		}
+	}
+	return retVal, err
+}
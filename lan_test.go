@@ -0,0 +1,41 @@
+package chess
+
+import "testing"
+
+func TestLAN(t *testing.T) {
+	chess := New()
+	chess.Load("1r2k3/P7/8/3pP3/8/8/8/R3K1N1 w Q d6 0 1")
+	for uci, expected := range map[string]string{"g1f3": "Ng1-f3", "e5d6": "e5xd6", "a7b8n": "a7xb8=N", "e1c1": "O-O-O", "a7b8q": "a7xb8=Q+"} {
+		move, err := chess.UCIToMove(uci)
+		if lan := chess.LAN(move); err != nil || lan != expected {
+			t.Errorf("Expected %s, got %s, %v", expected, lan, err)
+		}
+		if parsed, err := chess.LANToMove(expected); err != nil || parsed != move {
+			t.Errorf("Expected %s to read back, got %v", expected, err)
+		}
+	}
+	for _, lan := range []string{"Ng1xf3", "e5-d6", "Ng1-f4", "Ne1-f3", "a7-b8", "g1-f3", "Ng1f3", "a7xb8", ""} {
+		if _, err := chess.LANToMove(lan); err == nil {
+			t.Errorf("Expected %s not to be a legal move", lan)
+		}
+	}
+}
+
+func TestICCF(t *testing.T) {
+	chess := New()
+	chess.Load("4k3/P7/8/8/8/8/8/R3K1NR w KQ - 0 1")
+	for uci, expected := range map[string]string{"g1f3": "7163", "e1c1": "5131", "a7a8q": "17181", "a7a8n": "17184"} {
+		move, err := chess.UCIToMove(uci)
+		if iccf := move.ICCF(); err != nil || iccf != expected {
+			t.Errorf("Expected %s, got %s, %v", expected, iccf, err)
+		}
+		if parsed, err := chess.ICCFToMove(expected); err != nil || parsed != move {
+			t.Errorf("Expected %s to read back, got %v", expected, err)
+		}
+	}
+	for _, iccf := range []string{"1718", "7164", "17185", "9999", "71-63", ""} {
+		if _, err := chess.ICCFToMove(iccf); err == nil {
+			t.Errorf("Expected %s not to be a legal move", iccf)
+		}
+	}
+}
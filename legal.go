@@ -0,0 +1,112 @@
+package chess
+
+// legality is what's needed to tell which pseudo-legal moves are legal
+// without making them: the king of the side to move, the pieces checking
+// it, the squares a piece can move to to get out of a single check, and the
+// pinned pieces with the direction from the king of the ray each is pinned on
+type legality struct {
+	king     int
+	checkers []int
+	evasions [128]bool
+	pinned   [128]int
+}
+
+// newLegality works out the checks and pins in the current position
+func (chess *Chess) newLegality() *legality {
+	ourColor := chess.turn
+	theirColor := swapColor(ourColor)
+	retVal := &legality{king: chess.kings[ourColor]}
+	if retVal.king == emptySquare {
+		return retVal
+	}
+	retVal.checkers = chess.attackers(theirColor, retVal.king)
+
+	if len(retVal.checkers) == 1 {
+		// Capture the checker, or get in the way if it's a slider
+		checker := retVal.checkers[0]
+		retVal.evasions[checker] = true
+		if ptype := chess.board[checker].ptype; ptype == bishop || ptype == rook || ptype == queen {
+			offset := rays[retVal.king-checker+119]
+			for square := retVal.king + offset; square != checker; square += offset {
+				retVal.evasions[square] = true
+			}
+		}
+	}
+
+	for _, offset := range pieceOffsets[queen] {
+		slider := rook
+		if offset == -17 || offset == -15 || offset == 15 || offset == 17 {
+			slider = bishop
+		}
+		ours := emptySquare
+		for square := retVal.king + offset; square&0x88 == 0; square += offset {
+			piece := chess.board[square]
+			if piece.IsUnspecified() {
+				continue
+			}
+			if piece.pcolor == ourColor && ours == emptySquare {
+				ours = square
+				continue
+			}
+			if piece.pcolor == theirColor && ours != emptySquare && (piece.ptype == slider || piece.ptype == queen) {
+				retVal.pinned[ours] = offset
+			}
+			break
+		}
+	}
+	return retVal
+}
+
+// doubleCheck returns true if only the king can move
+func (legality *legality) doubleCheck() bool {
+	return len(legality.checkers) > 1
+}
+
+// legal returns true if the pseudo-legal move doesn't leave the king
+// attacked. Castling is already checked when it's generated.
+func (legality *legality) legal(chess *Chess, move Move) bool {
+	retVal := true
+	theirColor := swapColor(move.turn)
+	switch {
+	case legality.king == emptySquare:
+	case move.flags&(ksideCastleMove|qsideCastleMove) != 0:
+	case move.ptype == king:
+		// The king can't stay on a line it's being attacked along
+		retVal = !chess.attackedAfter(theirColor, move.to, move.from, emptySquare, move.to)
+	case move.flags&enpassantMove != 0:
+		// Both pawns leave the board, which can uncover a check along the
+		// rank that neither pin nor check tells us about
+		captured := move.to + 16
+		if move.turn == black {
+			captured = move.to - 16
+		}
+		retVal = !chess.attackedAfter(theirColor, legality.king, move.from, captured, move.to)
+	case legality.doubleCheck():
+		retVal = false
+	case len(legality.checkers) == 1 && !legality.evasions[move.to]:
+		retVal = false
+	case legality.pinned[move.from] != 0:
+		retVal = rays[legality.king-move.to+119] == legality.pinned[move.from]
+	}
+	return retVal
+}
+
+// attackedAfter returns true if colorAttacking would attack the square with
+// the pieces on vacated and alsoVacated gone and a piece on filled. A piece
+// of colorAttacking on filled or a vacated square has been taken, so doesn't
+// attack anything.
+func (chess *Chess) attackedAfter(colorAttacking PieceColor, squareNumAttacked int, vacated int, alsoVacated int, filled int) bool {
+	retVal := false
+	for cntr := squareNameToID["a8"]; retVal == false && cntr <= squareNameToID["h1"]; cntr++ {
+		if cntr&0x88 != 0 {
+			cntr += 7
+			continue
+		}
+		if chess.board[cntr].IsUnspecified() || chess.board[cntr].pcolor != colorAttacking ||
+			cntr == vacated || cntr == alsoVacated || cntr == filled {
+			continue
+		}
+		retVal = chess.attacksAfter(cntr, squareNumAttacked, vacated, alsoVacated, filled)
+	}
+	return retVal
+}
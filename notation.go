@@ -0,0 +1,123 @@
+package chess
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Notation is the symbols pieces are written with in algebraic notation: the
+// king, queen, rook, bishop and knight, in that order. Pawns have none. The
+// zero Notation is English.
+type Notation [5]string
+
+// The notations most often seen in print
+var (
+	EnglishNotation  = Notation{"K", "Q", "R", "B", "N"}
+	GermanNotation   = Notation{"K", "D", "T", "L", "S"}
+	FrenchNotation   = Notation{"R", "D", "T", "F", "C"}
+	SpanishNotation  = Notation{"R", "D", "T", "A", "C"}
+	ItalianNotation  = Notation{"R", "D", "T", "A", "C"}
+	DutchNotation    = Notation{"K", "D", "T", "L", "P"}
+	FigurineNotation = Notation{"♔", "♕", "♖", "♗", "♘"}
+)
+
+// Notations are the notations by name, for choosing one in settings or on
+// the command line
+var Notations = map[string]Notation{
+	"en":       EnglishNotation,
+	"de":       GermanNotation,
+	"fr":       FrenchNotation,
+	"es":       SpanishNotation,
+	"it":       ItalianNotation,
+	"nl":       DutchNotation,
+	"figurine": FigurineNotation,
+}
+
+// orEnglish returns the notation, or English for the zero Notation
+func (notation Notation) orEnglish() Notation {
+	retVal := notation
+	if notation == (Notation{}) {
+		retVal = EnglishNotation
+	}
+	return retVal
+}
+
+// translateSAN rewrites the piece symbols in a move from one notation to
+// another. Every symbol is replaced in one pass, so French R for the king
+// doesn't become English R for the rook.
+func translateSAN(san string, from Notation, to Notation) string {
+	from, to = from.orEnglish(), to.orEnglish()
+	if from == to {
+		return san
+	}
+	var buffer strings.Builder
+	for len(san) > 0 {
+		matched := false
+		for cntr := 0; cntr < len(from) && !matched; cntr++ {
+			if strings.HasPrefix(san, from[cntr]) {
+				buffer.WriteString(to[cntr])
+				san = san[len(from[cntr]):]
+				matched = true
+			}
+		}
+		if !matched {
+			_, size := utf8.DecodeRuneInString(san)
+			buffer.WriteString(san[:size])
+			san = san[size:]
+		}
+	}
+	return buffer.String()
+}
+
+// SANToMoveIn converts a move written in the notation into a Move, if it's a
+// legal move, or returns an error
+func (chess *Chess) SANToMoveIn(san string, notation Notation) (Move, error) {
+	return chess.SANToMove(translateSAN(san, notation, EnglishNotation))
+}
+
+// SloppySANToMoveIn reads a move written in the notation the way
+// SloppySANToMove does. A piece letter typed in lower case is taken as the
+// piece if it can't be a file.
+func (chess *Chess) SloppySANToMoveIn(san string, notation Notation) (Move, error) {
+	translated := strings.TrimSpace(san)
+	first, size := utf8.DecodeRuneInString(translated)
+	if unicode.IsLower(first) && (first < 'a' || first > 'h') {
+		upper := string(unicode.ToUpper(first))
+		for _, symbol := range notation.orEnglish() {
+			if symbol == upper {
+				translated = upper + translated[size:]
+			}
+		}
+	}
+	move, err := chess.SloppySANToMove(translateSAN(translated, notation, EnglishNotation))
+	if sanErr, ok := err.(*SANError); ok {
+		sanErr.SAN = san
+		for cntr := range sanErr.Candidates {
+			sanErr.Candidates[cntr] = translateSAN(sanErr.Candidates[cntr], EnglishNotation, notation)
+		}
+	}
+	return move, err
+}
+
+// SANHistory returns the moves made since the position was set up, oldest
+// first, written as the options say
+func (chess *Chess) SANHistory(options SANOptions) []string {
+	start := New()
+	start.Load(chess.InitialFen())
+	return start.lineToSANWithOptions(chess.History(), options)
+}
+
+// InNotation returns a copy of the game with its moves, which are read and
+// written in English, rewritten in the notation. PGN files are meant to be
+// in English, so this is for showing to people rather than to other programs.
+func (game *PGNGame) InNotation(notation Notation) *PGNGame {
+	retVal := &PGNGame{Tags: make(map[string]string), Result: game.Result}
+	for key, value := range game.Tags {
+		retVal.Tags[key] = value
+	}
+	for _, san := range game.Moves {
+		retVal.Moves = append(retVal.Moves, translateSAN(san, EnglishNotation, notation))
+	}
+	return retVal
+}
@@ -0,0 +1,61 @@
+package chess
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSANInNotation(t *testing.T) {
+	chess := New()
+	chess.Load("r3k2r/1P6/8/8/8/8/8/R3K1NR w KQkq - 0 1")
+	for uci, expected := range map[string][]string{
+		"g1f3": {"Nf3", "Sf3", "Cf3", "♘f3"},
+		"e1c1": {"O-O-O", "O-O-O", "O-O-O", "O-O-O"},
+		"e1d2": {"Kd2", "Kd2", "Rd2", "♔d2"},
+		"a1a8": {"Rxa8+", "Txa8+", "Txa8+", "♖xa8+"},
+		"b7b8": {"b8=Q+", "b8=D+", "b8=D+", "b8=♕+"},
+	} {
+		move, err := chess.UCIToMove(uci)
+		if uci == "b7b8" {
+			move, err = chess.UCIToMove("b7b8q")
+		}
+		for cntr, notation := range []Notation{{}, GermanNotation, FrenchNotation, FigurineNotation} {
+			san := chess.SANWithOptions(move, SANOptions{Notation: notation})
+			if err != nil || san != expected[cntr] {
+				t.Errorf("Expected %s, got %s, %v", expected[cntr], san, err)
+			}
+			if parsed, err := chess.SANToMoveIn(san, notation); err != nil || parsed != move {
+				t.Errorf("Expected %s to read back, got %v", san, err)
+			}
+		}
+	}
+}
+
+func TestSloppySANToMoveIn(t *testing.T) {
+	chess := New()
+	for san, expected := range map[string]string{"Sf3": "g1f3", "sf3": "g1f3", "e4": "e2e4", "Sg1-f3": "g1f3"} {
+		if move, err := chess.SloppySANToMoveIn(san, GermanNotation); err != nil || move.UCI() != expected {
+			t.Errorf("Expected %s to be %s, got %s, %v", san, expected, move.UCI(), err)
+		}
+	}
+	chess.Load("4k3/8/8/8/8/8/8/1N2KN2 w - - 0 1")
+	if _, err := chess.SloppySANToMoveIn("Sd2", GermanNotation); err == nil || err.Error() != "Sd2 is ambiguous, it could be Sbd2 or Sfd2" {
+		t.Errorf("Expected the candidates in German, got %v", err)
+	}
+}
+
+func TestSANHistoryAndPGNInNotation(t *testing.T) {
+	chess := New()
+	for _, san := range []string{"e4", "e5", "Nf3", "Nc6", "Bb5"} {
+		chess.Move(san)
+	}
+	history := chess.SANHistory(SANOptions{Notation: SpanishNotation})
+	if strings.Join(history, " ") != "e4 e5 Cf3 Cc6 Ab5" {
+		t.Errorf("Unexpected history %v", history)
+	}
+	var written strings.Builder
+	chess.PGNGame().InNotation(DutchNotation).Write(&written)
+	if !strings.Contains(written.String(), "1. e4 e5 2. Pf3 Pc6 3. Lb5 *") {
+		t.Errorf("Unexpected PGN\n%s", written.String())
+	}
+}
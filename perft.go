@@ -0,0 +1,36 @@
+package chess
+
+// Perft counts the leaf nodes of the legal move tree depth plies deep, for
+// checking move generation against known counts. The game is left as it was.
+func Perft(chess *Chess, depth int) int64 {
+	var retVal int64
+	if depth <= 0 {
+		retVal = 1
+	} else {
+		moves := chess.Moves(true, "")
+		if depth == 1 {
+			retVal = int64(len(moves))
+		} else {
+			for _, move := range moves {
+				chess.makeMove(move)
+				retVal += Perft(chess, depth-1)
+				chess.Undo()
+			}
+		}
+	}
+	return retVal
+}
+
+// Divide is Perft split by the first move, keyed by the move in UCI, for
+// finding which move a wrong count comes from
+func Divide(chess *Chess, depth int) map[string]int64 {
+	retVal := make(map[string]int64)
+	if depth > 0 {
+		for _, move := range chess.Moves(true, "") {
+			chess.makeMove(move)
+			retVal[move.UCI()] = Perft(chess, depth-1)
+			chess.Undo()
+		}
+	}
+	return retVal
+}
@@ -0,0 +1,118 @@
+package chess
+
+import "testing"
+
+func TestPerft(t *testing.T) {
+	tests := []struct {
+		fen      string
+		depth    int
+		expected int64
+	}{
+		{defaultPosition, 3, 8902},
+		{"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1", 2, 2039},
+		{"8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1", 3, 2812},
+		{"r3k2r/Pppp1ppp/1b3nbN/nP6/BBP1P3/q4N2/Pp1P2PP/R2Q1RK1 w kq - 0 1", 2, 264},
+		{defaultPosition, 0, 1},
+		{"8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1", 4, 43238},
+		{"rnbq1k1r/pp1Pbppp/2p5/8/2B5/8/PPP1NnPP/RNBQK2R w KQ - 1 8", 3, 62379},
+		// En passant would uncover a check along the rank
+		{"8/8/8/K2pP2r/8/8/8/7k w - d6 0 1", 1, 6},
+		{"8/8/8/8/k2Pp2Q/8/8/3K4 b - d3 0 1", 1, 6},
+	}
+	for _, test := range tests {
+		chess := New()
+		chess.Load(test.fen)
+		if nodes := Perft(chess, test.depth); nodes != test.expected {
+			t.Errorf("Expected %d nodes at depth %d for %s, got %d", test.expected, test.depth, test.fen, nodes)
+		}
+		if chess.GenerateFen() != test.fen {
+			t.Errorf("Expected the game to be left alone, got %s", chess.GenerateFen())
+		}
+	}
+}
+
+// legalByMakingMoves is how Moves used to find the legal moves: by making
+// each pseudo-legal move and seeing whether it leaves the king attacked
+func legalByMakingMoves(chess *Chess) []Move {
+	var retVal []Move
+	for _, move := range chess.Moves(false, "") {
+		chess.makeMove(move)
+		if !chess.kingAttacked(move.turn) {
+			retVal = append(retVal, move)
+		}
+		chess.Undo()
+	}
+	return retVal
+}
+
+func TestLegalMovesMatchMakingMoves(t *testing.T) {
+	var walk func(chess *Chess, depth int)
+	walk = func(chess *Chess, depth int) {
+		legal := chess.Moves(true, "")
+		expected := legalByMakingMoves(chess)
+		if len(legal) != len(expected) {
+			t.Fatalf("Expected %d moves in %s, got %d", len(expected), chess.GenerateFen(), len(legal))
+		}
+		for cntr := range legal {
+			if legal[cntr] != expected[cntr] {
+				t.Fatalf("Expected %v in %s, got %v", expected[cntr], chess.GenerateFen(), legal[cntr])
+			}
+		}
+		for _, move := range legal {
+			if depth > 1 {
+				chess.makeMove(move)
+				walk(chess, depth-1)
+				chess.Undo()
+			}
+		}
+	}
+	for _, fen := range []string{
+		"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1",
+		"8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1",
+		"r3k2r/Pppp1ppp/1b3nbN/nP6/BBP1P3/q4N2/Pp1P2PP/R2Q1RK1 w kq - 0 1",
+		"8/8/8/K2pP2r/8/8/8/7k w - d6 0 1",
+		"4k3/8/8/8/1b6/8/3P4/4K2r w - - 0 1",
+	} {
+		chess := New()
+		chess.Load(fen)
+		walk(chess, 3)
+	}
+}
+
+func TestMovesLeavesStateAlone(t *testing.T) {
+	chess := New()
+	chess.Load("r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1")
+	chess.Move("Qxf6")
+	counts := make(map[uint64]int)
+	for key, value := range chess.positionToCount {
+		counts[key] = value
+	}
+	chess.Moves(true, "")
+	if chess.history.Len() != 1 || len(chess.positionToCount) != len(counts) {
+		t.Errorf("Expected the history and position counts left alone")
+	}
+	for key, value := range counts {
+		if chess.positionToCount[key] != value {
+			t.Errorf("Expected the position counts left alone")
+		}
+	}
+}
+
+func BenchmarkPerft(b *testing.B) {
+	chess := New()
+	chess.Load("r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1")
+	for cntr := 0; cntr < b.N; cntr++ {
+		Perft(chess, 2)
+	}
+}
+
+func TestDivide(t *testing.T) {
+	divide := Divide(New(), 2)
+	var total int64
+	for _, nodes := range divide {
+		total += nodes
+	}
+	if len(divide) != 20 || divide["e2e4"] != 20 || total != 400 {
+		t.Errorf("Unexpected divide %v", divide)
+	}
+}
@@ -0,0 +1,314 @@
+package chess
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var tagPairRegexp = regexp.MustCompile(`^\[\s*(\w+)\s+"((?:[^"\\]|\\.)*)"\s*\]$`)
+var moveNumberRegexp = regexp.MustCompile(`^\d+(\.+|$)`)
+
+// sevenTagRoster is the tags every exported game has, in the order they come
+var sevenTagRoster = []string{"Event", "Site", "Date", "Round", "White", "Black", "Result"}
+
+// pgnLineLength is the longest a line of exported movetext gets
+const pgnLineLength = 79
+
+// PGNGame is a game read from PGN: its tag pairs, the SAN of its main line
+// and its result. Comments, NAGs and variations are dropped.
+type PGNGame struct {
+	Tags   map[string]string
+	Moves  []string
+	Result string
+}
+
+// PGNReader reads games one at a time from a PGN file, so large collections
+// don't have to fit in memory
+type PGNReader struct {
+	scanner    *bufio.Scanner
+	pending    string
+	hasPending bool
+}
+
+// NewPGNReader creates a PGNReader reading from reader
+func NewPGNReader(reader io.Reader) *PGNReader {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	return &PGNReader{scanner: scanner}
+}
+
+// ReadPGN reads every game from reader
+func ReadPGN(reader io.Reader) ([]*PGNGame, error) {
+	var retVal []*PGNGame
+	pgnReader := NewPGNReader(reader)
+	game, err := pgnReader.Next()
+	for err == nil {
+		retVal = append(retVal, game)
+		game, err = pgnReader.Next()
+	}
+	if err == io.EOF {
+		err = nil
+	}
+	return retVal, err
+}
+
+// Next returns the next game, or io.EOF when there are no more
+func (reader *PGNReader) Next() (*PGNGame, error) {
+	game := &PGNGame{Tags: make(map[string]string), Result: "*"}
+	var movetext strings.Builder
+	inMoves := false
+	seenGame := false
+
+	for {
+		line, ok := reader.nextLine()
+		if !ok {
+			break
+		}
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(line, "%") || trimmed == "" {
+			continue
+		}
+		if strings.HasPrefix(trimmed, "[") && tagPairRegexp.MatchString(trimmed) {
+			if inMoves {
+				// The start of the next game
+				reader.pending = line
+				reader.hasPending = true
+				break
+			}
+			match := tagPairRegexp.FindStringSubmatch(trimmed)
+			game.Tags[match[1]] = unescapeTagValue(match[2])
+		} else {
+			inMoves = true
+			movetext.WriteString(line)
+			movetext.WriteString("\n")
+		}
+		seenGame = true
+	}
+
+	var err error
+	if !seenGame {
+		err = reader.scanner.Err()
+		if err == nil {
+			err = io.EOF
+		}
+		game = nil
+	} else {
+		game.Moves, game.Result = parseMovetext(movetext.String())
+		if result, ok := game.Tags["Result"]; ok && game.Result == "*" {
+			game.Result = result
+		}
+	}
+	return game, err
+}
+
+func (reader *PGNReader) nextLine() (string, bool) {
+	var retVal string
+	ok := true
+	if reader.hasPending {
+		retVal = reader.pending
+		reader.hasPending = false
+	} else if reader.scanner.Scan() {
+		retVal = reader.scanner.Text()
+	} else {
+		ok = false
+	}
+	return retVal, ok
+}
+
+// parseMovetext pulls the main line moves and the result out of PGN movetext
+func parseMovetext(movetext string) ([]string, string) {
+	var moves []string
+	result := "*"
+	variationDepth := 0
+
+	for cntr := 0; cntr < len(movetext); cntr++ {
+		switch currChar := movetext[cntr]; {
+		case currChar == '{':
+			for cntr < len(movetext) && movetext[cntr] != '}' {
+				cntr++
+			}
+		case currChar == ';':
+			for cntr < len(movetext) && movetext[cntr] != '\n' {
+				cntr++
+			}
+		case currChar == '(':
+			variationDepth++
+		case currChar == ')':
+			if variationDepth > 0 {
+				variationDepth--
+			}
+		case currChar == ' ' || currChar == '\t' || currChar == '\n' || currChar == '\r':
+		default:
+			start := cntr
+			for cntr < len(movetext) && !strings.ContainsRune(" \t\r\n{};()", rune(movetext[cntr])) {
+				cntr++
+			}
+			token := movetext[start:cntr]
+			cntr--
+			if variationDepth > 0 {
+				continue
+			}
+			if isResult(token) {
+				result = token
+				continue
+			}
+			token = moveNumberRegexp.ReplaceAllString(token, "")
+			if token != "" && token[0] != '$' {
+				moves = append(moves, token)
+			}
+		}
+	}
+	return moves, result
+}
+
+func isResult(token string) bool {
+	retVal := false
+	for _, possible := range possibleResults {
+		if token == possible {
+			retVal = true
+		}
+	}
+	return retVal
+}
+
+func unescapeTagValue(value string) string {
+	return strings.NewReplacer(`\"`, `"`, `\\`, `\`).Replace(value)
+}
+
+// start returns the position the game starts from, honoring its FEN tag
+func (game *PGNGame) start() (*Chess, error) {
+	var err error
+	retVal := New()
+	if fen, ok := game.Tags["FEN"]; ok {
+		err = retVal.Load(fen)
+	}
+	return retVal, err
+}
+
+// Replay plays the game's moves from its starting position and returns the
+// result, with the game's tags copied into its header
+func (game *PGNGame) Replay() (*Chess, error) {
+	return game.replay((*Chess).SANToMove)
+}
+
+// ReplaySloppy is Replay reading the moves with SloppySANToMove, for games
+// whose moves aren't quite standard SAN
+func (game *PGNGame) ReplaySloppy() (*Chess, error) {
+	return game.replay((*Chess).SloppySANToMove)
+}
+
+func (game *PGNGame) replay(toMove func(*Chess, string) (Move, error)) (*Chess, error) {
+	retVal, err := game.start()
+	for cntr := 0; err == nil && cntr < len(game.Moves); cntr++ {
+		var move Move
+		move, err = toMove(retVal, game.Moves[cntr])
+		if err == nil {
+			retVal.makeMove(move)
+		} else {
+			err = fmt.Errorf("ply %d: %v", cntr+1, err)
+		}
+	}
+	if err == nil {
+		for key, value := range game.Tags {
+			retVal.header[key] = value
+		}
+	}
+	return retVal, err
+}
+
+// PGNGame returns the game so far as a PGNGame, with its header as the tags.
+// The result is the Result header if there is one, or how the game has ended.
+func (chess *Chess) PGNGame() *PGNGame {
+	retVal := &PGNGame{Tags: make(map[string]string), Result: chess.Outcome().Result}
+	for key, value := range chess.header {
+		retVal.Tags[key] = value
+	}
+	if result, ok := retVal.Tags["Result"]; ok && isResult(result) {
+		retVal.Result = result
+	}
+	start := New()
+	start.Load(chess.InitialFen())
+	retVal.Moves = start.lineToSAN(chess.History())
+	return retVal
+}
+
+// Write writes the game in PGN export format: the seven tag roster first,
+// with "?" for any that are missing, then the other tags in alphabetical
+// order, then the moves with their numbers, wrapped to fit in 80 columns.
+func (game *PGNGame) Write(writer io.Writer) error {
+	var buffer strings.Builder
+	tags := make(map[string]string)
+	for key, value := range game.Tags {
+		tags[key] = value
+	}
+	tags["Result"] = game.Result
+	for _, key := range sevenTagRoster {
+		value, ok := tags[key]
+		if !ok {
+			value = "?"
+			if key == "Date" {
+				value = "????.??.??"
+			}
+		}
+		writeTagPair(&buffer, key, value)
+		delete(tags, key)
+	}
+	var others []string
+	for key := range tags {
+		others = append(others, key)
+	}
+	sort.Strings(others)
+	for _, key := range others {
+		writeTagPair(&buffer, key, tags[key])
+	}
+	buffer.WriteString("\n")
+
+	moveNumber, turn := 1, white
+	if fen, ok := game.Tags["FEN"]; ok {
+		if parsed, err := parseFEN(fen); err == nil {
+			moveNumber, turn = parsed.fullMoves, parsed.activeColor
+		}
+	}
+	var tokens []string
+	for cntr, san := range game.Moves {
+		if turn == white {
+			tokens = append(tokens, strconv.Itoa(moveNumber)+". "+san)
+		} else if cntr == 0 {
+			tokens = append(tokens, strconv.Itoa(moveNumber)+"... "+san)
+		} else {
+			tokens = append(tokens, san)
+		}
+		if turn == black {
+			moveNumber++
+		}
+		turn = swapColor(turn)
+	}
+	tokens = append(tokens, game.Result)
+
+	lineLength := 0
+	for _, token := range tokens {
+		if lineLength > 0 && lineLength+1+len(token) > pgnLineLength {
+			buffer.WriteString("\n")
+			lineLength = 0
+		} else if lineLength > 0 {
+			buffer.WriteString(" ")
+			lineLength++
+		}
+		buffer.WriteString(token)
+		lineLength += len(token)
+	}
+	buffer.WriteString("\n\n")
+
+	_, err := io.WriteString(writer, buffer.String())
+	return err
+}
+
+func writeTagPair(buffer *strings.Builder, key string, value string) {
+	value = strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value)
+	fmt.Fprintf(buffer, "[%s \"%s\"]\n", key, value)
+}
@@ -0,0 +1,138 @@
+package chess
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+const twoGamePGN = `[Event "Club \"Open\""]
+[White "Morphy, Paul"]
+[Black "Duke Karl / Count Isouard"]
+[Result "1-0"]
+
+1. e4 e5 2. Nf3 d6 3. d4 Bg4 {This is a weak move
+already.} 4. dxe5 Bxf3 5. Qxf3 dxe5 6. Bc4 Nf6 7. Qb3 Qe7 8. Nc3 c6 9. Bg5 b5
+10. Nxb5 cxb5 11. Bxb5+ Nbd7 12. O-O-O Rd8 13. Rxd7 Rxd7 14. Rd1 Qe6 15. Bxd7+
+Nxd7 16. Qb8+ Nxb8 17. Rd8# 1-0
+
+% an escaped line
+[Event "Second"]
+[SetUp "1"]
+[FEN "4k3/8/8/8/8/8/4P3/4K3 w - - 0 1"]
+
+1. e4 (1. e3 Kd7 $2) 1... Kd7 $1 ; rest of line ignored
+2. Kd2 *
+`
+
+func TestReadPGN(t *testing.T) {
+	games, err := ReadPGN(strings.NewReader(twoGamePGN))
+	if err != nil {
+		t.Fatalf("Got an error %v", err)
+	}
+	if len(games) != 2 {
+		t.Fatalf("Expected 2 games, got %d", len(games))
+	}
+
+	first := games[0]
+	if first.Tags["Event"] != `Club "Open"` || first.Result != "1-0" {
+		t.Errorf("Unexpected tags %v and result %s", first.Tags, first.Result)
+	}
+	if len(first.Moves) != 33 || first.Moves[0] != "e4" || first.Moves[32] != "Rd8#" {
+		t.Errorf("Unexpected moves %v", first.Moves)
+	}
+
+	second := games[1]
+	if strings.Join(second.Moves, " ") != "e4 Kd7 Kd2" || second.Result != "*" {
+		t.Errorf("Unexpected moves %v and result %s", second.Moves, second.Result)
+	}
+}
+
+func TestPGNReaderReturnsEOF(t *testing.T) {
+	reader := NewPGNReader(strings.NewReader("\n\n"))
+	if _, err := reader.Next(); err != io.EOF {
+		t.Errorf("Expected io.EOF, got %v", err)
+	}
+}
+
+func TestReplay(t *testing.T) {
+	games, _ := ReadPGN(strings.NewReader(twoGamePGN))
+
+	chess, err := games[0].Replay()
+	if err != nil {
+		t.Fatalf("Got an error %v", err)
+	}
+	if !chess.InCheckmate() || chess.header["White"] != "Morphy, Paul" {
+		t.Errorf("Expected checkmate and the tags, got %s", chess.GenerateFen())
+	}
+
+	chess, err = games[1].Replay()
+	if err != nil || chess.GenerateFen() != "8/3k4/8/8/4P3/8/3K4/8 b - - 2 2" {
+		t.Errorf("Got %s, %v", chess.GenerateFen(), err)
+	}
+
+	games[1].Moves = append(games[1].Moves, "Qh5")
+	if _, err = games[1].Replay(); err == nil {
+		t.Errorf("Expected an error for an illegal move")
+	}
+}
+
+func TestWritePGN(t *testing.T) {
+	games, _ := ReadPGN(strings.NewReader(twoGamePGN))
+	var written strings.Builder
+	for _, game := range games {
+		replayed, err := game.Replay()
+		if err != nil {
+			t.Fatalf("Got an error %v", err)
+		}
+		if err := replayed.PGNGame().Write(&written); err != nil {
+			t.Fatalf("Got an error %v", err)
+		}
+	}
+
+	expected := `[Event "Club \"Open\""]
+[Site "?"]
+[Date "????.??.??"]
+[Round "?"]
+[White "Morphy, Paul"]
+[Black "Duke Karl / Count Isouard"]
+[Result "1-0"]
+
+1. e4 e5 2. Nf3 d6 3. d4 Bg4 4. dxe5 Bxf3 5. Qxf3 dxe5 6. Bc4 Nf6 7. Qb3 Qe7
+8. Nc3 c6 9. Bg5 b5 10. Nxb5 cxb5 11. Bxb5+ Nbd7 12. O-O-O Rd8 13. Rxd7 Rxd7
+14. Rd1 Qe6 15. Bxd7+ Nxd7 16. Qb8+ Nxb8 17. Rd8# 1-0
+
+[Event "Second"]
+[Site "?"]
+[Date "????.??.??"]
+[Round "?"]
+[White "?"]
+[Black "?"]
+[Result "*"]
+[FEN "4k3/8/8/8/8/8/4P3/4K3 w - - 0 1"]
+[SetUp "1"]
+
+1. e4 Kd7 2. Kd2 *
+
+`
+	if written.String() != expected {
+		t.Errorf("Unexpected PGN\n%s", written.String())
+	}
+
+	reread, err := ReadPGN(strings.NewReader(written.String()))
+	if err != nil || len(reread) != 2 || len(reread[0].Moves) != 33 || reread[0].Tags["Event"] != `Club "Open"` {
+		t.Errorf("Expected the written PGN to read back, got %v", err)
+	}
+}
+
+func TestWritePGNStartingWithBlack(t *testing.T) {
+	chess := New()
+	chess.Load("4k3/8/8/8/8/8/4P3/4K3 b - - 3 12")
+	chess.Move("Kd7")
+	chess.Move("e4")
+	var written strings.Builder
+	chess.PGNGame().Write(&written)
+	if !strings.HasSuffix(written.String(), "\n\n12... Kd7 13. e4 *\n\n") {
+		t.Errorf("Unexpected movetext in\n%s", written.String())
+	}
+}
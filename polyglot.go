@@ -0,0 +1,249 @@
+package chess
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"sort"
+)
+
+const polyglotEntrySize = 16
+
+// Offsets of the non-piece keys in polyglotRandom
+const (
+	polyglotCastlingOffset  = 768
+	polyglotEnpassantOffset = 772
+	polyglotTurnOffset      = 780
+)
+
+// PolyglotEntry is a single record of a Polyglot .bin opening book
+type PolyglotEntry struct {
+	Key    uint64
+	Move   uint16
+	Weight uint16
+	Learn  uint32
+}
+
+// PolyglotBook is a Polyglot opening book held in memory, sorted by key
+type PolyglotBook struct {
+	entries []PolyglotEntry
+}
+
+// BookMove is a move found in an opening book and how often it should be played relative to the others
+type BookMove struct {
+	Move   Move
+	Weight int
+}
+
+// LoadPolyglotBook reads the Polyglot book in the named file
+func LoadPolyglotBook(path string) (*PolyglotBook, error) {
+	var retVal *PolyglotBook
+	file, err := os.Open(path)
+	if err == nil {
+		defer file.Close()
+		retVal, err = ReadPolyglotBook(file)
+	}
+	return retVal, err
+}
+
+// ReadPolyglotBook reads a Polyglot book. Books are meant to be sorted
+// already, but the entries are sorted again in case they're not.
+func ReadPolyglotBook(reader io.Reader) (*PolyglotBook, error) {
+	var retVal *PolyglotBook
+
+	data, err := io.ReadAll(reader)
+	if err == nil && len(data)%polyglotEntrySize != 0 {
+		err = fmt.Errorf("Polyglot book is %d bytes, not a multiple of %d", len(data), polyglotEntrySize)
+	}
+	if err == nil {
+		retVal = &PolyglotBook{entries: make([]PolyglotEntry, len(data)/polyglotEntrySize)}
+		for cntr := range retVal.entries {
+			record := data[cntr*polyglotEntrySize:]
+			retVal.entries[cntr] = PolyglotEntry{
+				Key:    binary.BigEndian.Uint64(record[0:8]),
+				Move:   binary.BigEndian.Uint16(record[8:10]),
+				Weight: binary.BigEndian.Uint16(record[10:12]),
+				Learn:  binary.BigEndian.Uint32(record[12:16])}
+		}
+		sort.SliceStable(retVal.entries, func(i, j int) bool {
+			return retVal.entries[i].Key < retVal.entries[j].Key
+		})
+	}
+	return retVal, err
+}
+
+// Len returns the number of entries in the book
+func (book *PolyglotBook) Len() int {
+	return len(book.entries)
+}
+
+// Entries returns the book's entries for the given Polyglot key
+func (book *PolyglotBook) Entries(key uint64) []PolyglotEntry {
+	first := sort.Search(len(book.entries), func(index int) bool {
+		return book.entries[index].Key >= key
+	})
+	last := first
+	for last < len(book.entries) && book.entries[last].Key == key {
+		last++
+	}
+	return book.entries[first:last]
+}
+
+// Moves returns the book moves for the current position. Entries whose
+// move isn't legal in the position are skipped.
+func (book *PolyglotBook) Moves(chess *Chess) []BookMove {
+	var retVal []BookMove
+	for _, entry := range book.Entries(chess.PolyglotKey()) {
+		if move, err := chess.polyglotToMove(entry.Move); err == nil {
+			retVal = append(retVal, BookMove{Move: move, Weight: int(entry.Weight)})
+		}
+	}
+	return retVal
+}
+
+// PickMove chooses one of the book moves for the current position at
+// random, in proportion to their weights. Returns false if the position
+// isn't in the book.
+func (book *PolyglotBook) PickMove(chess *Chess, random *rand.Rand) (Move, bool) {
+	var retVal Move
+	moves := book.Moves(chess)
+	found := len(moves) > 0
+
+	if found {
+		total := 0
+		for _, bookMove := range moves {
+			total += bookMove.Weight
+		}
+		if total == 0 {
+			retVal = moves[random.Intn(len(moves))].Move
+		} else {
+			pick := random.Intn(total)
+			for _, bookMove := range moves {
+				pick -= bookMove.Weight
+				if pick < 0 {
+					retVal = bookMove.Move
+					break
+				}
+			}
+		}
+	}
+	return retVal, found
+}
+
+// PolyglotKey returns the position's hash as defined by the Polyglot book
+// format. The en passant file only counts when a pawn can really capture.
+func (chess *Chess) PolyglotKey() uint64 {
+	var retVal uint64
+
+	for cntr := squareNameToID["a8"]; cntr <= squareNameToID["h1"]; cntr++ {
+		if cntr&0x88 != 0 {
+			cntr += 7
+			continue
+		}
+		piece := chess.board[cntr]
+		if !piece.IsUnspecified() {
+			kind := int(shifts[piece.ptype]) * 2
+			if piece.pcolor == white {
+				kind++
+			}
+			retVal ^= polyglotRandom[64*kind+8*(7-rank(cntr))+file(cntr)]
+		}
+	}
+
+	if chess.castling[white]&ksideCastleMove != 0 {
+		retVal ^= polyglotRandom[polyglotCastlingOffset]
+	}
+	if chess.castling[white]&qsideCastleMove != 0 {
+		retVal ^= polyglotRandom[polyglotCastlingOffset+1]
+	}
+	if chess.castling[black]&ksideCastleMove != 0 {
+		retVal ^= polyglotRandom[polyglotCastlingOffset+2]
+	}
+	if chess.castling[black]&qsideCastleMove != 0 {
+		retVal ^= polyglotRandom[polyglotCastlingOffset+3]
+	}
+
+	if chess.enpassantCapturePossible() {
+		retVal ^= polyglotRandom[polyglotEnpassantOffset+file(chess.enpassantSquare)]
+	}
+	if chess.turn == white {
+		retVal ^= polyglotRandom[polyglotTurnOffset]
+	}
+	return retVal
+}
+
+// enpassantCapturePossible returns true if a pawn of the side to move is
+// next to the pawn that just made a big pawn move
+func (chess *Chess) enpassantCapturePossible() bool {
+	retVal := false
+	if chess.enpassantSquare != emptySquare {
+		// The capturing pawns sit one rank behind the en passant square, from the mover's side
+		behind := chess.enpassantSquare + 16
+		if chess.turn == black {
+			behind = chess.enpassantSquare - 16
+		}
+		for _, square := range []int{behind - 1, behind + 1} {
+			if square&0x88 == 0 && chess.board[square] == (Piece{ptype: pawn, pcolor: chess.turn}) {
+				retVal = true
+			}
+		}
+	}
+	return retVal
+}
+
+// enpassantCaptureLegal returns true if the side to move can take en
+// passant, not just has a pawn next to the one that moved
+func (chess *Chess) enpassantCaptureLegal() bool {
+	retVal := false
+	if chess.enpassantCapturePossible() {
+		for _, move := range chess.Moves(true, "") {
+			if move.flags&enpassantMove != 0 {
+				retVal = true
+			}
+		}
+	}
+	return retVal
+}
+
+// polyglotToMove decodes a Polyglot book move into a legal move in the
+// current position. Polyglot encodes castling as the king taking its own rook.
+func (chess *Chess) polyglotToMove(encoded uint16) (Move, error) {
+	var retVal Move
+	var err error
+
+	to := polyglotSquare(int(encoded&0x7), int((encoded>>3)&0x7))
+	from := polyglotSquare(int((encoded>>6)&0x7), int((encoded>>9)&0x7))
+	var promotion PieceType
+	if code := int((encoded >> 12) & 0x7); code < len(promotionFromCode) {
+		promotion = promotionFromCode[code]
+	}
+
+	movingPiece := chess.board[from]
+	if movingPiece.ptype == king && chess.board[to] == (Piece{ptype: rook, pcolor: movingPiece.pcolor}) {
+		if to > from {
+			to = from + 2
+		} else {
+			to = from - 2
+		}
+	}
+
+	found := false
+	for _, move := range chess.Moves(true, algebraic(from)) {
+		if move.to == to && move.promotedType == promotion {
+			retVal = move
+			found = true
+			break
+		}
+	}
+	if !found {
+		err = fmt.Errorf("Book move %s%s is not legal", algebraic(from), algebraic(to))
+	}
+	return retVal, err
+}
+
+// polyglotSquare converts a Polyglot file and row, where row 0 is the first rank, into a 0x88 square
+func polyglotSquare(fileNum int, row int) int {
+	return (7-row)*16 + fileNum
+}
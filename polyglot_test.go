@@ -0,0 +1,116 @@
+package chess
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math/rand"
+	"testing"
+)
+
+// Keys published with the Polyglot book format
+func TestPolyglotKey(t *testing.T) {
+	assertPolyglotKey(t, "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", 0x463b96181691fc9c)
+	assertPolyglotKey(t, "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1", 0x823c9b50fd114196)
+	assertPolyglotKey(t, "rnbqkbnr/ppp1pppp/8/3p4/4P3/8/PPPP1PPP/RNBQKBNR w KQkq d6 0 2", 0x0756b94461c50fb0)
+	assertPolyglotKey(t, "rnbqkbnr/ppp1pppp/8/3pP3/8/8/PPPP1PPP/RNBQKBNR b KQkq - 0 2", 0x662fafb965db29d4)
+	assertPolyglotKey(t, "rnbqkbnr/ppp1p1pp/8/3pPp2/8/8/PPPP1PPP/RNBQKBNR w KQkq f6 0 3", 0x22a48b5a8e47ff78)
+	assertPolyglotKey(t, "rnbqkbnr/ppp1p1pp/8/3pPp2/8/8/PPPPKPPP/RNBQ1BNR b kq - 0 3", 0x652a607ca3f242c1)
+	assertPolyglotKey(t, "rnbq1bnr/ppp1pkpp/8/3pPp2/8/8/PPPPKPPP/RNBQ1BNR w - - 0 4", 0x00fdd303c946bdd9)
+	assertPolyglotKey(t, "rnbqkbnr/p1pppppp/8/8/PpP4P/8/1P1PPPP1/RNBQKBNR b KQkq c3 0 3", 0x3c8123ea7b067637)
+	assertPolyglotKey(t, "rnbqkbnr/p1pppppp/8/8/P6P/R1p5/1P1PPPP1/1NBQKBNR b Kkq - 0 4", 0x5c3f9b829b279560)
+}
+
+func assertPolyglotKey(t *testing.T, fen string, expected uint64) {
+	chess := New()
+	if err := chess.Load(fen); err != nil {
+		t.Fatalf("Could not load %s: %v", fen, err)
+	}
+	if chess.PolyglotKey() != expected {
+		t.Errorf("Expected %016x for %s, got %016x", expected, fen, chess.PolyglotKey())
+	}
+}
+
+func buildBook(entries ...PolyglotEntry) *bytes.Buffer {
+	var retVal bytes.Buffer
+	for _, entry := range entries {
+		binary.Write(&retVal, binary.BigEndian, entry)
+	}
+	return &retVal
+}
+
+// polyglotMove encodes a move the way a Polyglot book does, from square names
+func polyglotMove(from string, to string, promotion uint16) uint16 {
+	fromFile, fromRow := uint16(from[0]-'a'), uint16(from[1]-'1')
+	toFile, toRow := uint16(to[0]-'a'), uint16(to[1]-'1')
+	return toFile | toRow<<3 | fromFile<<6 | fromRow<<9 | promotion<<12
+}
+
+func TestPolyglotBookMoves(t *testing.T) {
+	chess := New()
+	start := chess.PolyglotKey()
+	// Out of order on purpose, plus an entry for another position
+	book, err := ReadPolyglotBook(buildBook(
+		PolyglotEntry{Key: start, Move: polyglotMove("d2", "d4", 0), Weight: 1},
+		PolyglotEntry{Key: start + 1, Move: polyglotMove("a2", "a3", 0), Weight: 9},
+		PolyglotEntry{Key: start, Move: polyglotMove("e2", "e4", 0), Weight: 3}))
+	if err != nil {
+		t.Fatalf("Got an error %v", err)
+	}
+
+	moves := book.Moves(chess)
+	if len(moves) != 2 {
+		t.Fatalf("Expected 2 book moves, got %d", len(moves))
+	}
+	total := 0
+	for _, bookMove := range moves {
+		total += bookMove.Weight
+	}
+	if total != 4 {
+		t.Errorf("Expected total weight 4, got %d", total)
+	}
+
+	counts := make(map[string]int)
+	random := rand.New(rand.NewSource(1))
+	for cntr := 0; cntr < 400; cntr++ {
+		move, ok := book.PickMove(chess, random)
+		if !ok {
+			t.Fatalf("Expected a book move")
+		}
+		counts[move.UCI()]++
+	}
+	if counts["e2e4"] < 2*counts["d2d4"] {
+		t.Errorf("Expected e4 about three times as often as d4, got %v", counts)
+	}
+
+	chess.Move("e4")
+	if _, ok := book.PickMove(chess, random); ok {
+		t.Errorf("Expected no book move after e4")
+	}
+}
+
+func TestPolyglotCastlingAndPromotion(t *testing.T) {
+	chess := New()
+	chess.Load("r3k2r/1P6/8/8/8/8/8/R3K2R w KQkq - 0 1")
+
+	move, err := chess.polyglotToMove(polyglotMove("e1", "h1", 0))
+	if err != nil || chess.SAN(move) != "O-O" {
+		t.Errorf("Expected O-O, got %v %v", move, err)
+	}
+	move, err = chess.polyglotToMove(polyglotMove("e1", "a1", 0))
+	if err != nil || chess.SAN(move) != "O-O-O" {
+		t.Errorf("Expected O-O-O, got %v %v", move, err)
+	}
+	move, err = chess.polyglotToMove(polyglotMove("b7", "a8", 4))
+	if err != nil || chess.SAN(move) != "bxa8=Q+" {
+		t.Errorf("Expected bxa8=Q+, got %v %v", move, err)
+	}
+	if _, err = chess.polyglotToMove(polyglotMove("e1", "e3", 0)); err == nil {
+		t.Errorf("Expected an error for an illegal move")
+	}
+}
+
+func TestReadPolyglotBookRejectsTruncatedFile(t *testing.T) {
+	if _, err := ReadPolyglotBook(bytes.NewReader(make([]byte, 20))); err == nil {
+		t.Errorf("Expected an error")
+	}
+}
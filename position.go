@@ -0,0 +1,133 @@
+package chess
+
+// Position is a position on its own: the board, the side to move, castling
+// rights, the en passant square and the move counters, without a game's
+// history or headers. It's a value that shares nothing with the game it came
+// from or with its copies, so it can be handed to other goroutines, and Play
+// returns a new Position instead of changing the one it's called on.
+type Position struct {
+	board           [64]byte
+	turn            PieceColor
+	castling        [2]byte
+	kings           [2]int8
+	enpassantSquare int8
+	halfMoves       int
+	moveNumber      int
+}
+
+// NewPosition returns the position of the FEN, or an error if it isn't legal
+func NewPosition(fen string) (Position, error) {
+	var retVal Position
+	chess := New()
+	err := chess.Load(fen)
+	if err == nil {
+		retVal = chess.Position()
+	}
+	return retVal, err
+}
+
+// Position returns the game's current position
+func (chess *Chess) Position() Position {
+	var retVal Position
+	for bit := range retVal.board {
+		retVal.board[bit] = packSquare(chess.board[bitToSquare(bit)])
+	}
+	retVal.turn = chess.turn
+	for _, color := range []PieceColor{white, black} {
+		retVal.castling[colorIndex(color)] = byte(chess.castling[color])
+		retVal.kings[colorIndex(color)] = int8(chess.kings[color])
+	}
+	retVal.enpassantSquare = int8(chess.enpassantSquare)
+	retVal.halfMoves = chess.halfMoves
+	retVal.moveNumber = chess.moveNumber
+	return retVal
+}
+
+// packSquare stores a piece in a byte the way PackedPosition stores it in a
+// nibble, its shift plus 8 for black, but plus 1 so that 0 is an empty square
+func packSquare(piece Piece) byte {
+	var retVal byte
+	if !piece.IsUnspecified() {
+		retVal = byte(shifts[piece.ptype]) + 1
+		if piece.pcolor == black {
+			retVal |= 8
+		}
+	}
+	return retVal
+}
+
+// unpackSquare is the reverse of packSquare
+func unpackSquare(packed byte) Piece {
+	var retVal Piece
+	if packed != 0 {
+		retVal = Piece{ptype: pieceShifts[packed&7-1], pcolor: white}
+		if packed&8 != 0 {
+			retVal.pcolor = black
+		}
+	}
+	return retVal
+}
+
+// chess returns a game without history that starts from the position, for
+// reusing the game's move generation and making moves
+func (position Position) chess() *Chess {
+	retVal := new(Chess)
+	retVal.Clear()
+	for bit, packed := range position.board {
+		retVal.board[bitToSquare(bit)] = unpackSquare(packed)
+	}
+	retVal.turn = position.turn
+	for _, color := range []PieceColor{white, black} {
+		retVal.castling[color] = int(position.castling[colorIndex(color)])
+		retVal.kings[color] = int(position.kings[colorIndex(color)])
+	}
+	retVal.enpassantSquare = int(position.enpassantSquare)
+	retVal.halfMoves = position.halfMoves
+	retVal.moveNumber = position.moveNumber
+	return retVal
+}
+
+// Game returns a new game that starts from the position
+func (position Position) Game() *Chess {
+	retVal := position.chess()
+	retVal.updateSetup(retVal.GenerateFen())
+	return retVal
+}
+
+// Play returns the position after the move, which should be one of the
+// position's legal moves. The position itself doesn't change.
+func (position Position) Play(move Move) Position {
+	chess := position.chess()
+	chess.makeMove(move)
+	return chess.Position()
+}
+
+// Moves returns the legal moves in the position
+func (position Position) Moves() []Move {
+	return position.chess().Moves(true, "")
+}
+
+// Get returns the Piece at the given square or an unspecified Piece if the
+// square is unoccupied
+func (position Position) Get(squareID string) Piece {
+	var retVal Piece
+	if squareNum, ok := squareNameToID[squareID]; ok {
+		retVal = unpackSquare(position.board[squareToBit(squareNum)])
+	}
+	return retVal
+}
+
+// Turn returns the color of the side to move
+func (position Position) Turn() PieceColor {
+	return position.turn
+}
+
+// InCheck returns true if the side to move is in check
+func (position Position) InCheck() bool {
+	return position.chess().InCheck()
+}
+
+// FEN returns the FEN encoding of the position
+func (position Position) FEN() string {
+	return position.chess().GenerateFen()
+}
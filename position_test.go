@@ -0,0 +1,109 @@
+package chess
+
+import (
+	"strings"
+	"testing"
+	"unsafe"
+)
+
+func TestPositionPlay(t *testing.T) {
+	position, err := NewPosition("r3k2r/8/8/8/4p3/8/3P4/R3K2R w KQkq - 3 20")
+	if err != nil {
+		t.Fatalf("Got an error %v", err)
+	}
+	tests := []struct {
+		uci string
+		fen string
+	}{
+		{"d2d4", "r3k2r/8/8/8/3Pp3/8/8/R3K2R b KQkq d3 0 20"},
+		{"e1g1", "r3k2r/8/8/8/4p3/8/3P4/R4RK1 b kq - 4 20"},
+		{"a1a8", "R3k2r/8/8/8/4p3/8/3P4/4K2R b Kk - 0 20"},
+	}
+	for _, test := range tests {
+		move, err := position.Game().UCIToMove(test.uci)
+		if err != nil {
+			t.Errorf("%s: got an error %v", test.uci, err)
+			continue
+		}
+		if after := position.Play(move); after.FEN() != test.fen {
+			t.Errorf("%s: expected %s, got %s", test.uci, test.fen, after.FEN())
+		}
+	}
+	if position.FEN() != "r3k2r/8/8/8/4p3/8/3P4/R3K2R w KQkq - 3 20" {
+		t.Errorf("Playing changed the position to %s", position.FEN())
+	}
+
+	move, _ := position.Game().UCIToMove("d2d4")
+	after := position.Play(move)
+	capture, err := after.Game().UCIToMove("e4d3")
+	if err != nil || capture.flags&enpassantMove == 0 {
+		t.Errorf("Expected e4d3 en passant, got %v, %v", capture, err)
+	} else if fen := after.Play(capture).FEN(); fen != "r3k2r/8/8/8/8/3p4/8/R3K2R w KQkq - 0 21" {
+		t.Errorf("Expected the pawn taken en passant, got %s", fen)
+	}
+}
+
+func TestPositionMatchesGame(t *testing.T) {
+	games, err := ReadPGN(strings.NewReader(operaGame))
+	if err != nil || len(games) != 1 {
+		t.Fatalf("Expected 1 game, got %d, %v", len(games), err)
+	}
+	chess := New()
+	position := chess.Position()
+	for _, san := range games[0].Moves {
+		move, err := chess.SANToMove(san)
+		if err != nil {
+			t.Fatalf("%s: got an error %v", san, err)
+		}
+		chess.MakeMove(move)
+		position = position.Play(move)
+		if position != chess.Position() || position.FEN() != chess.GenerateFen() {
+			t.Errorf("After %s expected %s, got %s", san, chess.GenerateFen(), position.FEN())
+		}
+		if position.InCheck() != chess.InCheck() || len(position.Moves()) != len(chess.Moves(true, "")) {
+			t.Errorf("After %s the position and game disagree about check or moves", san)
+		}
+	}
+	if position.Turn() != black || position.Get("d8") != (Piece{rook, white}) {
+		t.Errorf("Expected white rook d8 and black to move, got %v and %c", position.Get("d8"), position.Turn())
+	}
+}
+
+func TestPositionGame(t *testing.T) {
+	fen := "7k/8/8/8/8/8/8/R3K3 w Q - 0 1"
+	position, _ := NewPosition(fen)
+	game := position.Game()
+	if game.InitialFen() != fen || len(game.History()) != 0 {
+		t.Errorf("Expected a new game from %s, got %s", fen, game.InitialFen())
+	}
+	if err := game.Move("Ra2"); err != nil {
+		t.Errorf("Got an error %v", err)
+	}
+	if position.FEN() != fen {
+		t.Errorf("Moving in the game changed the position to %s", position.FEN())
+	}
+
+	if _, err := NewPosition("not a fen"); err == nil {
+		t.Errorf("Expected an error for a bad FEN")
+	}
+}
+
+func TestPositionIsSmall(t *testing.T) {
+	// Positions are passed and returned by value, so they shouldn't carry a
+	// 0x88 board around
+	if size := unsafe.Sizeof(Position{}); size > 128 {
+		t.Errorf("Expected a position to fit in 128 bytes, got %d", size)
+	}
+	fen := "r3k2r/8/8/8/3Pp3/8/8/R3K2R b KQkq d3 0 20"
+	chess := New()
+	chess.Load(fen)
+	position := chess.Position()
+	for square := range squareNameToID {
+		if position.Get(square) != chess.Get(square) {
+			t.Errorf("%s: expected %v, got %v", square, chess.Get(square), position.Get(square))
+		}
+	}
+	if position.FEN() != fen {
+		t.Errorf("Expected %s, got %s", fen, position.FEN())
+	}
+}
@@ -0,0 +1,115 @@
+package chess
+
+import (
+	"strings"
+	"sync"
+	"testing"
+)
+
+// These are for running with go test -race. Each shares a game or position
+// between goroutines the way a server analysing games would.
+
+func TestConcurrentClones(t *testing.T) {
+	chess := New()
+	chess.Load("r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1")
+	chess.Move("Qxf6")
+	var wait sync.WaitGroup
+	counts := make([]int64, 4)
+	for cntr := range counts {
+		wait.Add(1)
+		go func(cntr int) {
+			defer wait.Done()
+			copied := chess.Clone()
+			counts[cntr] = Perft(copied, 2)
+			copied.Undo()
+			copied.Move("a3")
+			copied.header["Event"] = "Copy"
+		}(cntr)
+	}
+	wait.Wait()
+	for cntr, count := range counts {
+		if count != counts[0] {
+			t.Errorf("Goroutine %d counted %d, expected %d", cntr, count, counts[0])
+		}
+	}
+	if len(chess.History()) != 1 || chess.header["Event"] == "Copy" {
+		t.Errorf("The original game changed, got %s", chess.GenerateFen())
+	}
+}
+
+func TestConcurrentReaders(t *testing.T) {
+	games, err := ReadPGN(strings.NewReader(operaGame))
+	if err != nil || len(games) != 1 {
+		t.Fatalf("Expected 1 game, got %d, %v", len(games), err)
+	}
+	chess, _ := games[0].Replay()
+	chess.Undo()
+	var wait sync.WaitGroup
+	written := make([][]string, 4)
+	for cntr := range written {
+		wait.Add(1)
+		go func(cntr int) {
+			defer wait.Done()
+			for _, move := range chess.Moves(true, "") {
+				written[cntr] = append(written[cntr], chess.SAN(move), chess.LAN(move), chess.Spoken(move))
+			}
+			chess.SANToMove("Rd8")
+			chess.GenerateFen()
+			chess.InCheckmate()
+			chess.Outcome()
+			Describe(chess)
+		}(cntr)
+	}
+	wait.Wait()
+	for cntr := range written {
+		if strings.Join(written[cntr], " ") != strings.Join(written[0], " ") {
+			t.Errorf("Goroutine %d wrote %v, expected %v", cntr, written[cntr], written[0])
+		}
+	}
+}
+
+func TestConcurrentPositions(t *testing.T) {
+	fen := "r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1"
+	position, _ := NewPosition(fen)
+	var wait sync.WaitGroup
+	fens := make([]string, 4)
+	for cntr := range fens {
+		wait.Add(1)
+		go func(cntr int) {
+			defer wait.Done()
+			for _, move := range position.Moves() {
+				after := position.Play(move)
+				fens[cntr] += after.FEN() + "\n"
+				for _, reply := range after.Moves() {
+					after.Play(reply)
+				}
+			}
+		}(cntr)
+	}
+	wait.Wait()
+	for cntr := range fens {
+		if fens[cntr] == "" || fens[cntr] != fens[0] {
+			t.Errorf("Goroutine %d got different positions", cntr)
+		}
+	}
+	if position.FEN() != fen {
+		t.Errorf("The position changed to %s", position.FEN())
+	}
+}
+
+func TestConcurrentSearchThreads(t *testing.T) {
+	chess := New()
+	chess.Load("r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1")
+	fen := chess.GenerateFen()
+	searcher := NewSearcher(1)
+	searcher.Threads = 8
+	for cntr := 0; cntr < 2; cntr++ {
+		// A small table so the threads keep overwriting each other's entries
+		if result := searcher.Search(chess, Limits{Depth: 3}); result.Depth != 3 {
+			t.Errorf("Expected depth 3, got %d", result.Depth)
+		}
+	}
+	if chess.GenerateFen() != fen {
+		t.Errorf("The search changed the game to %s", chess.GenerateFen())
+	}
+}
@@ -3,6 +3,7 @@ package chess
 import (
 	"fmt"
 	"regexp"
+	"strings"
 	"unicode"
 )
 
@@ -12,12 +13,21 @@ func (chess *Chess) SANToMove(san string) (Move, error) {
 	var retVal Move
 
 	cleanSan := cleanSAN(san)
-	moves := chess.Moves(true, "")
-	for cntr := range moves {
-		maybe := chess.moveToSAN(moves[cntr])
-		if maybe == cleanSan {
-			retVal = moves[cntr]
-			break
+	if parsed, ok := parseSAN(cleanSan); ok {
+		// Only the moves of the right piece to the right square can be
+		// written this way. All of them are needed to check the disambiguation.
+		candidates := chess.sanCandidates(parsed)
+		for _, move := range candidates {
+			from := algebraic(move.from)
+			if (parsed.fromFile != 0 && from[0] != parsed.fromFile) ||
+				(parsed.fromRank != 0 && from[1] != parsed.fromRank) ||
+				parsed.capture != (move.flags&(captureMove|enpassantMove) != 0) {
+				continue
+			}
+			if formatSAN(move, disambiguator(move, candidates)) == cleanSan {
+				retVal = move
+				break
+			}
 		}
 	}
 	// TODO Determine if a Move struct is valid
@@ -27,13 +37,108 @@ func (chess *Chess) SANToMove(san string) (Move, error) {
 	return retVal, err
 }
 
+// parsedSAN is a SAN move taken apart. Castling has the king as its piece and
+// the file the king goes to as its destination.
+type parsedSAN struct {
+	ptype     PieceType
+	fromFile  byte
+	fromRank  byte
+	capture   bool
+	to        string
+	promotion PieceType
+}
+
+// parseSAN takes a SAN move, without check marks or annotations, apart. It
+// only checks the form of the move, not whether it's legal.
+func parseSAN(san string) (parsedSAN, bool) {
+	var retVal parsedSAN
+	ok := true
+	switch san {
+	case "O-O":
+		return parsedSAN{ptype: king, to: "g"}, true
+	case "O-O-O":
+		return parsedSAN{ptype: king, to: "c"}, true
+	}
+
+	retVal.ptype = pawn
+	if len(san) > 0 && strings.IndexByte("NBRQK", san[0]) >= 0 {
+		retVal.ptype = PieceType(unicode.ToLower(rune(san[0])))
+		san = san[1:]
+	}
+	if len(san) >= 2 && san[len(san)-2] == '=' {
+		promotion := san[len(san)-1]
+		ok = retVal.ptype == pawn && strings.IndexByte("NBRQ", promotion) >= 0
+		retVal.promotion = PieceType(unicode.ToLower(rune(promotion)))
+		san = san[:len(san)-2]
+	}
+	if ok = ok && len(san) >= 2 && isFile(san[len(san)-2]) && isRank(san[len(san)-1]); ok {
+		retVal.to = san[len(san)-2:]
+		san = san[:len(san)-2]
+	}
+	if ok && len(san) > 0 && san[len(san)-1] == 'x' {
+		retVal.capture = true
+		san = san[:len(san)-1]
+	}
+	if ok && len(san) > 0 && isFile(san[0]) {
+		retVal.fromFile = san[0]
+		san = san[1:]
+	}
+	if ok && len(san) > 0 && isRank(san[0]) {
+		retVal.fromRank = san[0]
+		san = san[1:]
+	}
+	return retVal, ok && san == ""
+}
+
+// sanCandidates returns the legal moves of the parsed move's piece type to its
+// destination, whatever its disambiguation says
+func (chess *Chess) sanCandidates(parsed parsedSAN) []Move {
+	var retVal []Move
+	legality := chess.newLegality()
+	for square := squareNameToID["a8"]; square <= squareNameToID["h1"]; square++ {
+		if square&0x88 != 0 {
+			square += 7
+			continue
+		}
+		piece := chess.board[square]
+		if piece.pcolor != chess.turn || piece.ptype != parsed.ptype {
+			continue
+		}
+		for _, move := range chess.Moves(false, algebraic(square)) {
+			to := algebraic(move.to)
+			if len(parsed.to) == 1 {
+				// Castling, where only the file is known
+				to = to[0:1]
+				if move.flags&(ksideCastleMove|qsideCastleMove) == 0 {
+					continue
+				}
+			}
+			if to != parsed.to || move.promotedType != parsed.promotion {
+				continue
+			}
+			if legality.legal(chess, move) {
+				retVal = append(retVal, move)
+			}
+		}
+	}
+	return retVal
+}
+
+var sanSuffixRegexp = regexp.MustCompile("[+#]?[?!]*$")
+
 func cleanSAN(san string) string {
-	r, _ := regexp.Compile("[+#]?[?!]*$")
-	retVal := r.ReplaceAll([]byte(san), []byte(""))
+	retVal := sanSuffixRegexp.ReplaceAll([]byte(san), []byte(""))
 	return string(retVal)
 }
 
 func (chess *Chess) getDisambigutor(move Move) string {
+	return disambiguator(move, chess.sanCandidates(parsedSAN{ptype: move.ptype, to: algebraic(move.to)}))
+}
+
+// disambiguator returns what has to go after the piece letter to tell the
+// move apart from the other moves of the same piece type to the same square:
+// the file if that's enough, otherwise the rank, otherwise both
+func disambiguator(move Move, moves []Move) string {
 	retVal := ""
 
 	from := move.from
@@ -44,8 +149,6 @@ func (chess *Chess) getDisambigutor(move Move) string {
 	sameRank := 0
 	sameFile := 0
 
-	moves := chess.Moves(true, "")
-
 	for cntr := range moves {
 		ambigFrom := moves[cntr].from
 		ambigTo := moves[cntr].to
@@ -67,7 +170,7 @@ func (chess *Chess) getDisambigutor(move Move) string {
 		if sameRank > 0 && sameFile > 0 {
 			retVal = algebraic(from)
 		} else if sameFile > 0 {
-			retVal = algebraic(from)[1:1]
+			retVal = algebraic(from)[1:2]
 		} else {
 			retVal = algebraic(from)[0:1]
 		}
@@ -75,7 +178,81 @@ func (chess *Chess) getDisambigutor(move Move) string {
 	return retVal
 }
 
+// SANOptions control how moves are written in SAN
+type SANOptions struct {
+	// NoSuffix leaves out the + or # after moves that check or mate
+	NoSuffix bool
+	// Notation is how pieces are written, English if it's the zero Notation
+	Notation Notation
+}
+
 func (chess *Chess) moveToSAN(move Move) string {
+	return chess.moveToSANWithOptions(move, SANOptions{})
+}
+
+func (chess *Chess) moveToSANWithOptions(move Move, options SANOptions) string {
+	disambig := ""
+	if move.ptype != pawn && move.flags&(ksideCastleMove|qsideCastleMove) == 0 {
+		disambig = chess.getDisambigutor(move)
+	}
+	retVal := formatSAN(move, disambig)
+	if !options.NoSuffix {
+		retVal += chess.checkSuffix(move)
+	}
+	return translateSAN(retVal, EnglishNotation, options.Notation)
+}
+
+// checkSuffix works out whether the move checks or mates, returning "+", "#"
+// or "". Checks are found from the board as it is, so the game isn't touched
+// and writing moves is safe alongside other readers. Only a check needs the
+// position after the move, to see whether there's a way out of it.
+func (chess *Chess) checkSuffix(move Move) string {
+	retVal := ""
+	if chess.givesCheck(move) {
+		retVal = "+"
+		if len(chess.Position().Play(move).Moves()) == 0 {
+			retVal = "#"
+		}
+	}
+	return retVal
+}
+
+// givesCheck returns true if the legal move would attack the other king,
+// either with the piece that moves or by uncovering a piece behind it
+func (chess *Chess) givesCheck(move Move) bool {
+	theirKing := chess.kings[swapColor(move.turn)]
+	if theirKing == emptySquare {
+		return false
+	}
+	retVal := false
+	switch {
+	case move.flags&(ksideCastleMove|qsideCastleMove) != 0:
+		// Only the rook can check, from the square it lands on next to the king
+		rookFrom, rookTo := move.to+1, move.to-1
+		if move.flags&qsideCastleMove != 0 {
+			rookFrom, rookTo = move.to-2, move.to+1
+		}
+		retVal = chess.pieceAttacksAfter(Piece{rook, move.turn}, rookTo, theirKing, move.from, rookFrom, move.to)
+	default:
+		captured := emptySquare
+		if move.flags&enpassantMove != 0 {
+			captured = move.to + 16
+			if move.turn == black {
+				captured = move.to - 16
+			}
+		}
+		moved := Piece{move.ptype, move.turn}
+		if move.flags&promotionMove != 0 {
+			moved.ptype = move.promotedType
+		}
+		retVal = chess.pieceAttacksAfter(moved, move.to, theirKing, move.from, captured, emptySquare) ||
+			chess.attackedAfter(move.turn, theirKing, move.from, captured, move.to)
+	}
+	return retVal
+}
+
+// formatSAN writes the move in SAN, with the disambiguation given for pieces
+func formatSAN(move Move, disambig string) string {
 	retVal := ""
 
 	if (move.flags & ksideCastleMove) != 0 {
@@ -84,7 +261,6 @@ func (chess *Chess) moveToSAN(move Move) string {
 		retVal = "O-O-O"
 	} else {
 		if move.ptype != pawn {
-			disambig := chess.getDisambigutor(move)
 			retVal = string(unicode.ToUpper(rune(move.ptype))) + disambig
 		}
 		if (move.flags & (captureMove | enpassantMove)) != 0 {
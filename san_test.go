@@ -1,6 +1,10 @@
 package chess
 
-import "testing"
+import (
+	"os"
+	"strings"
+	"testing"
+)
 
 func TestSanToMove(t *testing.T) {
 	chess := New()
@@ -91,3 +95,220 @@ func TestIsRank(t *testing.T) {
 		t.Errorf("Expected 8 to be true")
 	}
 }
+
+// operaGame has castling, disambiguation, checks and mate
+const operaGame = `[Event "Opera Game"]
+[White "Paul Morphy"]
+[Black "Duke Karl / Count Isouard"]
+[Result "1-0"]
+
+1. e4 e5 2. Nf3 d6 3. d4 Bg4 4. dxe5 Bxf3 5. Qxf3 dxe5 6. Bc4 Nf6 7. Qb3 Qe7
+8. Nc3 c6 9. Bg5 b5 10. Nxb5 cxb5 11. Bxb5+ Nbd7 12. O-O-O Rd8 13. Rxd7 Rxd7
+14. Rd1 Qe6 15. Bxd7+ Nxd7 16. Qb8+ Nxb8 17. Rd8# 1-0
+`
+
+// immortalGame has a promotion-free sacrificial attack with many checks
+const immortalGame = `[Event "Immortal Game"]
+[White "Adolf Anderssen"]
+[Black "Lionel Kieseritzky"]
+[Result "1-0"]
+
+1. e4 e5 2. f4 exf4 3. Bc4 Qh4+ 4. Kf1 b5 5. Bxb5 Nf6 6. Nf3 Qh6 7. d3 Nh5
+8. Nh4 Qg5 9. Nf5 c6 10. g4 Nf6 11. Rg1 cxb5 12. h4 Qg6 13. h5 Qg5 14. Qf3 Ng8
+15. Bxf4 Qf6 16. Nc3 Bc5 17. Nd5 Qxb2 18. Bd6 Bxg1 19. e5 Qxa1+ 20. Ke2 Na6
+21. Nxg7+ Kd8 22. Qf6+ Nxf6 23. Be7# 1-0
+`
+
+// TestSANMatchesGames checks that the SAN written for real games is the SAN
+// they were published with, move by move. testdata/games.pgn has master games
+// with disambiguation by file and by rank, double checks, castling both ways
+// and mate by an en passant capture.
+func TestSANMatchesGames(t *testing.T) {
+	corpus, err := os.ReadFile("testdata/games.pgn")
+	if err != nil {
+		t.Fatalf("Got an error %v", err)
+	}
+	games, err := ReadPGN(strings.NewReader(operaGame + "\n" + immortalGame + "\n" + string(corpus)))
+	if err != nil || len(games) != 10 {
+		t.Fatalf("Expected 10 games, got %d, %v", len(games), err)
+	}
+	enpassant := 0
+	for _, game := range games {
+		name := game.Tags["White"] + " - " + game.Tags["Black"]
+		replayed, err := game.Replay()
+		if err != nil {
+			t.Errorf("%s: got an error %v", name, err)
+			continue
+		}
+		written := replayed.PGNGame().Moves
+		for cntr := range game.Moves {
+			if cntr >= len(written) || written[cntr] != game.Moves[cntr] {
+				t.Errorf("%s: expected %s at ply %d, got %v", name, game.Moves[cntr], cntr+1, written[min(cntr, len(written)):])
+				break
+			}
+		}
+		if mated := strings.HasSuffix(game.Moves[len(game.Moves)-1], "#"); mated != replayed.InCheckmate() {
+			t.Errorf("%s: expected the game to end in mate %v", name, mated)
+		}
+		for _, move := range replayed.History() {
+			if move.flags&enpassantMove != 0 {
+				enpassant++
+			}
+		}
+	}
+	if enpassant == 0 {
+		t.Errorf("Expected the games to have an en passant capture")
+	}
+}
+
+func TestSANDisambiguation(t *testing.T) {
+	chess := New()
+	// Load won't take three knights, so the third is put on the board
+	chess.Load("4k3/8/8/8/8/2N5/8/4K1N1 w - - 0 1")
+	chess.board[squareNameToID["c1"]] = Piece{ptype: knight, pcolor: white}
+	for from, expected := range map[string]string{"c1": "Nc1e2", "c3": "N3e2", "g1": "Nge2"} {
+		move, err := chess.UCIToMove(from + "e2")
+		if san := chess.SAN(move); err != nil || san != expected {
+			t.Errorf("Expected %s, got %s, %v", expected, san, err)
+		}
+		if parsed, err := chess.SANToMove(expected); err != nil || parsed != move {
+			t.Errorf("Expected %s to read back, got %v", expected, err)
+		}
+	}
+
+	chess.Load("4k3/8/8/R7/8/8/8/R3K3 w - - 0 1")
+	move, _ := chess.UCIToMove("a1a3")
+	if san := chess.SAN(move); san != "R1a3" {
+		t.Errorf("Expected R1a3, got %s", san)
+	}
+}
+
+func TestSANSuffixes(t *testing.T) {
+	chess := New()
+	chess.Load("6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1")
+	for uci, expected := range map[string]string{"a1a8": "Ra8#", "a1a7": "Ra7", "g1f2": "Kf2"} {
+		move, _ := chess.UCIToMove(uci)
+		if san := chess.SAN(move); san != expected {
+			t.Errorf("Expected %s, got %s", expected, san)
+		}
+	}
+	chess.Load("4k3/8/8/8/8/8/8/R3K3 w - - 0 1")
+	move, _ := chess.UCIToMove("a1a8")
+	if san := chess.SAN(move); san != "Ra8+" {
+		t.Errorf("Expected Ra8+, got %s", san)
+	}
+	if san := chess.SANWithOptions(move, SANOptions{NoSuffix: true}); san != "Ra8" {
+		t.Errorf("Expected Ra8 without the suffix, got %s", san)
+	}
+	if chess.GenerateFen() != "4k3/8/8/8/8/8/8/R3K3 w - - 0 1" {
+		t.Errorf("Expected the position unchanged, got %s", chess.GenerateFen())
+	}
+}
+
+func TestCheckSuffixMatchesMakingTheMove(t *testing.T) {
+	var walk func(chess *Chess, depth int)
+	walk = func(chess *Chess, depth int) {
+		for _, move := range chess.Moves(true, "") {
+			chess.makeMove(move)
+			expected := ""
+			if chess.InCheckmate() {
+				expected = "#"
+			} else if chess.InCheck() {
+				expected = "+"
+			}
+			if depth > 1 {
+				walk(chess, depth-1)
+			}
+			chess.Undo()
+			if suffix := chess.checkSuffix(move); suffix != expected {
+				t.Fatalf("%s in %s: expected %q, got %q", chess.moveToSANWithOptions(move, SANOptions{NoSuffix: true}), chess.GenerateFen(), expected, suffix)
+			}
+		}
+	}
+	for _, fen := range []string{
+		"r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1",
+		"8/2p5/3p4/KP5r/1R3p1k/8/4P1P1/8 w - - 0 1",
+		"r3k2r/Pppp1ppp/1b3nbN/nP6/BBP1P3/q4N2/Pp1P2PP/R2Q1RK1 w kq - 0 1",
+		"5k2/8/8/8/8/8/8/4K2R w K - 0 1",
+		"r3k3/1P6/8/8/8/8/8/4K3 w q - 0 1",
+		"8/8/8/1k6/3Pp3/8/8/4K2Q b - d3 0 1",
+	} {
+		chess := New()
+		chess.Load(fen)
+		walk(chess, 3)
+	}
+}
+
+func TestSANToMoveReplaysGame(t *testing.T) {
+	games, err := ReadPGN(strings.NewReader(operaGame))
+	var game *Chess
+	if err == nil {
+		game, err = games[0].Replay()
+	}
+	if err != nil {
+		t.Errorf("Got an error %v", err)
+	} else if !game.InCheckmate() {
+		t.Errorf("Expected checkmate, got %s", game.GenerateFen())
+	}
+}
+
+func BenchmarkSANToMove(b *testing.B) {
+	chess := New()
+	chess.Load("r1bqk2r/pppp1ppp/2n2n2/2b1p3/2B1P3/2NP1N2/PPP2PPP/R1BQK2R w KQkq - 0 1")
+	for cntr := 0; cntr < b.N; cntr++ {
+		if _, err := chess.SANToMove("Nxe5"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkReplayPGN(b *testing.B) {
+	games, err := ReadPGN(strings.NewReader(operaGame))
+	if err != nil {
+		b.Fatal(err)
+	}
+	for cntr := 0; cntr < b.N; cntr++ {
+		if _, err := games[0].Replay(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkMoveToSAN(b *testing.B) {
+	chess := New()
+	chess.Load("r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1")
+	moves := chess.Moves(true, "")
+	for cntr := 0; cntr < b.N; cntr++ {
+		for _, move := range moves {
+			chess.moveToSAN(move)
+		}
+	}
+}
+
+func TestSANToMoveIsStrict(t *testing.T) {
+	chess := New()
+	chess.Load("4k3/8/4r3/8/3N1N2/8/8/4K3 w - - 0 1")
+	for _, san := range []string{"Nxe6", "Nfe6", "Nf4xe6", "Nfxe6=Q", "fxe6", "N4xe6", "O-O", "Nfxe6x", ""} {
+		if _, err := chess.SANToMove(san); err == nil {
+			t.Errorf("Expected %s not to be a legal move", san)
+		}
+	}
+	for _, san := range []string{"Nfxe6", "Ndxe6+", "Nfxe6!?", "Kd2"} {
+		if _, err := chess.SANToMove(san); err != nil {
+			t.Errorf("Got an error %v", err)
+		}
+	}
+	chess.Load("r3k3/1P6/8/8/8/8/8/4K3 w q - 0 1")
+	for san, expected := range map[string]string{"bxa8=N": "b7a8n", "b8=Q": "b7b8q"} {
+		if move, err := chess.SANToMove(san); err != nil || move.UCI() != expected {
+			t.Errorf("Expected %s to be %s, got %s, %v", san, expected, move.UCI(), err)
+		}
+	}
+	if _, err := chess.SANToMove("b8"); err == nil {
+		t.Errorf("Expected a promotion without a piece not to be legal")
+	}
+	chess.Load("r3k3/8/8/8/8/8/8/4K3 b q - 0 1")
+	if move, err := chess.SANToMove("O-O-O"); err != nil || move.UCI() != "e8c8" {
+		t.Errorf("Expected O-O-O to be e8c8, got %s, %v", move.UCI(), err)
+	}
+}
@@ -0,0 +1,373 @@
+package chess
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const maxPly = 64
+
+// Scores above mateBound are mates; mateScore less the ply is the score for
+// being mated that many plies from the root
+const (
+	mateScore = 30000
+	mateBound = mateScore - 1000
+	infinity  = 32000
+)
+
+// DefaultHashSize is the size of the transposition table in megabytes
+const DefaultHashSize = 16
+
+// Limits bounds a search. Zero values mean no limit, though a search without
+// any limit stops at maxPly.
+type Limits struct {
+	Depth    int
+	Nodes    int64
+	MoveTime time.Duration
+}
+
+// SearchResult is the best move found by a search and its principal variation
+type SearchResult struct {
+	BestMove Move
+	Score    int
+	Depth    int
+	Nodes    int64
+	PV       []Move
+}
+
+// IsMate returns true if Score is a forced mate, for or against the side to move
+func (result SearchResult) IsMate() bool {
+	return result.Score > mateBound || result.Score < -mateBound
+}
+
+// Searcher finds the best move in a position. It keeps its transposition
+// table between searches, so use one Searcher per game. With Threads above
+// one it runs a Lazy SMP search: each thread searches its own copy of the
+// position and they share only the transposition table.
+type Searcher struct {
+	Threads int
+	tt      *transpositionTable
+}
+
+// searchShared is the state every thread of a search sees
+type searchShared struct {
+	tt       *transpositionTable
+	limits   Limits
+	start    time.Time
+	stopped  int32
+	nodes    int64
+	excluded []Move
+}
+
+// searchWorker is a single search thread with its own copy of the position
+type searchWorker struct {
+	shared   *searchShared
+	chess    *Chess
+	nodes    int64
+	pv       [maxPly + 1][maxPly + 1]Move
+	pvLength [maxPly + 1]int
+}
+
+// NewSearcher creates a single threaded Searcher with a transposition table of hashMB megabytes
+func NewSearcher(hashMB int) *Searcher {
+	return &Searcher{Threads: 1, tt: newTranspositionTable(hashMB)}
+}
+
+// Search finds the best move for the side to move. The game itself isn't changed.
+func (searcher *Searcher) Search(chess *Chess, limits Limits) SearchResult {
+	return searcher.search(chess, limits, nil)
+}
+
+// ClearHash empties the transposition table, e.g. when starting a new game
+func (searcher *Searcher) ClearHash() {
+	searcher.tt.clear()
+}
+
+// search runs the main thread and any helpers, skipping excluded moves at the root
+func (searcher *Searcher) search(chess *Chess, limits Limits, excluded []Move) SearchResult {
+	shared := &searchShared{
+		tt:       searcher.tt,
+		limits:   limits,
+		start:    time.Now(),
+		excluded: excluded}
+	maxDepth := limits.Depth
+	if maxDepth <= 0 || maxDepth > maxPly {
+		maxDepth = maxPly
+	}
+
+	var helpers sync.WaitGroup
+	for cntr := 1; cntr < searcher.Threads; cntr++ {
+		helpers.Add(1)
+		worker := &searchWorker{shared: shared, chess: chess.Clone()}
+		// Odd helpers run a ply ahead so the threads don't all search the same tree
+		go func(offset int) {
+			defer helpers.Done()
+			worker.iterate(maxDepth+offset, offset)
+		}(cntr % 2)
+	}
+
+	main := &searchWorker{shared: shared, chess: chess.Clone()}
+	retVal := main.iterate(maxDepth, 0)
+	atomic.StoreInt32(&shared.stopped, 1)
+	helpers.Wait()
+
+	retVal.Nodes = atomic.LoadInt64(&shared.nodes)
+	return retVal
+}
+
+// iterate runs iterative deepening up to maxDepth, starting offset plies deeper
+func (worker *searchWorker) iterate(maxDepth int, offset int) SearchResult {
+	var retVal SearchResult
+
+	for depth := 1 + offset; depth <= maxDepth && !worker.stopped(); depth++ {
+		score := worker.negamax(depth, 0, -infinity, infinity)
+		// A partial iteration can't be trusted unless it's the only one we have
+		if worker.stopped() && retVal.Depth > 0 {
+			break
+		}
+		if worker.pvLength[0] > 0 {
+			retVal.Score = score
+			retVal.Depth = depth
+			retVal.PV = append([]Move(nil), worker.pv[0][:worker.pvLength[0]]...)
+			retVal.BestMove = retVal.PV[0]
+		}
+		if score > mateBound || score < -mateBound {
+			// Nothing deeper will find a faster mate
+			if mateScore-abs(score) <= depth {
+				break
+			}
+		}
+	}
+	return retVal
+}
+
+func (worker *searchWorker) negamax(depth int, ply int, alpha int, beta int) int {
+	chess := worker.chess
+	worker.pvLength[ply] = 0
+
+	if worker.countNode() {
+		return 0
+	}
+	hash := chess.hash()
+	if ply > 0 && (chess.halfMoves >= 100 || chess.positionToCount[hash] > 1) {
+		return 0
+	}
+	if ply >= maxPly {
+		return chess.evaluate()
+	}
+
+	inCheck := chess.kingAttacked(chess.turn)
+	if inCheck {
+		depth++
+	}
+	if depth <= 0 {
+		return worker.quiesce(ply, alpha, beta)
+	}
+
+	entry, found := worker.shared.tt.probe(hash)
+	if found && ply > 0 && entry.depth >= depth {
+		score := scoreFromTT(entry.score, ply)
+		if entry.bound == exactBound ||
+			(entry.bound == lowerBound && score >= beta) ||
+			(entry.bound == upperBound && score <= alpha) {
+			return score
+		}
+	}
+
+	moves := chess.Moves(false, "")
+	orderMoves(moves, entry, found)
+
+	originalAlpha := alpha
+	bestScore := -infinity
+	var bestMove Move
+	legalMoves := 0
+	for _, move := range moves {
+		if ply == 0 && worker.isExcluded(move) {
+			continue
+		}
+		chess.makeMove(move)
+		if chess.kingAttacked(move.turn) {
+			chess.Undo()
+			continue
+		}
+		legalMoves++
+		score := -worker.negamax(depth-1, ply+1, -beta, -alpha)
+		chess.Undo()
+
+		if worker.stopped() {
+			return 0
+		}
+		if score > bestScore {
+			bestScore = score
+			bestMove = move
+			if score > alpha {
+				alpha = score
+				worker.updatePV(ply, move)
+				if alpha >= beta {
+					break
+				}
+			}
+		}
+	}
+
+	if legalMoves == 0 {
+		bestScore = 0
+		if inCheck {
+			bestScore = -mateScore + ply
+		}
+		return bestScore
+	}
+	// With moves left out the root score isn't the position's real score
+	if ply == 0 && len(worker.shared.excluded) > 0 {
+		return bestScore
+	}
+
+	bound := exactBound
+	if bestScore <= originalAlpha {
+		bound = upperBound
+	} else if bestScore >= beta {
+		bound = lowerBound
+	}
+	worker.shared.tt.store(hash, ttData{
+		from:      bestMove.from,
+		to:        bestMove.to,
+		promotion: bestMove.promotedType,
+		score:     scoreToTT(bestScore, ply),
+		depth:     depth,
+		bound:     bound})
+	return bestScore
+}
+
+// quiesce only searches captures and promotions so the evaluation isn't taken in the middle of an exchange
+func (worker *searchWorker) quiesce(ply int, alpha int, beta int) int {
+	chess := worker.chess
+	worker.pvLength[ply] = 0
+
+	if worker.countNode() {
+		return 0
+	}
+	standPat := chess.evaluate()
+	if standPat >= beta || ply >= maxPly {
+		return standPat
+	}
+	if standPat > alpha {
+		alpha = standPat
+	}
+
+	var captures []Move
+	for _, move := range chess.Moves(false, "") {
+		if move.flags&(captureMove|enpassantMove|promotionMove) != 0 {
+			captures = append(captures, move)
+		}
+	}
+	orderMoves(captures, ttData{}, false)
+
+	for _, move := range captures {
+		chess.makeMove(move)
+		if chess.kingAttacked(move.turn) {
+			chess.Undo()
+			continue
+		}
+		score := -worker.quiesce(ply+1, -beta, -alpha)
+		chess.Undo()
+
+		if worker.stopped() {
+			return 0
+		}
+		if score > alpha {
+			alpha = score
+			if alpha >= beta {
+				break
+			}
+		}
+	}
+	return alpha
+}
+
+func (worker *searchWorker) updatePV(ply int, move Move) {
+	worker.pv[ply][0] = move
+	childLength := worker.pvLength[ply+1]
+	copy(worker.pv[ply][1:], worker.pv[ply+1][:childLength])
+	worker.pvLength[ply] = childLength + 1
+}
+
+// countNode counts a node against the limits and returns true if the search should stop
+func (worker *searchWorker) countNode() bool {
+	shared := worker.shared
+	worker.nodes++
+	nodes := atomic.AddInt64(&shared.nodes, 1)
+
+	if shared.limits.Nodes > 0 && nodes >= shared.limits.Nodes {
+		atomic.StoreInt32(&shared.stopped, 1)
+	} else if shared.limits.MoveTime > 0 && worker.nodes&1023 == 0 &&
+		time.Since(shared.start) >= shared.limits.MoveTime {
+		atomic.StoreInt32(&shared.stopped, 1)
+	}
+	return worker.stopped()
+}
+
+func (worker *searchWorker) stopped() bool {
+	return atomic.LoadInt32(&worker.shared.stopped) != 0
+}
+
+func (worker *searchWorker) isExcluded(move Move) bool {
+	retVal := false
+	for _, excluded := range worker.shared.excluded {
+		if excluded == move {
+			retVal = true
+			break
+		}
+	}
+	return retVal
+}
+
+// orderMoves puts the transposition table's move first, then captures of
+// the most valuable pieces by the least valuable attackers, then promotions
+func orderMoves(moves []Move, entry ttData, found bool) {
+	scores := make(map[Move]int, len(moves))
+	for _, move := range moves {
+		score := 0
+		if found && entry.matches(move) {
+			score = 1000000
+		} else if move.flags&(captureMove|enpassantMove) != 0 {
+			score = 10000 + 10*pieceValues[move.capturedType] - pieceValues[move.ptype]/10
+		}
+		if move.flags&promotionMove != 0 {
+			score += 9000 + pieceValues[move.promotedType]
+		}
+		scores[move] = score
+	}
+	sort.SliceStable(moves, func(i, j int) bool {
+		return scores[moves[i]] > scores[moves[j]]
+	})
+}
+
+// Mate scores are stored relative to the node so they stay right when found through a transposition
+func scoreToTT(score int, ply int) int {
+	retVal := score
+	if score > mateBound {
+		retVal += ply
+	} else if score < -mateBound {
+		retVal -= ply
+	}
+	return retVal
+}
+
+func scoreFromTT(score int, ply int) int {
+	retVal := score
+	if score > mateBound {
+		retVal -= ply
+	} else if score < -mateBound {
+		retVal += ply
+	}
+	return retVal
+}
+
+func abs(value int) int {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
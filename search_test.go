@@ -0,0 +1,95 @@
+package chess
+
+import (
+	"testing"
+)
+
+func TestSearchFindsMateInOne(t *testing.T) {
+	chess := New()
+	chess.Load("6k1/5ppp/8/8/8/8/5PPP/R5K1 w - - 0 1")
+
+	result := NewSearcher(DefaultHashSize).Search(chess, Limits{Depth: 3})
+	if chess.SAN(result.BestMove) != "Ra8#" {
+		t.Errorf("Expected Ra8#, got %s", chess.SAN(result.BestMove))
+	}
+	if !result.IsMate() || result.Score != mateScore-1 {
+		t.Errorf("Expected mate in one, got score %d", result.Score)
+	}
+}
+
+func TestSearchWinsHangingQueen(t *testing.T) {
+	chess := New()
+	chess.Load("4k3/8/8/3q4/8/8/3R4/4K3 w - - 0 1")
+
+	result := NewSearcher(DefaultHashSize).Search(chess, Limits{Depth: 2})
+	if chess.SAN(result.BestMove) != "Rxd5" {
+		t.Errorf("Expected Rxd5, got %s", chess.SAN(result.BestMove))
+	}
+}
+
+func TestSearchLeavesGameUntouched(t *testing.T) {
+	chess := New()
+	chess.Move("e4")
+	fen := chess.GenerateFen()
+
+	NewSearcher(DefaultHashSize).Search(chess, Limits{Depth: 3})
+	if chess.GenerateFen() != fen || len(chess.History()) != 1 {
+		t.Errorf("Search changed the game to %s", chess.GenerateFen())
+	}
+}
+
+func TestSearchIsDeterministicWithOneThread(t *testing.T) {
+	chess := New()
+	chess.Load("r1bqkbnr/pppp1ppp/2n5/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R w KQkq - 2 3")
+
+	first := NewSearcher(DefaultHashSize).Search(chess, Limits{Depth: 4})
+	second := NewSearcher(DefaultHashSize).Search(chess, Limits{Depth: 4})
+	if first.BestMove != second.BestMove || first.Score != second.Score || first.Nodes != second.Nodes {
+		t.Errorf("Expected identical searches, got %v and %v", first, second)
+	}
+}
+
+// TestLazySMPAgreesWithSingleThread searches a middlegame to the same depth
+// with one thread and with four. Helper threads share the hash table, so the
+// main thread can pick a different move of the same value, and cutoffs from
+// their entries can move the score a little, but it shouldn't move far.
+func TestLazySMPAgreesWithSingleThread(t *testing.T) {
+	chess := New()
+	chess.Load("r2q1rk1/pp1nbppp/2p1pn2/3p1b2/2PP4/1QN1PN2/PP1BBPPP/R3K2R w KQ - 4 9")
+
+	single := NewSearcher(DefaultHashSize).Search(chess, Limits{Depth: 4})
+	searcher := NewSearcher(DefaultHashSize)
+	searcher.Threads = 4
+	parallel := searcher.Search(chess, Limits{Depth: 4})
+
+	if parallel.Depth != single.Depth || parallel.Depth != 4 {
+		t.Errorf("Expected both to finish depth 4, got %d and %d", single.Depth, parallel.Depth)
+	}
+	if diff := parallel.Score - single.Score; diff > 30 || diff < -30 {
+		t.Errorf("Expected scores within 30, got %d and %d", single.Score, parallel.Score)
+	}
+	if len(parallel.PV) == 0 || parallel.PV[0] != parallel.BestMove {
+		t.Errorf("Expected the principal variation to start with the best move, got %v", parallel.PV)
+	}
+}
+
+func TestSearchHonorsNodeLimit(t *testing.T) {
+	chess := New()
+	result := NewSearcher(DefaultHashSize).Search(chess, Limits{Nodes: 500})
+	if result.Nodes > 500 {
+		t.Errorf("Expected at most 500 nodes, got %d", result.Nodes)
+	}
+	if result.BestMove.ptype == 0 {
+		t.Errorf("Expected a move from a partial search")
+	}
+}
+
+func TestSearchScoresStalemateAsDraw(t *testing.T) {
+	chess := New()
+	chess.Load("7k/5K2/6Q1/8/8/8/8/8 b - - 0 1")
+
+	result := NewSearcher(DefaultHashSize).Search(chess, Limits{Depth: 2})
+	if result.Score != 0 || len(result.PV) != 0 {
+		t.Errorf("Expected a drawn score with no moves, got %v", result)
+	}
+}
@@ -0,0 +1,626 @@
+package server
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rkitts/chess"
+)
+
+//go:embed live.html
+var livePage []byte
+
+// The colours as chess.Chess.Turn returns them
+const (
+	white = chess.PieceColor('w')
+	black = chess.PieceColor('b')
+)
+
+// How often an idle event stream gets a comment, so proxies don't close it
+const keepAliveInterval = 30 * time.Second
+
+// How long games are kept once nobody needs them
+const (
+	// abandonedTimeout is how long a game that's still going is kept with
+	// neither player following it or moving, so they can reconnect
+	abandonedTimeout = 10 * time.Minute
+	// finishedTimeout is how long a game is kept after it ends, so everyone
+	// can see how
+	finishedTimeout = time.Minute
+)
+
+// Multiplayer pairs people up and serves their games live, to both players
+// and to anyone watching.
+//
+//	GET  /                         the live board page
+//	POST /api/match                wait for an opponent, {"name", "timeControl"}; returns a Seat
+//	GET  /api/games/{id}           the game's state, also for reconnecting
+//	GET  /api/games/{id}/events    Server-Sent Events, a "state" event on every change;
+//	                               ?token= says a player is following
+//	POST /api/games/{id}/move      {"token", "san"} or {"token", "from", "to", "promotion"}
+//	POST /api/games/{id}/resign    {"token"}
+//
+// Games are forgotten a while after they end, or after neither player has
+// followed the events or moved for a while.
+type Multiplayer struct {
+	mutex            sync.Mutex
+	games            map[string]*liveGame
+	waiting          []*waiter
+	random           *rand.Rand
+	abandonedTimeout time.Duration
+	finishedTimeout  time.Duration
+}
+
+// Seat is a player's place in a game. The token has to be sent with every
+// move, and is all a player needs to carry on after reconnecting.
+type Seat struct {
+	GameID string `json:"gameId"`
+	Color  string `json:"color"`
+	Token  string `json:"token"`
+}
+
+// OutcomeJSON is a chess.Outcome as the API sends it
+type OutcomeJSON struct {
+	Result string `json:"result"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// LiveState is a live game as the API sends it
+type LiveState struct {
+	ID       string      `json:"id"`
+	White    string      `json:"white"`
+	Black    string      `json:"black"`
+	FEN      string      `json:"fen"`
+	Turn     string      `json:"turn"`
+	History  []string    `json:"history"`
+	InCheck  bool        `json:"inCheck"`
+	LastMove *MoveJSON   `json:"lastMove,omitempty"`
+	Outcome  OutcomeJSON `json:"outcome"`
+	Clock    *ClockJSON  `json:"clock,omitempty"`
+}
+
+// ClockJSON is a timed game's clock: the PGN TimeControl and each player's
+// remaining time in milliseconds. Only the side to move's time is running.
+type ClockJSON struct {
+	TimeControl string `json:"timeControl"`
+	White       int64  `json:"white"`
+	Black       int64  `json:"black"`
+}
+
+// MatchRequest is the body when asking for an opponent. TimeControl is in
+// the form of the PGN tag, for example "300+3"; players are only paired with
+// someone asking for the same one. Leave it out for an untimed game.
+type MatchRequest struct {
+	Name        string `json:"name"`
+	TimeControl string `json:"timeControl,omitempty"`
+}
+
+// LiveMoveRequest is the body of a move in a live game
+type LiveMoveRequest struct {
+	Token string `json:"token"`
+	MoveRequest
+}
+
+// TokenRequest is the body of requests that only need the player's token
+type TokenRequest struct {
+	Token string `json:"token"`
+}
+
+// waiter is someone waiting for an opponent. gone is closed when they stop
+// waiting.
+type waiter struct {
+	name        string
+	timeControl string
+	seats       chan Seat
+	gone        <-chan struct{}
+}
+
+type livePlayer struct {
+	name  string
+	token string
+}
+
+// liveGame is a game between two people and everyone following it.
+// connected counts each player's event streams, and done is closed once the
+// game is forgotten.
+type liveGame struct {
+	mutex       sync.Mutex
+	owner       *Multiplayer
+	id          string
+	chess       *chess.Chess
+	players     map[chess.PieceColor]livePlayer
+	san         []string
+	lastMove    *MoveJSON
+	outcome     chess.Outcome
+	timeControl string
+	clock       *chess.Clock
+	flagTimer   *time.Timer
+	subscribers map[chan []byte]bool
+	connected   map[chess.PieceColor]int
+	forgetTimer *time.Timer
+	done        chan struct{}
+}
+
+// NewMultiplayer creates a Multiplayer with nobody waiting and no games
+func NewMultiplayer() *Multiplayer {
+	return &Multiplayer{
+		games:            make(map[string]*liveGame),
+		random:           rand.New(rand.NewSource(time.Now().UnixNano())),
+		abandonedTimeout: abandonedTimeout,
+		finishedTimeout:  finishedTimeout}
+}
+
+// ServeHTTP implements http.Handler
+func (multiplayer *Multiplayer) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	request.Body = http.MaxBytesReader(writer, request.Body, maxBodyBytes)
+	parts := strings.Split(strings.Trim(request.URL.Path, "/"), "/")
+	route := request.Method + " " + request.URL.Path
+	if len(parts) >= 3 && parts[0] == "api" && parts[1] == "games" {
+		route = request.Method + " /api/games/{id}"
+		if len(parts) > 3 {
+			route += "/" + strings.Join(parts[3:], "/")
+		}
+	}
+
+	var handler func(http.ResponseWriter, *http.Request, *liveGame)
+	switch route {
+	case "GET /":
+		writer.Header().Set("Content-Type", "text/html; charset=utf-8")
+		writer.Write(livePage)
+	case "POST /api/match":
+		multiplayer.handleMatch(writer, request)
+	case "GET /api/games/{id}":
+		handler = multiplayer.handleState
+	case "GET /api/games/{id}/events":
+		handler = multiplayer.handleEvents
+	case "POST /api/games/{id}/move":
+		handler = multiplayer.handleMove
+	case "POST /api/games/{id}/resign":
+		handler = multiplayer.handleResign
+	default:
+		writeError(writer, http.StatusNotFound, fmt.Errorf("No such endpoint %s", route))
+	}
+	if handler != nil {
+		multiplayer.mutex.Lock()
+		found, ok := multiplayer.games[parts[2]]
+		multiplayer.mutex.Unlock()
+		if ok {
+			handler(writer, request, found)
+		} else {
+			writeError(writer, http.StatusNotFound, fmt.Errorf("No game '%s'", parts[2]))
+		}
+	}
+}
+
+// handleMatch pairs the caller with whoever has been waiting longest, or
+// waits for the next person to ask. Colours are picked at random.
+func (multiplayer *Multiplayer) handleMatch(writer http.ResponseWriter, request *http.Request) {
+	var body MatchRequest
+	if err := decodeOptional(request, &body); err != nil {
+		writeError(writer, http.StatusBadRequest, err)
+		return
+	}
+	if body.Name == "" {
+		body.Name = "Anonymous"
+	}
+	var control chess.TimeControl
+	if body.TimeControl != "" {
+		var err error
+		if control, err = chess.ParseTimeControl(body.TimeControl); err != nil {
+			writeError(writer, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	multiplayer.mutex.Lock()
+	for cntr := 0; cntr < len(multiplayer.waiting); cntr++ {
+		opponent := multiplayer.waiting[cntr]
+		if opponent.timeControl != body.TimeControl {
+			continue
+		}
+		multiplayer.waiting = append(multiplayer.waiting[:cntr], multiplayer.waiting[cntr+1:]...)
+		select {
+		case <-opponent.gone:
+			// They've left and not yet taken themselves off the list
+			close(opponent.seats)
+			cntr--
+			continue
+		default:
+		}
+		opponentColor, myColor := white, black
+		if multiplayer.random.Intn(2) == 1 {
+			opponentColor, myColor = black, white
+		}
+		game := multiplayer.newGame(map[chess.PieceColor]string{opponentColor: opponent.name, myColor: body.Name},
+			body.TimeControl, control)
+		multiplayer.mutex.Unlock()
+
+		opponent.seats <- game.seat(opponentColor)
+		writeJSON(writer, http.StatusOK, game.seat(myColor))
+		return
+	}
+	me := &waiter{name: body.Name, timeControl: body.TimeControl, seats: make(chan Seat, 1),
+		gone: request.Context().Done()}
+	multiplayer.waiting = append(multiplayer.waiting, me)
+	multiplayer.mutex.Unlock()
+
+	var seat Seat
+	paired := false
+	select {
+	case seat, paired = <-me.seats:
+	case <-me.gone:
+		listed := false
+		multiplayer.mutex.Lock()
+		for cntr, waiting := range multiplayer.waiting {
+			if waiting == me {
+				multiplayer.waiting = append(multiplayer.waiting[:cntr], multiplayer.waiting[cntr+1:]...)
+				listed = true
+				break
+			}
+		}
+		multiplayer.mutex.Unlock()
+		if !listed {
+			// Someone took us off the list, either pairing with us or, having
+			// seen we'd gone, closing seats
+			seat, paired = <-me.seats
+		}
+	}
+	switch {
+	case !paired:
+	case request.Context().Err() != nil:
+		// Paired just as the request went away, so nobody will ever sit in
+		// this seat. The opponent hears the game is off.
+		multiplayer.mutex.Lock()
+		game := multiplayer.games[seat.GameID]
+		multiplayer.mutex.Unlock()
+		if game != nil {
+			game.abort()
+		}
+	default:
+		writeJSON(writer, http.StatusOK, seat)
+	}
+}
+
+// newGame starts a game between the named players, with the clock running
+// if it has a time control. The lock must be held.
+func (multiplayer *Multiplayer) newGame(names map[chess.PieceColor]string, tag string, control chess.TimeControl) *liveGame {
+	retVal := &liveGame{
+		owner: multiplayer,
+		id:    newID(),
+		chess: chess.New(),
+		players: map[chess.PieceColor]livePlayer{
+			white: {name: names[white], token: newID()},
+			black: {name: names[black], token: newID()}},
+		outcome:     chess.Outcome{Result: "*"},
+		timeControl: tag,
+		subscribers: make(map[chan []byte]bool),
+		connected:   make(map[chess.PieceColor]int),
+		done:        make(chan struct{})}
+	if len(control) > 0 {
+		retVal.clock, _ = chess.NewClock(retVal.chess, control, nil)
+		retVal.clock.Start()
+		retVal.watchFlag()
+	}
+	retVal.keepOrForget()
+	multiplayer.games[retVal.id] = retVal
+	return retVal
+}
+
+// remove takes the game off the list, if it's still there
+func (multiplayer *Multiplayer) remove(game *liveGame) {
+	multiplayer.mutex.Lock()
+	if multiplayer.games[game.id] == game {
+		delete(multiplayer.games, game.id)
+	}
+	multiplayer.mutex.Unlock()
+}
+
+func (multiplayer *Multiplayer) handleState(writer http.ResponseWriter, request *http.Request, game *liveGame) {
+	game.mutex.Lock()
+	state := game.state()
+	game.mutex.Unlock()
+	writeJSON(writer, http.StatusOK, state)
+}
+
+// handleEvents streams the game's state, starting with where it is now, so
+// reconnecting is the same as connecting. The stream ends when the game is
+// forgotten.
+func (multiplayer *Multiplayer) handleEvents(writer http.ResponseWriter, request *http.Request, game *liveGame) {
+	flusher, ok := writer.(http.Flusher)
+	if !ok {
+		writeError(writer, http.StatusInternalServerError, fmt.Errorf("Streaming isn't supported"))
+		return
+	}
+	writer.Header().Set("Content-Type", "text/event-stream")
+	writer.Header().Set("Cache-Control", "no-cache")
+	writer.WriteHeader(http.StatusOK)
+
+	events := make(chan []byte, 1)
+	game.mutex.Lock()
+	color, _, notPlayer := game.player(request.URL.Query().Get("token"))
+	events <- stateEvent(game.state())
+	game.subscribers[events] = true
+	if notPlayer == nil {
+		game.connected[color]++
+		game.keepOrForget()
+	}
+	game.mutex.Unlock()
+	defer func() {
+		game.mutex.Lock()
+		delete(game.subscribers, events)
+		if notPlayer == nil {
+			game.connected[color]--
+			game.keepOrForget()
+		}
+		game.mutex.Unlock()
+	}()
+
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case event := <-events:
+			writer.Write(event)
+		case <-keepAlive.C:
+			writer.Write([]byte(": keep-alive\n\n"))
+		case <-game.done:
+			select {
+			case event := <-events:
+				writer.Write(event)
+			default:
+			}
+			return
+		case <-request.Context().Done():
+			return
+		}
+		flusher.Flush()
+	}
+}
+
+func (multiplayer *Multiplayer) handleMove(writer http.ResponseWriter, request *http.Request, game *liveGame) {
+	var body LiveMoveRequest
+	err := json.NewDecoder(request.Body).Decode(&body)
+
+	game.mutex.Lock()
+	defer game.mutex.Unlock()
+	status := http.StatusBadRequest
+	if err == nil {
+		if turnStatus, turnErr := game.checkTurn(body.Token); turnErr != nil {
+			status, err = turnStatus, turnErr
+		}
+	}
+	var move chess.Move
+	if err == nil {
+		move, err = findMove(game.chess, body.MoveRequest)
+	}
+	if err == nil {
+		played := moveJSON(game.chess, move)
+		if game.clock != nil {
+			err = game.clock.MakeMove(move)
+		} else {
+			game.chess.MakeMove(move)
+		}
+		if err == nil {
+			game.san = append(game.san, played.SAN)
+			game.lastMove = &played
+			game.outcome = game.chess.Outcome()
+			game.watchFlag()
+			game.keepOrForget()
+		}
+	}
+	if err != nil {
+		writeError(writer, status, err)
+		return
+	}
+	state := game.state()
+	game.broadcast(state)
+	writeJSON(writer, http.StatusOK, state)
+}
+
+func (multiplayer *Multiplayer) handleResign(writer http.ResponseWriter, request *http.Request, game *liveGame) {
+	var body TokenRequest
+	err := json.NewDecoder(request.Body).Decode(&body)
+
+	game.mutex.Lock()
+	defer game.mutex.Unlock()
+	status := http.StatusBadRequest
+	var color chess.PieceColor
+	if err == nil {
+		var playerStatus int
+		color, playerStatus, err = game.player(body.Token)
+		if err != nil {
+			status = playerStatus
+		}
+	}
+	if err == nil && game.outcome.Over() {
+		status, err = http.StatusConflict, fmt.Errorf("The game is over")
+	}
+	if err != nil {
+		writeError(writer, status, err)
+		return
+	}
+	if game.clock != nil {
+		game.stopFlagWatch()
+		game.clock.Stop()
+	}
+	game.outcome = chess.Outcome{Result: "1-0", Reason: "resignation"}
+	if color == white {
+		game.outcome.Result = "0-1"
+	}
+	game.keepOrForget()
+	state := game.state()
+	game.broadcast(state)
+	writeJSON(writer, http.StatusOK, state)
+}
+
+// player returns the colour the token plays, or the status to fail the request with
+func (game *liveGame) player(token string) (chess.PieceColor, int, error) {
+	var retVal chess.PieceColor
+	var err error
+	var status int
+	for color, player := range game.players {
+		if token != "" && player.token == token {
+			retVal = color
+		}
+	}
+	if retVal == 0 {
+		status, err = http.StatusForbidden, fmt.Errorf("Not a player in this game")
+	}
+	return retVal, status, err
+}
+
+// checkTurn makes sure the token's player is the one to move in a game
+// that's still going, returning the status to fail the request with if not
+func (game *liveGame) checkTurn(token string) (int, error) {
+	color, status, err := game.player(token)
+	if err == nil && game.outcome.Over() {
+		status, err = http.StatusConflict, fmt.Errorf("The game is over")
+	}
+	if err == nil && color != game.chess.Turn() {
+		status, err = http.StatusConflict, fmt.Errorf("It's not your turn")
+	}
+	return status, err
+}
+
+// watchFlag arranges for the game to end when the side to move runs out of
+// time, and for everyone to hear about it. The lock must be held.
+func (game *liveGame) watchFlag() {
+	if game.clock == nil {
+		return
+	}
+	game.stopFlagWatch()
+	if game.outcome.Over() {
+		return
+	}
+	game.flagTimer = time.AfterFunc(game.clock.Remaining(game.chess.Turn()), func() {
+		game.mutex.Lock()
+		defer game.mutex.Unlock()
+		if !game.outcome.Over() && game.clock.Flagged() {
+			game.outcome = game.clock.Outcome()
+			game.keepOrForget()
+			game.broadcast(game.state())
+		}
+	})
+}
+
+// stopFlagWatch stops waiting for a flag to fall. The lock must be held.
+func (game *liveGame) stopFlagWatch() {
+	if game.flagTimer != nil {
+		game.flagTimer.Stop()
+		game.flagTimer = nil
+	}
+}
+
+// keepOrForget arranges for the game to be forgotten a while after it ends,
+// or after neither player has followed it or moved for a while. The lock
+// must be held.
+func (game *liveGame) keepOrForget() {
+	if game.forgetTimer != nil {
+		game.forgetTimer.Stop()
+		game.forgetTimer = nil
+	}
+	delay := game.owner.finishedTimeout
+	if !game.outcome.Over() {
+		if game.connected[white]+game.connected[black] > 0 {
+			return
+		}
+		delay = game.owner.abandonedTimeout
+	}
+	var timer *time.Timer
+	timer = time.AfterFunc(delay, func() {
+		game.mutex.Lock()
+		// A timer that was stopped too late finds it's been replaced
+		current := game.forgetTimer == timer
+		if current {
+			game.close()
+		}
+		game.mutex.Unlock()
+		if current {
+			game.owner.remove(game)
+		}
+	})
+	game.forgetTimer = timer
+}
+
+// abort calls the game off before it started, tells everyone following it
+// and forgets it
+func (game *liveGame) abort() {
+	game.mutex.Lock()
+	game.outcome = chess.Outcome{Result: "*", Reason: "aborted"}
+	game.broadcast(game.state())
+	game.close()
+	game.mutex.Unlock()
+	game.owner.remove(game)
+}
+
+// close stops the game's timers and ends its event streams. The lock must be
+// held.
+func (game *liveGame) close() {
+	game.stopFlagWatch()
+	if game.forgetTimer != nil {
+		game.forgetTimer.Stop()
+		game.forgetTimer = nil
+	}
+	if game.clock != nil {
+		game.clock.Stop()
+	}
+	select {
+	case <-game.done:
+	default:
+		close(game.done)
+	}
+}
+
+func (game *liveGame) seat(color chess.PieceColor) Seat {
+	return Seat{GameID: game.id, Color: string(rune(color)), Token: game.players[color].token}
+}
+
+// state is the game as it is now. The lock must be held.
+func (game *liveGame) state() LiveState {
+	retVal := LiveState{
+		ID:       game.id,
+		White:    game.players[white].name,
+		Black:    game.players[black].name,
+		FEN:      game.chess.GenerateFen(),
+		Turn:     string(rune(game.chess.Turn())),
+		History:  append([]string{}, game.san...),
+		InCheck:  game.chess.InCheck(),
+		LastMove: game.lastMove,
+		Outcome:  OutcomeJSON{Result: game.outcome.Result, Reason: game.outcome.Reason}}
+	if game.clock != nil {
+		retVal.Clock = &ClockJSON{
+			TimeControl: game.timeControl,
+			White:       max(game.clock.Remaining(white), 0).Milliseconds(),
+			Black:       max(game.clock.Remaining(black), 0).Milliseconds()}
+	}
+	return retVal
+}
+
+// broadcast sends the state to everyone following the game. A subscriber
+// that hasn't read the last state yet only gets this newer one. The lock
+// must be held.
+func (game *liveGame) broadcast(state LiveState) {
+	event := stateEvent(state)
+	for subscriber := range game.subscribers {
+		select {
+		case subscriber <- event:
+		default:
+			select {
+			case <-subscriber:
+			default:
+			}
+			subscriber <- event
+		}
+	}
+}
+
+func stateEvent(state LiveState) []byte {
+	data, _ := json.Marshal(state)
+	return []byte("event: state\ndata: " + string(data) + "\n\n")
+}
@@ -0,0 +1,348 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// matchPair has two players ask for a game with the same time control at once
+func matchPair(t *testing.T, server *httptest.Server, timeControl string) (Seat, Seat) {
+	seats := make(chan Seat, 2)
+	for _, name := range []string{"Ann", "Bob"} {
+		go func(name string) {
+			var seat Seat
+			call(t, "POST", server.URL+"/api/match", MatchRequest{Name: name, TimeControl: timeControl}, &seat)
+			seats <- seat
+		}(name)
+	}
+	first, second := <-seats, <-seats
+	if first.Color == "b" {
+		first, second = second, first
+	}
+	return first, second
+}
+
+// nextState reads events until the next state event
+func nextState(t *testing.T, reader *bufio.Reader) LiveState {
+	var retVal LiveState
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("Got an error %v", err)
+		}
+		if strings.HasPrefix(line, "data: ") {
+			json.Unmarshal([]byte(line[len("data: "):]), &retVal)
+			return retVal
+		}
+	}
+}
+
+func TestMatchPairsPlayers(t *testing.T) {
+	server := httptest.NewServer(NewMultiplayer())
+	defer server.Close()
+
+	white, black := matchPair(t, server, "")
+	if white.GameID == "" || white.GameID != black.GameID || white.Color != "w" || black.Color != "b" ||
+		white.Token == black.Token {
+		t.Errorf("Unexpected seats %v and %v", white, black)
+	}
+
+	var state LiveState
+	call(t, "GET", server.URL+"/api/games/"+white.GameID, nil, &state)
+	names := state.White + " " + state.Black
+	if (names != "Ann Bob" && names != "Bob Ann") || state.Outcome.Result != "*" {
+		t.Errorf("Unexpected state %v", state)
+	}
+}
+
+func TestMatchGivesUpWhenTheRequestIsCancelled(t *testing.T) {
+	multiplayer := NewMultiplayer()
+	server := httptest.NewServer(multiplayer)
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	request, _ := http.NewRequestWithContext(ctx, "POST", server.URL+"/api/match", strings.NewReader(`{"name": "Ann"}`))
+	if _, err := http.DefaultClient.Do(request); err == nil {
+		t.Errorf("Expected the request to time out")
+	}
+
+	for cntr := 0; cntr < 100; cntr++ {
+		multiplayer.mutex.Lock()
+		waiting := len(multiplayer.waiting)
+		multiplayer.mutex.Unlock()
+		if waiting == 0 {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Errorf("Expected nobody left waiting")
+}
+
+func TestMovesAreValidatedAndPushed(t *testing.T) {
+	server := httptest.NewServer(NewMultiplayer())
+	defer server.Close()
+	white, black := matchPair(t, server, "")
+	url := server.URL + "/api/games/" + white.GameID
+
+	response, err := http.Get(url + "/events")
+	if err != nil {
+		t.Fatalf("Got an error %v", err)
+	}
+	defer response.Body.Close()
+	if response.Header.Get("Content-Type") != "text/event-stream" {
+		t.Errorf("Expected an event stream, got %s", response.Header.Get("Content-Type"))
+	}
+	events := bufio.NewReader(response.Body)
+	if state := nextState(t, events); len(state.History) != 0 {
+		t.Errorf("Expected the stream to start with the current state, got %v", state)
+	}
+
+	tests := []struct {
+		body   LiveMoveRequest
+		status int
+	}{
+		{LiveMoveRequest{Token: "nope", MoveRequest: MoveRequest{SAN: "e4"}}, http.StatusForbidden},
+		{LiveMoveRequest{Token: black.Token, MoveRequest: MoveRequest{SAN: "e5"}}, http.StatusConflict},
+		{LiveMoveRequest{Token: white.Token, MoveRequest: MoveRequest{SAN: "e5"}}, http.StatusBadRequest},
+		{LiveMoveRequest{Token: white.Token, MoveRequest: MoveRequest{From: "e2", To: "e4"}}, http.StatusOK},
+	}
+	for _, test := range tests {
+		if status := call(t, "POST", url+"/move", test.body, nil); status != test.status {
+			t.Errorf("Expected %d for %v, got %d", test.status, test.body, status)
+		}
+	}
+
+	state := nextState(t, events)
+	if state.LastMove == nil || state.LastMove.SAN != "e4" || state.Turn != "b" {
+		t.Errorf("Expected e4 to be pushed, got %v", state)
+	}
+
+	for _, san := range []string{"f6", "d4", "g5", "Qh5"} {
+		token := white.Token
+		if state.Turn == "b" {
+			token = black.Token
+		}
+		call(t, "POST", url+"/move", LiveMoveRequest{Token: token, MoveRequest: MoveRequest{SAN: san}}, &state)
+	}
+	pushed := nextState(t, events)
+	for pushed.LastMove == nil || pushed.LastMove.SAN != "Qh5#" {
+		pushed = nextState(t, events)
+	}
+	if pushed.Outcome != (OutcomeJSON{"1-0", "checkmate"}) {
+		t.Errorf("Expected mate to be pushed, got %v", pushed.Outcome)
+	}
+	if status := call(t, "POST", url+"/resign", TokenRequest{Token: black.Token}, nil); status != http.StatusConflict {
+		t.Errorf("Expected 409 resigning a finished game, got %d", status)
+	}
+}
+
+func TestResign(t *testing.T) {
+	server := httptest.NewServer(NewMultiplayer())
+	defer server.Close()
+	white, _ := matchPair(t, server, "")
+	url := server.URL + "/api/games/" + white.GameID
+
+	if status := call(t, "POST", url+"/resign", TokenRequest{Token: "nope"}, nil); status != http.StatusForbidden {
+		t.Errorf("Expected 403 for a stranger resigning, got %d", status)
+	}
+	var state LiveState
+	call(t, "POST", url+"/resign", TokenRequest{Token: white.Token}, &state)
+	if state.Outcome != (OutcomeJSON{"0-1", "resignation"}) {
+		t.Errorf("Expected white to have resigned, got %v", state.Outcome)
+	}
+	if status := call(t, "POST", url+"/move", LiveMoveRequest{Token: white.Token, MoveRequest: MoveRequest{SAN: "e4"}}, nil); status != http.StatusConflict {
+		t.Errorf("Expected 409 moving after resigning, got %d", status)
+	}
+}
+
+func TestTimedGames(t *testing.T) {
+	multiplayer := NewMultiplayer()
+	server := httptest.NewServer(multiplayer)
+	defer server.Close()
+
+	if status := call(t, "POST", server.URL+"/api/match", MatchRequest{TimeControl: "5 minutes"}, nil); status != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a bad time control, got %d", status)
+	}
+
+	// Someone after a different time control doesn't get paired
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		request, _ := http.NewRequestWithContext(ctx, "POST", server.URL+"/api/match", strings.NewReader(`{"timeControl": "600"}`))
+		http.DefaultClient.Do(request)
+	}()
+	waiting := func() int {
+		multiplayer.mutex.Lock()
+		defer multiplayer.mutex.Unlock()
+		return len(multiplayer.waiting)
+	}
+	for cntr := 0; cntr < 100 && waiting() == 0; cntr++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	white, black := matchPair(t, server, "1")
+	if waiting := waiting(); waiting != 1 {
+		t.Errorf("Expected the 10 minute player to still be waiting, got %d waiting", waiting)
+	}
+
+	url := server.URL + "/api/games/" + white.GameID
+	response, err := http.Get(url + "/events")
+	if err != nil {
+		t.Fatalf("Got an error %v", err)
+	}
+	defer response.Body.Close()
+	events := bufio.NewReader(response.Body)
+	state := nextState(t, events)
+	if state.Clock == nil || state.Clock.TimeControl != "1" || state.Clock.Black != 1000 {
+		t.Errorf("Expected a one second clock, got %v", state.Clock)
+	}
+
+	call(t, "POST", url+"/move", LiveMoveRequest{Token: white.Token, MoveRequest: MoveRequest{SAN: "e4"}}, nil)
+	for state.Outcome.Result == "*" {
+		state = nextState(t, events)
+	}
+	if state.Outcome != (OutcomeJSON{"1-0", "timeout"}) || state.Clock.Black != 0 {
+		t.Errorf("Expected black to lose on time, got %v %v", state.Outcome, state.Clock)
+	}
+	if status := call(t, "POST", url+"/move", LiveMoveRequest{Token: black.Token, MoveRequest: MoveRequest{SAN: "e5"}}, nil); status != http.StatusConflict {
+		t.Errorf("Expected 409 moving after the flag fell, got %d", status)
+	}
+}
+
+func TestUnknownLiveGame(t *testing.T) {
+	server := httptest.NewServer(NewMultiplayer())
+	defer server.Close()
+	if status := call(t, "GET", server.URL+"/api/games/nope/events", nil, nil); status != http.StatusNotFound {
+		t.Errorf("Expected 404, got %d", status)
+	}
+}
+
+// waitForStatus polls the url until it answers with the status, or gives up
+func waitForStatus(t *testing.T, url string, status int) bool {
+	for cntr := 0; cntr < 100; cntr++ {
+		if call(t, "GET", url, nil, nil) == status {
+			return true
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return false
+}
+
+func TestFinishedGamesAreForgotten(t *testing.T) {
+	multiplayer := NewMultiplayer()
+	multiplayer.finishedTimeout = 50 * time.Millisecond
+	server := httptest.NewServer(multiplayer)
+	defer server.Close()
+	white, _ := matchPair(t, server, "")
+	url := server.URL + "/api/games/" + white.GameID
+
+	response, err := http.Get(url + "/events?token=" + white.Token)
+	if err != nil {
+		t.Fatalf("Got an error %v", err)
+	}
+	defer response.Body.Close()
+	events := bufio.NewReader(response.Body)
+	nextState(t, events)
+
+	call(t, "POST", url+"/resign", TokenRequest{Token: white.Token}, nil)
+	if state := nextState(t, events); state.Outcome.Reason != "resignation" {
+		t.Errorf("Expected the resignation to be pushed, got %v", state.Outcome)
+	}
+	if status := call(t, "GET", url, nil, nil); status != http.StatusOK {
+		t.Errorf("Expected the finished game to be kept for a while, got %d", status)
+	}
+	if !waitForStatus(t, url, http.StatusNotFound) {
+		t.Errorf("Expected the finished game to be forgotten")
+	}
+	// Hangs if the event stream doesn't end
+	io.Copy(io.Discard, events)
+}
+
+func TestAbandonedGamesAreForgotten(t *testing.T) {
+	multiplayer := NewMultiplayer()
+	multiplayer.abandonedTimeout = 100 * time.Millisecond
+	server := httptest.NewServer(multiplayer)
+	defer server.Close()
+	white, black := matchPair(t, server, "")
+	url := server.URL + "/api/games/" + white.GameID
+
+	// Following the game as a player keeps it, watching doesn't
+	ctx, cancel := context.WithCancel(context.Background())
+	request, _ := http.NewRequestWithContext(ctx, "GET", url+"/events?token="+black.Token, nil)
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		t.Fatalf("Got an error %v", err)
+	}
+	defer response.Body.Close()
+	watcher, err := http.Get(url + "/events")
+	if err != nil {
+		t.Fatalf("Got an error %v", err)
+	}
+	defer watcher.Body.Close()
+	time.Sleep(200 * time.Millisecond)
+	if status := call(t, "GET", url, nil, nil); status != http.StatusOK {
+		t.Errorf("Expected the game to be kept while a player follows it, got %d", status)
+	}
+
+	cancel()
+	if !waitForStatus(t, url, http.StatusNotFound) {
+		t.Errorf("Expected the game to be forgotten once the players left")
+	}
+}
+
+func TestMatchSkipsWaitersWhoLeft(t *testing.T) {
+	multiplayer := NewMultiplayer()
+	server := httptest.NewServer(multiplayer)
+	defer server.Close()
+
+	gone := make(chan struct{})
+	close(gone)
+	left := &waiter{name: "Cy", seats: make(chan Seat, 1), gone: gone}
+	multiplayer.mutex.Lock()
+	multiplayer.waiting = append(multiplayer.waiting, left)
+	multiplayer.mutex.Unlock()
+
+	white, _ := matchPair(t, server, "")
+	var state LiveState
+	call(t, "GET", server.URL+"/api/games/"+white.GameID, nil, &state)
+	if names := state.White + " " + state.Black; names != "Ann Bob" && names != "Bob Ann" {
+		t.Errorf("Expected Ann and Bob to be paired, got %s", names)
+	}
+	if _, ok := <-left.seats; ok {
+		t.Errorf("Expected no seat for the player who left")
+	}
+}
+
+func TestAbortedGamesAreForgotten(t *testing.T) {
+	multiplayer := NewMultiplayer()
+	server := httptest.NewServer(multiplayer)
+	defer server.Close()
+	white, _ := matchPair(t, server, "")
+	url := server.URL + "/api/games/" + white.GameID
+
+	response, err := http.Get(url + "/events?token=" + white.Token)
+	if err != nil {
+		t.Fatalf("Got an error %v", err)
+	}
+	defer response.Body.Close()
+	events := bufio.NewReader(response.Body)
+	nextState(t, events)
+
+	multiplayer.mutex.Lock()
+	game := multiplayer.games[white.GameID]
+	multiplayer.mutex.Unlock()
+	game.abort()
+	if state := nextState(t, events); state.Outcome.Reason != "aborted" {
+		t.Errorf("Expected the abort to be pushed, got %v", state.Outcome)
+	}
+	if status := call(t, "GET", url, nil, nil); status != http.StatusNotFound {
+		t.Errorf("Expected the aborted game to be forgotten, got %d", status)
+	}
+}
@@ -0,0 +1,398 @@
+// Package server serves a board page and a JSON API for playing against
+// the package's own search, so the browser never needs to know the rules.
+package server
+
+import (
+	"crypto/rand"
+	_ "embed"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rkitts/chess"
+)
+
+//go:embed index.html
+var indexPage []byte
+
+// DefaultBotLimits is how long the bot thinks when Options doesn't say
+var DefaultBotLimits = chess.Limits{Depth: 4, MoveTime: 2 * time.Second}
+
+// maxBodyBytes is the most of a request body that's read. Every body the API
+// takes is a small JSON object.
+const maxBodyBytes = 64 << 10
+
+// Options configures a Server
+type Options struct {
+	// BotLimits bounds the bot's search. Zero means DefaultBotLimits.
+	BotLimits chess.Limits
+	// HashMB is the size of each bot's transposition table. Zero means 4.
+	HashMB int
+	// Bots is how many bot moves can be searched for at once, each with its
+	// own transposition table, shared by all the games. Zero means 4.
+	Bots int
+	// MaxGames is how many games can be kept at once. Starting another is
+	// refused until some expire. Zero means 1000.
+	MaxGames int
+	// IdleTimeout is how long a game is kept without any requests for it.
+	// Zero means an hour.
+	IdleTimeout time.Duration
+}
+
+// Server owns every game being played and serves the page and API. Games
+// without requests for Options.IdleTimeout are forgotten.
+//
+//	GET  /                              the board page
+//	POST /api/games                     start a game, optionally {"fen": ...}
+//	GET  /api/games/{id}                the game's state
+//	GET  /api/games/{id}/moves?square=  legal moves, optionally from one square
+//	POST /api/games/{id}/move           play {"from", "to", "promotion"} or {"san"}
+//	POST /api/games/{id}/undo?plies=    take back plies, one by default
+//	POST /api/games/{id}/bot            have the bot reply
+type Server struct {
+	options   Options
+	mutex     sync.Mutex
+	games     map[string]*game
+	searchers chan *chess.Searcher
+	now       func() time.Time
+}
+
+// game is a single game. lastUsed is guarded by the server's mutex, the rest
+// by the game's.
+type game struct {
+	mutex    sync.Mutex
+	id       string
+	chess    *chess.Chess
+	san      []string
+	lastUsed time.Time
+}
+
+// MoveJSON is a move as the API sends it
+type MoveJSON struct {
+	From      string `json:"from"`
+	To        string `json:"to"`
+	Promotion string `json:"promotion,omitempty"`
+	SAN       string `json:"san"`
+	UCI       string `json:"uci"`
+}
+
+// GameState is a game as the API sends it. Result is "*" while the game is
+// still going.
+type GameState struct {
+	ID       string    `json:"id"`
+	FEN      string    `json:"fen"`
+	Turn     string    `json:"turn"`
+	History  []string  `json:"history"`
+	InCheck  bool      `json:"inCheck"`
+	GameOver bool      `json:"gameOver"`
+	Result   string    `json:"result"`
+	LastMove *MoveJSON `json:"lastMove,omitempty"`
+}
+
+// MoveRequest is the body of a move. Either From and To, or SAN, must be set.
+// A promotion without Promotion becomes a queen.
+type MoveRequest struct {
+	From      string `json:"from"`
+	To        string `json:"to"`
+	Promotion string `json:"promotion"`
+	SAN       string `json:"san"`
+}
+
+// NewGameRequest is the optional body when starting a game
+type NewGameRequest struct {
+	FEN string `json:"fen"`
+}
+
+// New creates a Server with no games
+func New(options Options) *Server {
+	if options.BotLimits == (chess.Limits{}) {
+		options.BotLimits = DefaultBotLimits
+	}
+	if options.HashMB <= 0 {
+		options.HashMB = 4
+	}
+	if options.Bots <= 0 {
+		options.Bots = 4
+	}
+	if options.MaxGames <= 0 {
+		options.MaxGames = 1000
+	}
+	if options.IdleTimeout <= 0 {
+		options.IdleTimeout = time.Hour
+	}
+	retVal := &Server{options: options, games: make(map[string]*game),
+		searchers: make(chan *chess.Searcher, options.Bots), now: time.Now}
+	for cntr := 0; cntr < options.Bots; cntr++ {
+		retVal.searchers <- chess.NewSearcher(options.HashMB)
+	}
+	return retVal
+}
+
+// ServeHTTP implements http.Handler
+func (server *Server) ServeHTTP(writer http.ResponseWriter, request *http.Request) {
+	request.Body = http.MaxBytesReader(writer, request.Body, maxBodyBytes)
+	parts := strings.Split(strings.Trim(request.URL.Path, "/"), "/")
+	route := request.Method + " " + request.URL.Path
+	if len(parts) >= 3 && parts[0] == "api" && parts[1] == "games" {
+		// Route on the path with the game's id taken out
+		route = request.Method + " /api/games/{id}"
+		if len(parts) > 3 {
+			route += "/" + strings.Join(parts[3:], "/")
+		}
+	}
+
+	var handler func(http.ResponseWriter, *http.Request, *game)
+	switch route {
+	case "GET /":
+		server.handleIndex(writer, request)
+	case "POST /api/games", "POST /api/games/":
+		server.handleNewGame(writer, request)
+	case "GET /api/games/{id}":
+		handler = server.handleState
+	case "GET /api/games/{id}/moves":
+		handler = server.handleMoves
+	case "POST /api/games/{id}/move":
+		handler = server.handleMove
+	case "POST /api/games/{id}/undo":
+		handler = server.handleUndo
+	case "POST /api/games/{id}/bot":
+		handler = server.handleBot
+	default:
+		writeError(writer, http.StatusNotFound, fmt.Errorf("No such endpoint %s", route))
+	}
+	if handler != nil {
+		server.withGame(parts[2], writer, request, handler)
+	}
+}
+
+func (server *Server) handleIndex(writer http.ResponseWriter, request *http.Request) {
+	writer.Header().Set("Content-Type", "text/html; charset=utf-8")
+	writer.Write(indexPage)
+}
+
+func (server *Server) handleNewGame(writer http.ResponseWriter, request *http.Request) {
+	var body NewGameRequest
+	err := decodeOptional(request, &body)
+
+	newGame := &game{
+		id:    newID(),
+		chess: chess.New()}
+	if err == nil && body.FEN != "" {
+		err = newGame.chess.Load(body.FEN)
+	}
+	if err != nil {
+		writeError(writer, http.StatusBadRequest, err)
+		return
+	}
+
+	server.mutex.Lock()
+	server.expire()
+	full := len(server.games) >= server.options.MaxGames
+	if !full {
+		newGame.lastUsed = server.now()
+		server.games[newGame.id] = newGame
+	}
+	server.mutex.Unlock()
+	if full {
+		writeError(writer, http.StatusServiceUnavailable, fmt.Errorf("Too many games, try again later"))
+		return
+	}
+	writeJSON(writer, http.StatusCreated, newGame.state(nil))
+}
+
+// expire forgets the games that have been idle too long. The server's mutex
+// must be held.
+func (server *Server) expire() {
+	cutoff := server.now().Add(-server.options.IdleTimeout)
+	for id, current := range server.games {
+		if current.lastUsed.Before(cutoff) {
+			delete(server.games, id)
+		}
+	}
+}
+
+// withGame looks up the game and holds its lock while handler runs
+func (server *Server) withGame(id string, writer http.ResponseWriter, request *http.Request,
+	handler func(http.ResponseWriter, *http.Request, *game)) {
+	server.mutex.Lock()
+	found, ok := server.games[id]
+	if ok {
+		found.lastUsed = server.now()
+	}
+	server.mutex.Unlock()
+	if !ok {
+		writeError(writer, http.StatusNotFound, fmt.Errorf("No game '%s'", id))
+		return
+	}
+	found.mutex.Lock()
+	defer found.mutex.Unlock()
+	handler(writer, request, found)
+}
+
+func (server *Server) handleState(writer http.ResponseWriter, request *http.Request, current *game) {
+	writeJSON(writer, http.StatusOK, current.state(nil))
+}
+
+func (server *Server) handleMoves(writer http.ResponseWriter, request *http.Request, current *game) {
+	square := request.URL.Query().Get("square")
+	if square != "" && !validSquare(square) {
+		writeError(writer, http.StatusBadRequest, fmt.Errorf("Invalid square name '%s'", square))
+		return
+	}
+	retVal := []MoveJSON{}
+	for _, move := range current.chess.Moves(true, square) {
+		retVal = append(retVal, moveJSON(current.chess, move))
+	}
+	writeJSON(writer, http.StatusOK, retVal)
+}
+
+func (server *Server) handleMove(writer http.ResponseWriter, request *http.Request, current *game) {
+	if current.state(nil).GameOver {
+		writeError(writer, http.StatusConflict, fmt.Errorf("The game is over"))
+		return
+	}
+	var body MoveRequest
+	err := json.NewDecoder(request.Body).Decode(&body)
+	var move chess.Move
+	if err == nil {
+		move, err = findMove(current.chess, body)
+	}
+	if err != nil {
+		writeError(writer, http.StatusBadRequest, err)
+		return
+	}
+	played := current.play(move)
+	writeJSON(writer, http.StatusOK, current.state(&played))
+}
+
+func (server *Server) handleUndo(writer http.ResponseWriter, request *http.Request, current *game) {
+	plies := 1
+	if value := request.URL.Query().Get("plies"); value != "" {
+		var err error
+		plies, err = strconv.Atoi(value)
+		if err != nil || plies < 1 {
+			writeError(writer, http.StatusBadRequest, fmt.Errorf("Invalid plies '%s'", value))
+			return
+		}
+	}
+	for cntr := 0; cntr < plies && len(current.san) > 0; cntr++ {
+		current.chess.Undo()
+		current.san = current.san[:len(current.san)-1]
+	}
+	writeJSON(writer, http.StatusOK, current.state(nil))
+}
+
+func (server *Server) handleBot(writer http.ResponseWriter, request *http.Request, current *game) {
+	if current.state(nil).GameOver {
+		writeError(writer, http.StatusConflict, fmt.Errorf("The game is over"))
+		return
+	}
+	// Wait for a free bot rather than giving every game its own table
+	searcher := <-server.searchers
+	result := searcher.Search(current.chess, server.options.BotLimits)
+	server.searchers <- searcher
+	played := current.play(result.BestMove)
+	writeJSON(writer, http.StatusOK, current.state(&played))
+}
+
+// findMove matches the request against the legal moves
+func findMove(game *chess.Chess, body MoveRequest) (chess.Move, error) {
+	var retVal chess.Move
+	var err error
+
+	if body.SAN != "" {
+		retVal, err = game.SANToMove(body.SAN)
+	} else if !validSquare(body.From) || !validSquare(body.To) {
+		err = fmt.Errorf("A move needs from and to squares, or san")
+	} else {
+		found := false
+		for _, move := range game.Moves(true, body.From) {
+			uci := move.UCI()
+			if uci[2:4] != body.To {
+				continue
+			}
+			promotion := body.Promotion
+			if promotion == "" {
+				promotion = "q"
+			}
+			if len(uci) == 4 || uci[4:] == promotion {
+				retVal = move
+				found = true
+				break
+			}
+		}
+		if !found {
+			err = fmt.Errorf("%s%s%s is not a legal move", body.From, body.To, body.Promotion)
+		}
+	}
+	return retVal, err
+}
+
+func (current *game) play(move chess.Move) MoveJSON {
+	retVal := moveJSON(current.chess, move)
+	current.chess.MakeMove(move)
+	current.san = append(current.san, retVal.SAN)
+	return retVal
+}
+
+func (current *game) state(lastMove *MoveJSON) GameState {
+	retVal := GameState{
+		ID:       current.id,
+		FEN:      current.chess.GenerateFen(),
+		Turn:     string(rune(current.chess.Turn())),
+		History:  append([]string{}, current.san...),
+		InCheck:  current.chess.InCheck(),
+		LastMove: lastMove}
+	outcome := current.chess.Outcome()
+	retVal.Result = outcome.Result
+	retVal.GameOver = outcome.Over()
+	return retVal
+}
+
+func moveJSON(game *chess.Chess, move chess.Move) MoveJSON {
+	uci := move.UCI()
+	return MoveJSON{
+		From:      uci[0:2],
+		To:        uci[2:4],
+		Promotion: uci[4:],
+		SAN:       game.SAN(move),
+		UCI:       uci}
+}
+
+func validSquare(square string) bool {
+	return len(square) == 2 && square[0] >= 'a' && square[0] <= 'h' && square[1] >= '1' && square[1] <= '8'
+}
+
+// decodeOptional decodes a JSON body if there is one
+func decodeOptional(request *http.Request, value interface{}) error {
+	var err error
+	if request.ContentLength != 0 {
+		err = json.NewDecoder(request.Body).Decode(value)
+		if err == io.EOF {
+			err = nil
+		}
+	}
+	return err
+}
+
+func newID() string {
+	buffer := make([]byte, 8)
+	rand.Read(buffer)
+	return hex.EncodeToString(buffer)
+}
+
+func writeJSON(writer http.ResponseWriter, status int, value interface{}) {
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(status)
+	json.NewEncoder(writer).Encode(value)
+}
+
+func writeError(writer http.ResponseWriter, status int, err error) {
+	writeJSON(writer, status, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,226 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rkitts/chess"
+)
+
+func newTestServer() *httptest.Server {
+	return httptest.NewServer(New(Options{BotLimits: chess.Limits{Depth: 2}, HashMB: 1}))
+}
+
+// call makes a request and decodes the JSON response into result, returning the status
+func call(t *testing.T, method string, url string, body interface{}, result interface{}) int {
+	var reader *bytes.Reader
+	if body != nil {
+		encoded, _ := json.Marshal(body)
+		reader = bytes.NewReader(encoded)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+	request, _ := http.NewRequest(method, url, reader)
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		t.Fatalf("Got an error %v", err)
+	}
+	defer response.Body.Close()
+	if result != nil {
+		json.NewDecoder(response.Body).Decode(result)
+	}
+	return response.StatusCode
+}
+
+func newGame(t *testing.T, server *httptest.Server, fen string) GameState {
+	var state GameState
+	var body interface{}
+	if fen != "" {
+		body = NewGameRequest{FEN: fen}
+	}
+	if status := call(t, "POST", server.URL+"/api/games", body, &state); status != http.StatusCreated {
+		t.Fatalf("Expected 201, got %d", status)
+	}
+	return state
+}
+
+func TestServesIndexPage(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+
+	response, err := http.Get(server.URL + "/")
+	if err != nil {
+		t.Fatalf("Got an error %v", err)
+	}
+	defer response.Body.Close()
+	var page bytes.Buffer
+	page.ReadFrom(response.Body)
+	if response.StatusCode != http.StatusOK || !strings.Contains(page.String(), "/api/games") {
+		t.Errorf("Expected the board page, got %d", response.StatusCode)
+	}
+}
+
+func TestNewGameAndMoves(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+
+	state := newGame(t, server, "")
+	if state.FEN != "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1" || state.Result != "*" {
+		t.Errorf("Unexpected new game %v", state)
+	}
+
+	var moves []MoveJSON
+	call(t, "GET", server.URL+"/api/games/"+state.ID+"/moves?square=e2", nil, &moves)
+	if len(moves) != 2 || moves[0].From != "e2" || moves[1].SAN != "e4" {
+		t.Errorf("Expected e3 and e4, got %v", moves)
+	}
+	call(t, "GET", server.URL+"/api/games/"+state.ID+"/moves", nil, &moves)
+	if len(moves) != 20 {
+		t.Errorf("Expected 20 moves, got %d", len(moves))
+	}
+	if status := call(t, "GET", server.URL+"/api/games/"+state.ID+"/moves?square=z9", nil, nil); status != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a bad square, got %d", status)
+	}
+}
+
+func TestMoveUndoAndBot(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+	id := newGame(t, server, "").ID
+
+	var state GameState
+	call(t, "POST", server.URL+"/api/games/"+id+"/move", MoveRequest{From: "e2", To: "e4"}, &state)
+	if state.LastMove == nil || state.LastMove.SAN != "e4" || state.Turn != "b" {
+		t.Errorf("Unexpected state after e4 %v", state)
+	}
+	call(t, "POST", server.URL+"/api/games/"+id+"/move", MoveRequest{SAN: "e5"}, &state)
+	if strings.Join(state.History, " ") != "e4 e5" {
+		t.Errorf("Unexpected history %v", state.History)
+	}
+	if status := call(t, "POST", server.URL+"/api/games/"+id+"/move", MoveRequest{From: "e4", To: "e6"}, nil); status != http.StatusBadRequest {
+		t.Errorf("Expected 400 for an illegal move, got %d", status)
+	}
+
+	call(t, "POST", server.URL+"/api/games/"+id+"/undo?plies=2", nil, &state)
+	if len(state.History) != 0 || state.Turn != "w" {
+		t.Errorf("Expected both moves taken back, got %v", state)
+	}
+
+	call(t, "POST", server.URL+"/api/games/"+id+"/bot", nil, &state)
+	if state.LastMove == nil || len(state.History) != 1 || state.Turn != "b" {
+		t.Errorf("Expected the bot to move, got %v", state)
+	}
+
+	call(t, "GET", server.URL+"/api/games/"+id, nil, &state)
+	if len(state.History) != 1 {
+		t.Errorf("Expected the bot's move in the game, got %v", state)
+	}
+}
+
+func TestPromotionAndGameOver(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+	id := newGame(t, server, "7k/P7/6K1/8/8/8/8/8 w - - 0 1").ID
+
+	var state GameState
+	call(t, "POST", server.URL+"/api/games/"+id+"/move", MoveRequest{From: "a7", To: "a8", Promotion: "r"}, &state)
+	if state.LastMove == nil || state.LastMove.SAN != "a8=R#" {
+		t.Errorf("Expected a8=R#, got %v", state.LastMove)
+	}
+	call(t, "POST", server.URL+"/api/games/"+id+"/undo", nil, &state)
+	call(t, "POST", server.URL+"/api/games/"+id+"/move", MoveRequest{From: "a7", To: "a8"}, &state)
+	if !state.GameOver || state.Result != "1-0" || state.LastMove.SAN != "a8=Q#" {
+		t.Errorf("Expected a8=Q to mate, got %v", state)
+	}
+	if status := call(t, "POST", server.URL+"/api/games/"+id+"/bot", nil, nil); status != http.StatusConflict {
+		t.Errorf("Expected 409 asking for a move after the game ended, got %d", status)
+	}
+}
+
+func TestNoMovesAfterADraw(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+	// Fifty moves without a capture or pawn move, but plenty of legal moves left
+	id := newGame(t, server, "4k3/8/8/8/8/8/8/R3K3 w - - 100 80").ID
+
+	var state GameState
+	call(t, "GET", server.URL+"/api/games/"+id, nil, &state)
+	if !state.GameOver || state.Result != "1/2-1/2" {
+		t.Errorf("Expected a draw, got %v", state)
+	}
+	if status := call(t, "POST", server.URL+"/api/games/"+id+"/move", MoveRequest{SAN: "Ra8+"}, nil); status != http.StatusConflict {
+		t.Errorf("Expected 409 moving after the game ended, got %d", status)
+	}
+	if status := call(t, "POST", server.URL+"/api/games/"+id+"/bot", nil, nil); status != http.StatusConflict {
+		t.Errorf("Expected 409 asking for a move after the game ended, got %d", status)
+	}
+	call(t, "GET", server.URL+"/api/games/"+id, nil, &state)
+	if len(state.History) != 0 {
+		t.Errorf("Expected no moves played, got %v", state.History)
+	}
+}
+
+func TestUnknownGameAndBadFen(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+
+	if status := call(t, "GET", server.URL+"/api/games/nope", nil, nil); status != http.StatusNotFound {
+		t.Errorf("Expected 404, got %d", status)
+	}
+	var failure map[string]string
+	status := call(t, "POST", server.URL+"/api/games", NewGameRequest{FEN: "not a fen"}, &failure)
+	if status != http.StatusBadRequest || failure["error"] == "" {
+		t.Errorf("Expected 400 with an error, got %d %v", status, failure)
+	}
+}
+
+func TestGamesExpireAndAreCapped(t *testing.T) {
+	now := time.Now()
+	handler := New(Options{BotLimits: chess.Limits{Depth: 1}, HashMB: 1, MaxGames: 2, IdleTimeout: time.Minute})
+	handler.now = func() time.Time { return now }
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	first := newGame(t, server, "")
+	newGame(t, server, "")
+	if status := call(t, "POST", server.URL+"/api/games", nil, nil); status != http.StatusServiceUnavailable {
+		t.Errorf("Expected 503 with too many games, got %d", status)
+	}
+
+	// Using the first game keeps it, so only the second expires
+	now = now.Add(40 * time.Second)
+	call(t, "GET", server.URL+"/api/games/"+first.ID, nil, nil)
+	now = now.Add(40 * time.Second)
+	third := newGame(t, server, "")
+	if status := call(t, "GET", server.URL+"/api/games/"+first.ID, nil, nil); status != http.StatusOK {
+		t.Errorf("Expected the game in use to be kept, got %d", status)
+	}
+	if status := call(t, "POST", server.URL+"/api/games/"+third.ID+"/bot", nil, nil); status != http.StatusOK {
+		t.Errorf("Expected the bot to move, got %d", status)
+	}
+	if len(handler.games) != 2 {
+		t.Errorf("Expected 2 games, got %d", len(handler.games))
+	}
+}
+
+func TestRequestBodyIsLimited(t *testing.T) {
+	server := newTestServer()
+	defer server.Close()
+
+	// A legal move, but only after more than the limit of white space
+	state := newGame(t, server, "")
+	body := strings.Repeat(" ", maxBodyBytes) + `{"san": "e4"}`
+	response, err := http.Post(server.URL+"/api/games/"+state.ID+"/move", "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("Got an error %v", err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected 400 for a huge body, got %d", response.StatusCode)
+	}
+}
@@ -0,0 +1,174 @@
+package chess
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// SANErrorKind says why a move couldn't be read
+type SANErrorKind int
+
+const (
+	// SANUnparseable means the text isn't a move in any notation understood
+	SANUnparseable SANErrorKind = iota
+	// SANIllegal means the text is a move, but not a legal one
+	SANIllegal
+	// SANAmbiguous means the text fits more than one legal move
+	SANAmbiguous
+)
+
+// SANError is the error from SloppySANToMove. Candidates are the legal
+// moves, in SAN, that an ambiguous move could be.
+type SANError struct {
+	SAN        string
+	Kind       SANErrorKind
+	Candidates []string
+}
+
+func (err *SANError) Error() string {
+	retVal := fmt.Sprintf("Can't read '%s' as a move", err.SAN)
+	switch err.Kind {
+	case SANIllegal:
+		retVal = fmt.Sprintf("%s is not a legal move", err.SAN)
+	case SANAmbiguous:
+		retVal = fmt.Sprintf("%s is ambiguous, it could be %s", err.SAN, strings.Join(err.Candidates, " or "))
+	}
+	return retVal
+}
+
+// The forms a move can be written in, once annotations are removed. A
+// piece letter is optional in all of them, for pawns written as Pe4.
+var (
+	sloppyCastleRegexp     = regexp.MustCompile(`^[Oo0]-?[Oo0](-?[Oo0])?$`)
+	sloppyCoordinateRegexp = regexp.MustCompile(`^([PNBRQK])?([a-h][1-8])[-x:]?([a-h][1-8])=?([NBRQnbrq])?$`)
+	sloppySANRegexp        = regexp.MustCompile(`^([PNBRQK])?([a-h])?([1-8])?[x:]?([a-h][1-8])=?([NBRQnbrq])?$`)
+	sloppyAnnotationRegexp = regexp.MustCompile(`(\s*e\.?p\.?)?[+#]*[?!]*$`)
+)
+
+// sloppyMove is a move as read from text, before it's matched to a legal move.
+// Anything that wasn't written is zero.
+type sloppyMove struct {
+	ptype     PieceType
+	fromFile  byte
+	fromRank  byte
+	to        string
+	promotion PieceType
+}
+
+// SloppySANToMove reads a move the way people and other programs write them,
+// as well as standard SAN. It accepts castling with zeros, pawns written
+// with a P, coordinates such as e2e4 or e2-e4, more disambiguation than
+// needed, e.p. after en passant captures, promotions without = or in lower
+// case, piece letters in lower case, and check marks and annotations such
+// as +!?. The error is a *SANError saying whether the move is unreadable,
+// illegal or ambiguous.
+func (chess *Chess) SloppySANToMove(san string) (Move, error) {
+	var retVal Move
+	var err error
+	cleaned := sloppyAnnotationRegexp.ReplaceAllString(strings.TrimSpace(san), "")
+
+	parses := parseSloppyMove(cleaned)
+	var matches []Move
+	for cntr := 0; cntr < len(parses) && len(matches) == 0; cntr++ {
+		matches = chess.matchSloppyMove(parses[cntr])
+	}
+	switch {
+	case len(parses) == 0:
+		err = &SANError{SAN: san, Kind: SANUnparseable}
+	case len(matches) == 0:
+		err = &SANError{SAN: san, Kind: SANIllegal}
+	case len(matches) > 1:
+		sanErr := &SANError{SAN: san, Kind: SANAmbiguous}
+		for _, match := range matches {
+			sanErr.Candidates = append(sanErr.Candidates, chess.moveToSAN(match))
+		}
+		err = sanErr
+	default:
+		retVal = matches[0]
+	}
+	return retVal, err
+}
+
+// MoveSloppy reads a move with SloppySANToMove and makes it
+func (chess *Chess) MoveSloppy(san string) error {
+	move, err := chess.SloppySANToMove(san)
+	if err == nil {
+		chess.makeMove(move)
+	}
+	return err
+}
+
+// parseSloppyMove returns the moves the text could be, most likely first.
+// Lower case piece letters are tried as upper case after the text as it is,
+// so bxc6 is a pawn capture if there is one and a bishop's otherwise.
+func parseSloppyMove(text string) []sloppyMove {
+	var retVal []sloppyMove
+	if sloppyCastleRegexp.MatchString(text) {
+		to := "g"
+		if strings.Count(strings.ToUpper(strings.ReplaceAll(text, "0", "O")), "O") == 3 {
+			to = "c"
+		}
+		return []sloppyMove{{ptype: king, to: to}}
+	}
+	candidates := []string{text}
+	if len(text) > 1 && strings.ContainsRune("nbrqkp", rune(text[0])) {
+		candidates = append(candidates, string(unicode.ToUpper(rune(text[0])))+text[1:])
+	}
+	for _, candidate := range candidates {
+		if groups := sloppyCoordinateRegexp.FindStringSubmatch(candidate); groups != nil {
+			retVal = append(retVal, sloppyMove{
+				ptype:     pieceFromLetter(groups[1]),
+				fromFile:  groups[2][0],
+				fromRank:  groups[2][1],
+				to:        groups[3],
+				promotion: pieceFromLetter(groups[4])})
+		} else if groups := sloppySANRegexp.FindStringSubmatch(candidate); groups != nil {
+			parsed := sloppyMove{ptype: pieceFromLetter(groups[1]), to: groups[4], promotion: pieceFromLetter(groups[5])}
+			if parsed.ptype == 0 {
+				parsed.ptype = pawn
+			}
+			if groups[2] != "" {
+				parsed.fromFile = groups[2][0]
+			}
+			if groups[3] != "" {
+				parsed.fromRank = groups[3][0]
+			}
+			retVal = append(retVal, parsed)
+		}
+	}
+	return retVal
+}
+
+// matchSloppyMove returns the legal moves that fit what was read. A castling
+// move only has its king and the file it goes to.
+func (chess *Chess) matchSloppyMove(parsed sloppyMove) []Move {
+	var retVal []Move
+	for _, move := range chess.Moves(true, "") {
+		from, to := algebraic(move.from), algebraic(move.to)
+		castling := move.flags&(ksideCastleMove|qsideCastleMove) != 0
+		matches := parsed.ptype == 0 || move.ptype == parsed.ptype
+		if len(parsed.to) == 1 {
+			matches = matches && castling && to[0] == parsed.to[0]
+		} else {
+			matches = matches && to == parsed.to
+		}
+		matches = matches && (parsed.fromFile == 0 || from[0] == parsed.fromFile)
+		matches = matches && (parsed.fromRank == 0 || from[1] == parsed.fromRank)
+		matches = matches && (parsed.promotion == 0 || move.promotedType == parsed.promotion)
+		if matches {
+			retVal = append(retVal, move)
+		}
+	}
+	return retVal
+}
+
+// pieceFromLetter returns the piece type for a letter in either case, or 0 for none
+func pieceFromLetter(letter string) PieceType {
+	var retVal PieceType
+	if letter != "" {
+		retVal = PieceType(unicode.ToLower(rune(letter[0])))
+	}
+	return retVal
+}
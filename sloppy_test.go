@@ -0,0 +1,91 @@
+package chess
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSloppySANToMove(t *testing.T) {
+	tests := []struct {
+		fen      string
+		typed    string
+		expected string
+	}{
+		{defaultPosition, "e4", "e2e4"},
+		{defaultPosition, "Pe4", "e2e4"},
+		{defaultPosition, "e2e4", "e2e4"},
+		{defaultPosition, "e2-e4", "e2e4"},
+		{defaultPosition, "nf3", "g1f3"},
+		{defaultPosition, "Ng1f3", "g1f3"},
+		{defaultPosition, "Ng1-f3", "g1f3"},
+		{defaultPosition, "Nf3!?", "g1f3"},
+		{"r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1", "0-0", "e1g1"},
+		{"r3k2r/8/8/8/8/8/8/R3K2R w KQkq - 0 1", "O-O-O+", "e1c1"},
+		{"r3k2r/8/8/8/8/8/8/R3K2R b KQkq - 0 1", "o-o", "e8g8"},
+		{"r3k2r/8/8/8/8/8/8/R3K2R b KQkq - 0 1", "e8c8", "e8c8"},
+		{"4k3/8/8/8/8/8/8/1N2K1N1 w - - 0 1", "Nge2", "g1e2"},
+		{"4k3/8/8/3pP3/8/8/8/4K3 w - d6 0 2", "exd6e.p.", "e5d6"},
+		{"4k3/8/8/3pP3/8/8/8/4K3 w - d6 0 2", "exd6 ep", "e5d6"},
+		{"k7/4P3/8/8/8/8/8/4K3 w - - 0 1", "e8Q", "e7e8q"},
+		{"k7/4P3/8/8/8/8/8/4K3 w - - 0 1", "e7e8n", "e7e8n"},
+		{"k7/4P3/8/8/8/8/8/4K3 w - - 0 1", "e8=R+", "e7e8r"},
+		{"k7/4P3/8/8/8/8/8/4K3 w - - 0 1", "e8=q", "e7e8q"},
+		{"k7/4P3/8/8/8/8/8/4K3 w - - 0 1", "e8q", "e7e8q"},
+		{"k7/4P3/8/8/8/8/8/4K3 w - - 0 1", "e8b", "e7e8b"},
+		{"3r3k/4P3/8/8/8/8/8/4K3 w - - 0 1", "exd8=n", "e7d8n"},
+		{"r1bqkbnr/pppp1ppp/2n5/1B2p3/4P3/5N2/PPPP1PPP/RNBQK2R w KQkq - 3 3", "Bxc6+!?", "b5c6"},
+		{"r1bqkbnr/pppp1ppp/2n5/1B2p3/4P3/5N2/PPPP1PPP/RNBQK2R w KQkq - 3 3", "bxc6", "b5c6"},
+		// A pawn capture from the b file wins over the bishop
+		{"4k3/8/2r5/1P6/8/8/B7/4K3 w - - 0 1", "bxc6", "b5c6"},
+	}
+	for _, test := range tests {
+		chess := New()
+		chess.Load(test.fen)
+		move, err := chess.SloppySANToMove(test.typed)
+		if err != nil || move.UCI() != test.expected {
+			t.Errorf("Expected %s for %s, got %s %v", test.expected, test.typed, move.UCI(), err)
+		}
+	}
+}
+
+func TestSloppySANErrors(t *testing.T) {
+	tests := []struct {
+		fen        string
+		typed      string
+		kind       SANErrorKind
+		candidates string
+	}{
+		{defaultPosition, "nonsense", SANUnparseable, ""},
+		{defaultPosition, "e5", SANIllegal, ""},
+		{defaultPosition, "O-O", SANIllegal, ""},
+		{defaultPosition, "Nbd2", SANIllegal, ""},
+		{"4k3/8/8/8/8/8/8/1N2KN2 w - - 0 1", "Nd2", SANAmbiguous, "Nbd2 Nfd2"},
+		{"k7/4P3/8/8/8/8/8/4K3 w - - 0 1", "e8", SANAmbiguous, "e8=Q+ e8=R+ e8=B e8=N"},
+	}
+	for _, test := range tests {
+		chess := New()
+		chess.Load(test.fen)
+		_, err := chess.SloppySANToMove(test.typed)
+		var sanErr *SANError
+		if !errors.As(err, &sanErr) || sanErr.Kind != test.kind || strings.Join(sanErr.Candidates, " ") != test.candidates {
+			t.Errorf("Expected error kind %d with %s for %s, got %v", test.kind, test.candidates, test.typed, err)
+		}
+	}
+	chess := New()
+	chess.Load("4k3/8/8/8/8/8/8/1N2KN2 w - - 0 1")
+	if _, err := chess.SloppySANToMove("Nd2"); err == nil || err.Error() != "Nd2 is ambiguous, it could be Nbd2 or Nfd2" {
+		t.Errorf("Unexpected message %v", err)
+	}
+}
+
+func TestReplaySloppy(t *testing.T) {
+	games, _ := ReadPGN(strings.NewReader("1. e2e4 e7-e5 2. ng1f3 Nb8c6 3. Bf1c4 Ng8f6 4. 0-0 *\n"))
+	if _, err := games[0].Replay(); err == nil {
+		t.Errorf("Expected the strict replay to fail")
+	}
+	chess, err := games[0].ReplaySloppy()
+	if err != nil || chess.GenerateFen() != "r1bqkb1r/pppp1ppp/2n2n2/4p3/2B1P3/5N2/PPPP1PPP/RNBQ1RK1 b kq - 5 4" {
+		t.Errorf("Unexpected replay %v %v", chess, err)
+	}
+}
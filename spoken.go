@@ -0,0 +1,231 @@
+package chess
+
+import (
+	"strings"
+)
+
+// pieceNames are the piece types as they're said
+var pieceNames = map[PieceType]string{
+	pawn: "pawn", knight: "knight", bishop: "bishop", rook: "rook", queen: "queen", king: "king"}
+
+// Words that can be said in a move, other than pieces and squares
+var (
+	spokenCaptures   = wordSet("takes take captures capture x")
+	spokenPromotions = wordSet("promotes promote promoting promotion equals becomes")
+	spokenFillers    = wordSet("to from on at moves move goes go square the a white black en passant check checkmate mate and")
+	spokenNumbers    = map[string]string{"one": "1", "two": "2", "three": "3", "four": "4", "five": "5", "six": "6", "seven": "7", "eight": "8"}
+	spokenFiles      = map[string]string{"alpha": "a", "bravo": "b", "charlie": "c", "delta": "d", "echo": "e", "foxtrot": "f", "golf": "g", "hotel": "h"}
+)
+
+func wordSet(words string) map[string]bool {
+	retVal := make(map[string]bool)
+	for _, word := range strings.Fields(words) {
+		retVal[word] = true
+	}
+	return retVal
+}
+
+// spokenMove is a move as it was said. Anything that wasn't said is zero.
+// castle is "g" or "c" for the file the king goes to, or "?" for either.
+type spokenMove struct {
+	ptype     PieceType
+	from      string
+	to        string
+	capture   bool
+	captured  PieceType
+	promotion PieceType
+	castle    string
+}
+
+// SpokenToMove reads a move said in words, such as "knight to f3", "pawn
+// takes d5", "castle kingside", "queen e7 check" or "bishop b5 takes
+// knight". Squares can be said as e4, e 4, e four or echo four. The error is
+// a *SANError; when the move could be more than one legal move its
+// candidates are said the way Spoken says them, for asking which was meant.
+func (chess *Chess) SpokenToMove(text string) (Move, error) {
+	var retVal Move
+	var err error
+	parsed, ok := parseSpokenMove(spokenWords(text))
+	var matches []Move
+	if ok {
+		for _, move := range chess.Moves(true, "") {
+			if parsed.matches(move) {
+				matches = append(matches, move)
+			}
+		}
+	}
+	switch {
+	case !ok:
+		err = &SANError{SAN: text, Kind: SANUnparseable}
+	case len(matches) == 0:
+		err = &SANError{SAN: text, Kind: SANIllegal}
+	case len(matches) > 1:
+		sanErr := &SANError{SAN: text, Kind: SANAmbiguous}
+		for _, match := range matches {
+			sanErr.Candidates = append(sanErr.Candidates, spokenMoveText(match))
+		}
+		err = sanErr
+	default:
+		retVal = matches[0]
+	}
+	return retVal, err
+}
+
+// spokenWords splits what was said into lower case words, joining squares
+// said as a file and a rank into one word
+func spokenWords(text string) []string {
+	replacer := strings.NewReplacer(",", " ", ".", " ", "!", " ", "?", " ", ";", " ", ":", " ", "-", " ")
+	var retVal []string
+	for _, word := range strings.Fields(strings.ToLower(replacer.Replace(text))) {
+		if number, ok := spokenNumbers[word]; ok {
+			word = number
+		}
+		if file, ok := spokenFiles[word]; ok {
+			word = file
+		}
+		last := len(retVal) - 1
+		if len(word) == 1 && isRank(word[0]) && last >= 0 && len(retVal[last]) == 1 && isFile(retVal[last][0]) {
+			retVal[last] += word
+		} else {
+			retVal = append(retVal, word)
+		}
+	}
+	return retVal
+}
+
+// parseSpokenMove works out what was said. Squares before a capture word are
+// where the piece is, and the one after is where it goes; without a capture,
+// one square is where the piece goes and two are from and to.
+func parseSpokenMove(words []string) (spokenMove, bool) {
+	var retVal spokenMove
+	ok := true
+	var squares []string
+	afterCapture, afterPromotion := false, false
+	for _, word := range words {
+		if strings.HasPrefix(word, "castl") {
+			retVal.castle = "?"
+		}
+	}
+	if retVal.castle != "" {
+		for _, word := range words {
+			switch word {
+			case "kingside", "king", "short":
+				retVal.castle = "g"
+			case "queenside", "queen", "long":
+				retVal.castle = "c"
+			}
+		}
+		return retVal, true
+	}
+
+	for cntr := 0; cntr < len(words) && ok; cntr++ {
+		word := words[cntr]
+		ptype, isPiece := spokenPiece(word)
+		switch {
+		case isPiece && afterPromotion:
+			retVal.promotion = ptype
+		case isPiece && afterCapture:
+			retVal.captured = ptype
+		case isPiece && retVal.ptype == 0 && len(squares) == 0:
+			retVal.ptype = ptype
+		case isPiece && len(squares) > 0 && ptype != pawn && ptype != king:
+			// A pawn move followed by what it becomes, as in "e8 queen"
+			retVal.promotion = ptype
+		case len(word) == 2 && isFile(word[0]) && isRank(word[1]):
+			if afterCapture && retVal.to == "" {
+				retVal.to = word
+			} else if afterCapture {
+				ok = false
+			} else {
+				squares = append(squares, word)
+			}
+		case spokenCaptures[word]:
+			retVal.capture = true
+			afterCapture = true
+		case spokenPromotions[word]:
+			afterPromotion = true
+		case spokenFillers[word]:
+		default:
+			ok = false
+		}
+	}
+
+	switch {
+	case len(squares) == 1 && afterCapture:
+		retVal.from = squares[0]
+	case len(squares) == 1:
+		retVal.to = squares[0]
+	case len(squares) == 2 && !afterCapture:
+		retVal.from, retVal.to = squares[0], squares[1]
+	case len(squares) > 0:
+		ok = false
+	}
+	ok = ok && (retVal.to != "" || retVal.captured != 0 || (retVal.capture && retVal.from != ""))
+	return retVal, ok
+}
+
+// spokenPiece returns the piece type a word names
+func spokenPiece(word string) (PieceType, bool) {
+	word = strings.TrimSuffix(word, "s")
+	for ptype, name := range pieceNames {
+		if name == word {
+			return ptype, true
+		}
+	}
+	return 0, false
+}
+
+// matches returns true if the legal move fits what was said
+func (parsed spokenMove) matches(move Move) bool {
+	castling := move.flags & (ksideCastleMove | qsideCastleMove)
+	if parsed.castle != "" {
+		return castling != 0 && (parsed.castle == "?" || algebraic(move.to)[0] == parsed.castle[0])
+	}
+	captured := move.capturedType
+	if move.flags&enpassantMove != 0 {
+		captured = pawn
+	}
+	retVal := parsed.ptype == 0 || parsed.ptype == move.ptype
+	retVal = retVal && (parsed.from == "" || parsed.from == algebraic(move.from))
+	retVal = retVal && (parsed.to == "" || parsed.to == algebraic(move.to))
+	retVal = retVal && (!parsed.capture || move.flags&(captureMove|enpassantMove) != 0)
+	retVal = retVal && (parsed.captured == 0 || parsed.captured == captured)
+	retVal = retVal && (parsed.promotion == 0 || parsed.promotion == move.promotedType)
+	return retVal
+}
+
+// Spoken returns the move as it could be read aloud, such as "White knight
+// from g1 to f3, check" or "Black castles queenside"
+func (chess *Chess) Spoken(move Move) string {
+	retVal := colorName(move.turn) + " " + spokenMoveText(move)
+	switch chess.checkSuffix(move) {
+	case "+":
+		retVal += ", check"
+	case "#":
+		retVal += ", checkmate"
+	}
+	return retVal
+}
+
+// spokenMoveText is the move in words, without who made it or what it does
+// to the other king
+func spokenMoveText(move Move) string {
+	if (move.flags & ksideCastleMove) != 0 {
+		return "castles kingside"
+	} else if (move.flags & qsideCastleMove) != 0 {
+		return "castles queenside"
+	}
+	retVal := pieceNames[move.ptype] + " from " + algebraic(move.from)
+	switch {
+	case (move.flags & enpassantMove) != 0:
+		retVal += " takes pawn en passant on " + algebraic(move.to)
+	case (move.flags & captureMove) != 0:
+		retVal += " takes " + pieceNames[move.capturedType] + " on " + algebraic(move.to)
+	default:
+		retVal += " to " + algebraic(move.to)
+	}
+	if (move.flags & promotionMove) != 0 {
+		retVal += ", promotes to " + pieceNames[move.promotedType]
+	}
+	return retVal
+}
@@ -0,0 +1,83 @@
+package chess
+
+import "testing"
+
+func TestSpokenToMove(t *testing.T) {
+	tests := []struct {
+		fen      string
+		spoken   string
+		expected string
+	}{
+		{defaultPosition, "knight to f3", "g1f3"},
+		{defaultPosition, "Pawn to e four.", "e2e4"},
+		{defaultPosition, "pawn echo two echo four", "e2e4"},
+		{defaultPosition, "e2 e4", "e2e4"},
+		{"rnbqkbnr/ppp1pppp/8/3p4/4P3/8/PPPP1PPP/RNBQKBNR w KQkq - 0 2", "pawn takes d5", "e4d5"},
+		{"rnbqkbnr/ppp1pppp/8/3p4/4P3/8/PPPP1PPP/RNBQKBNR w KQkq - 0 2", "takes pawn", "e4d5"},
+		{"r1bqkbnr/pppp1ppp/2n5/1B2p3/4P3/5N2/PPPP1PPP/RNBQK2R w KQkq - 0 4", "bishop b5 takes knight", "b5c6"},
+		{"r1bqkbnr/pppp1ppp/2n5/1B2p3/4P3/5N2/PPPP1PPP/RNBQK2R w KQkq - 0 4", "castle kingside", "e1g1"},
+		{"r1bqkbnr/pppp1ppp/2n5/1B2p3/4P3/5N2/PPPP1PPP/RNBQK2R w KQkq - 0 4", "castles", "e1g1"},
+		{"3qk3/8/8/8/8/8/8/4K3 b - - 0 1", "queen e7 check", "d8e7"},
+		{"4k3/8/8/3pP3/8/8/8/4K3 w - d6 0 1", "pawn e5 takes pawn en passant on d6", "e5d6"},
+		{"8/P6k/8/8/8/8/8/K7 w - - 0 1", "pawn to a8 promotes to knight", "a7a8n"},
+		{"8/P6k/8/8/8/8/8/K7 w - - 0 1", "a8 queen", "a7a8q"},
+	}
+	for _, test := range tests {
+		chess := New()
+		chess.Load(test.fen)
+		if move, err := chess.SpokenToMove(test.spoken); err != nil || move.UCI() != test.expected {
+			t.Errorf("Expected '%s' to be %s, got %s, %v", test.spoken, test.expected, move.UCI(), err)
+		}
+	}
+}
+
+func TestSpokenToMoveErrors(t *testing.T) {
+	tests := []struct {
+		fen      string
+		spoken   string
+		kind     SANErrorKind
+		expected string
+	}{
+		{defaultPosition, "knight to e4", SANIllegal, "knight to e4 is not a legal move"},
+		{defaultPosition, "wibble", SANUnparseable, "Can't read 'wibble' as a move"},
+		{defaultPosition, "knight", SANUnparseable, "Can't read 'knight' as a move"},
+		{"4k3/8/8/8/8/8/8/1N2KN2 w - - 0 1", "knight to d2", SANAmbiguous,
+			"knight to d2 is ambiguous, it could be knight from b1 to d2 or knight from f1 to d2"},
+	}
+	for _, test := range tests {
+		chess := New()
+		chess.Load(test.fen)
+		_, err := chess.SpokenToMove(test.spoken)
+		if sanErr, ok := err.(*SANError); !ok || sanErr.Kind != test.kind || sanErr.Error() != test.expected {
+			t.Errorf("Expected '%s', got %v", test.expected, err)
+		}
+	}
+}
+
+func TestSpoken(t *testing.T) {
+	tests := []struct {
+		fen      string
+		uci      string
+		expected string
+	}{
+		{defaultPosition, "g1f3", "White knight from g1 to f3"},
+		{"rnbqkbnr/ppp1pppp/8/3p4/4P3/8/PPPP1PPP/RNBQKBNR w KQkq - 0 2", "e4d5", "White pawn from e4 takes pawn on d5"},
+		{"4k3/8/8/3pP3/8/8/8/4K3 w - d6 0 1", "e5d6", "White pawn from e5 takes pawn en passant on d6"},
+		{"r3k3/8/8/8/8/8/8/4K3 b q - 0 1", "e8c8", "Black castles queenside"},
+		{"4k3/8/8/8/8/8/8/R3K3 w - - 0 1", "a1a8", "White rook from a1 to a8, check"},
+		{"6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1", "a1a8", "White rook from a1 to a8, checkmate"},
+		{"8/P6k/8/8/8/8/8/K7 w - - 0 1", "a7a8q", "White pawn from a7 to a8, promotes to queen"},
+	}
+	for _, test := range tests {
+		chess := New()
+		chess.Load(test.fen)
+		move, _ := chess.UCIToMove(test.uci)
+		spoken := chess.Spoken(move)
+		if spoken != test.expected {
+			t.Errorf("Expected '%s', got '%s'", test.expected, spoken)
+		}
+		if parsed, err := chess.SpokenToMove(spoken); err != nil || parsed != move {
+			t.Errorf("Expected '%s' to read back, got %v", spoken, err)
+		}
+	}
+}
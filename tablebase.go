@@ -0,0 +1,813 @@
+package chess
+
+import (
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Results of a tablebase probe, from the side to move's point of view
+const (
+	TablebaseLoss = -1
+	TablebaseDraw = 0
+	TablebaseWin  = 1
+)
+
+// Encoding of a position's value in Tablebase.values. A win or loss is
+// stored as the plies to mate plus one: an odd number of plies is a win for
+// the side to move, an even number a loss, with zero meaning checkmated.
+const (
+	tbDraw    = 0
+	tbInvalid = 255
+	tbMaxPly  = 253
+)
+
+// Marks a position whose result has been spread to the positions before it
+const tbDone = 255
+
+// Pieces are indexed in this order within each side, after the king
+const tbPieceOrder = "QRBNP"
+
+// The most pieces, kings included, a table can be generated for. Every extra
+// piece makes the table 64 times bigger.
+const tbMaxPieces = 4
+
+const tbFileMagic = "DTM1"
+
+// TablebaseExtension is the extension Tablebases.Save gives each table's file
+const TablebaseExtension = ".dtm"
+
+var tbSignatureRegexp = regexp.MustCompile(`^K([QRBNP]*)K([QRBNP]*)$`)
+
+// TablebaseResult is what a tablebase knows about a position: whether the
+// side to move wins, draws or loses and, unless it's a draw, how many plies
+// it is until mate with best play. DTM is zero when the side to move is
+// already checkmated.
+type TablebaseResult struct {
+	WDL int
+	DTM int
+}
+
+// Tablebase holds the value of every position with a single material
+// signature, such as KQK or KRKN, with white having the first set of pieces
+type Tablebase struct {
+	signature string
+	pieces    []Piece
+	values    []uint8
+}
+
+// Tablebases is a set of tables that can be probed for any position whose
+// material one of them covers, whichever side has the pieces
+type Tablebases struct {
+	tables map[string]*Tablebase
+}
+
+// NewTablebases creates an empty set of tables
+func NewTablebases() *Tablebases {
+	return &Tablebases{tables: make(map[string]*Tablebase)}
+}
+
+// LoadTablebases reads every table saved in dir by Tablebases.Save
+func LoadTablebases(dir string) (*Tablebases, error) {
+	retVal := NewTablebases()
+	paths, err := filepath.Glob(filepath.Join(dir, "*"+TablebaseExtension))
+	for cntr := 0; err == nil && cntr < len(paths); cntr++ {
+		var file *os.File
+		file, err = os.Open(paths[cntr])
+		if err == nil {
+			var table *Tablebase
+			table, err = ReadTablebase(file)
+			file.Close()
+			if err == nil {
+				retVal.Add(table)
+			}
+		}
+	}
+	return retVal, err
+}
+
+// Add adds a table to the set, replacing any with the same signature
+func (tbs *Tablebases) Add(table *Tablebase) {
+	tbs.tables[table.signature] = table
+}
+
+// Table returns the table for signature, generated or added with either side having the pieces
+func (tbs *Tablebases) Table(signature string) (*Tablebase, bool) {
+	var retVal *Tablebase
+	whitePieces, blackPieces, err := parseSignature(signature)
+	if err == nil {
+		retVal, _ = tbs.locate(whitePieces, blackPieces)
+	}
+	return retVal, retVal != nil
+}
+
+// Signatures returns the signatures of the tables in the set, sorted
+func (tbs *Tablebases) Signatures() []string {
+	var retVal []string
+	for signature := range tbs.tables {
+		retVal = append(retVal, signature)
+	}
+	sort.Strings(retVal)
+	return retVal
+}
+
+// Save writes each table to dir as its signature followed by TablebaseExtension
+func (tbs *Tablebases) Save(dir string) error {
+	var err error
+	for _, signature := range tbs.Signatures() {
+		var file *os.File
+		file, err = os.Create(filepath.Join(dir, signature+TablebaseExtension))
+		if err == nil {
+			err = tbs.tables[signature].Write(file)
+			if closeErr := file.Close(); err == nil {
+				err = closeErr
+			}
+		}
+		if err != nil {
+			break
+		}
+	}
+	return err
+}
+
+// Generate builds the table for signature, such as "KQK" or "KBNK", by
+// retrograde analysis. The tables reached by captures and promotions are
+// generated first and kept in the set, since the table needs their values.
+// Nothing is done if the set already has the table.
+func (tbs *Tablebases) Generate(signature string) error {
+	whitePieces, blackPieces, err := parseSignature(signature)
+	if err == nil && len(whitePieces)+len(blackPieces)+2 > tbMaxPieces {
+		err = fmt.Errorf("Can't generate %s, tables are limited to %d pieces", signature, tbMaxPieces)
+	}
+	if err == nil {
+		if table, _ := tbs.locate(whitePieces, blackPieces); table == nil {
+			for _, child := range childSignatures(whitePieces, blackPieces) {
+				if err == nil {
+					err = tbs.Generate(child)
+				}
+			}
+			if err == nil {
+				table = newTablebase(whitePieces, blackPieces)
+				err = tbs.solve(table)
+			}
+			if err == nil {
+				tbs.Add(table)
+			}
+		}
+	}
+	return err
+}
+
+// Probe looks up the current position. Positions with castling rights
+// aren't covered by the tables, and en passant captures are ignored.
+func (tbs *Tablebases) Probe(chess *Chess) (TablebaseResult, error) {
+	var retVal TablebaseResult
+	var err error
+
+	if chess.castling[white] != 0 || chess.castling[black] != 0 {
+		err = fmt.Errorf("Tablebases don't cover positions with castling rights")
+	}
+	var pieces []Piece
+	var squares []int
+	if err == nil {
+		for cntr := squareNameToID["a8"]; cntr <= squareNameToID["h1"]; cntr++ {
+			if cntr&0x88 != 0 {
+				cntr += 7
+				continue
+			}
+			if !chess.board[cntr].IsUnspecified() {
+				pieces = append(pieces, chess.board[cntr])
+				squares = append(squares, cntr)
+			}
+		}
+	}
+	if err == nil {
+		value, found := tbs.value(pieces, squares, chess.turn)
+		if !found {
+			err = fmt.Errorf("No table for %s", materialSignature(pieces))
+		} else if value == tbInvalid {
+			err = fmt.Errorf("%s is not a legal position", chess.GenerateFen())
+		} else {
+			retVal = tablebaseResult(value)
+		}
+	}
+	return retVal, err
+}
+
+// BestMove returns the move the tables say is best for the side to move:
+// the fastest mate when winning, a move that holds the draw when drawing and
+// the longest resistance when losing. The result is the probe of the
+// current position.
+func (tbs *Tablebases) BestMove(chess *Chess) (Move, TablebaseResult, error) {
+	var retVal Move
+	result, err := tbs.Probe(chess)
+
+	found := false
+	bestRank := 0
+	if err == nil {
+		for _, move := range chess.Moves(true, "") {
+			chess.makeMove(move)
+			childResult, childErr := tbs.Probe(chess)
+			chess.Undo()
+			if childErr != nil {
+				err = childErr
+				break
+			}
+			rank := moveRank(childResult)
+			if !found || rank > bestRank {
+				retVal = move
+				bestRank = rank
+				found = true
+			}
+		}
+	}
+	if err == nil && !found {
+		err = fmt.Errorf("There are no legal moves in %s", chess.GenerateFen())
+	}
+	return retVal, result, err
+}
+
+// ReadTablebase reads a table written by Tablebase.Write
+func ReadTablebase(reader io.Reader) (*Tablebase, error) {
+	var retVal *Tablebase
+	var signature string
+	var data []byte
+
+	unzipped, err := gzip.NewReader(reader)
+	if err == nil {
+		defer unzipped.Close()
+		buffered := bufio.NewReader(unzipped)
+		var header string
+		header, err = buffered.ReadString('\n')
+		fields := strings.Fields(header)
+		if err == nil && (len(fields) != 2 || fields[0] != tbFileMagic) {
+			err = fmt.Errorf("Not a tablebase file")
+		}
+		if err == nil {
+			signature = fields[1]
+			data, err = io.ReadAll(buffered)
+		}
+	}
+	var whitePieces, blackPieces string
+	if err == nil {
+		whitePieces, blackPieces, err = parseSignature(signature)
+	}
+	if err == nil {
+		retVal = newTablebase(whitePieces, blackPieces)
+		if len(data) != retVal.size() {
+			err = fmt.Errorf("%s table has %d values, expected %d", signature, len(data), retVal.size())
+			retVal = nil
+		} else {
+			retVal.values = data
+		}
+	}
+	return retVal, err
+}
+
+// Write writes the table gzipped, with a short header naming its signature
+func (table *Tablebase) Write(writer io.Writer) error {
+	zipped := gzip.NewWriter(writer)
+	_, err := fmt.Fprintf(zipped, "%s %s\n", tbFileMagic, table.signature)
+	if err == nil {
+		_, err = zipped.Write(table.values)
+	}
+	if closeErr := zipped.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// Signature returns the material the table covers, white's pieces first
+func (table *Tablebase) Signature() string {
+	return table.signature
+}
+
+// MaxDTM returns the longest win in the table, in plies, for the side to move
+func (table *Tablebase) MaxDTM(turn PieceColor) int {
+	retVal := 0
+	for index, value := range table.values {
+		if (index&1 == 0) == (turn == white) && value != tbInvalid && value != tbDraw {
+			if plies := int(value) - 1; plies%2 == 1 && plies > retVal {
+				retVal = plies
+			}
+		}
+	}
+	return retVal
+}
+
+func newTablebase(whitePieces string, blackPieces string) *Tablebase {
+	retVal := &Tablebase{signature: "K" + whitePieces + "K" + blackPieces}
+	for _, side := range []struct {
+		color  PieceColor
+		pieces string
+	}{{white, "K" + whitePieces}, {black, "K" + blackPieces}} {
+		for _, symbol := range side.pieces {
+			retVal.pieces = append(retVal.pieces, Piece{ptype: PieceType(symbol - 'A' + 'a'), pcolor: side.color})
+		}
+	}
+	return retVal
+}
+
+// size is the number of positions in the table: each piece on every square, with either side to move
+func (table *Tablebase) size() int {
+	return 2 << uint(6*len(table.pieces))
+}
+
+// index returns where the position is in the table. The pieces can be in
+// any order. With flip set the position is first mirrored top to bottom
+// with the colors swapped.
+func (table *Tablebase) index(pieces []Piece, squares []int, turn PieceColor, flip bool) (int, bool) {
+	retVal := 0
+	if turn == black {
+		retVal = 1
+	}
+	if flip {
+		retVal ^= 1
+	}
+	used := make([]bool, len(table.pieces))
+	found := len(pieces) == len(table.pieces)
+	for cntr := 0; found && cntr < len(pieces); cntr++ {
+		piece := pieces[cntr]
+		square := squares[cntr]
+		if flip {
+			piece.pcolor = swapColor(piece.pcolor)
+			square ^= 0x70
+		}
+		found = false
+		for slot, slotPiece := range table.pieces {
+			if !used[slot] && slotPiece == piece {
+				used[slot] = true
+				retVal |= squareToIndex(square) << uint(6*slot+1)
+				found = true
+				break
+			}
+		}
+	}
+	return retVal, found
+}
+
+// locate finds the table covering the material and whether the position has to be flipped to use it
+func (tbs *Tablebases) locate(whitePieces string, blackPieces string) (*Tablebase, bool) {
+	retVal, flip := tbs.tables["K"+whitePieces+"K"+blackPieces], false
+	if retVal == nil {
+		retVal, flip = tbs.tables["K"+blackPieces+"K"+whitePieces], true
+	}
+	return retVal, flip
+}
+
+// value returns the table value of a position given as a list of pieces and their squares
+func (tbs *Tablebases) value(pieces []Piece, squares []int, turn PieceColor) (uint8, bool) {
+	var retVal uint8
+	whitePieces, blackPieces := sideMaterial(pieces)
+	table, flip := tbs.locate(whitePieces, blackPieces)
+	found := table != nil
+	if found {
+		var index int
+		index, found = table.index(pieces, squares, turn, flip)
+		if found {
+			retVal = table.values[index]
+		}
+	}
+	return retVal, found
+}
+
+// solve fills in the table. Every position is classified once, going
+// forwards: mates, stalemates and the moves that leave the table, through
+// a capture or promotion, are scored from the tables already generated.
+// Then results are spread backwards a ply at a time by un-making moves, so
+// each position is reached first by its shortest mate.
+func (tbs *Tablebases) solve(table *Tablebase) error {
+	var err error
+	size := table.size()
+	table.values = make([]uint8, size)
+	// remaining counts the moves not yet known to lose, and exitLoss the
+	// longest of the losing moves that leave the table
+	remaining := make([]uint8, size)
+	exitLoss := make([]uint8, size)
+	var levels [][]int32
+	push := func(plies int, index int) {
+		if plies > tbMaxPly {
+			err = fmt.Errorf("%s has a mate longer than %d plies", table.signature, tbMaxPly)
+			return
+		}
+		for len(levels) <= plies {
+			levels = append(levels, nil)
+		}
+		levels[plies] = append(levels[plies], int32(index))
+	}
+
+	board := newTBBoard(table.pieces)
+	childPieces := make([]Piece, 0, len(table.pieces))
+	childSquares := make([]int, 0, len(table.pieces))
+	for index := 0; index < size; index++ {
+		if !board.decode(index) {
+			table.values[index] = tbInvalid
+			continue
+		}
+		legalMoves := 0
+		unrefuted := 0
+		board.legalMoves(func(move tbMove) {
+			legalMoves++
+			if move.captured < 0 && move.promotion == 0 {
+				unrefuted++
+				return
+			}
+			childPieces, childSquares = board.after(move, childPieces[:0], childSquares[:0])
+			value, _ := tbs.value(childPieces, childSquares, swapColor(board.turn))
+			if value == tbDraw || value == tbInvalid {
+				unrefuted++
+			} else if plies := int(value) - 1; plies%2 == 0 {
+				// The opponent gets mated, so this is a win unless a faster one turns up
+				unrefuted++
+				push(plies+1, index)
+			} else if uint8(plies) > exitLoss[index] {
+				exitLoss[index] = uint8(plies)
+			}
+		})
+
+		if legalMoves == 0 {
+			if board.inCheck() {
+				table.values[index] = 1
+				push(0, index)
+			}
+		} else if unrefuted == 0 {
+			table.values[index] = exitLoss[index] + 2
+			push(int(exitLoss[index])+1, index)
+		} else {
+			remaining[index] = uint8(unrefuted)
+		}
+	}
+
+	for plies := 0; err == nil && plies < len(levels); plies++ {
+		for _, index := range levels[plies] {
+			if table.values[index] == tbDraw {
+				table.values[index] = uint8(plies + 1)
+			} else if table.values[index] != uint8(plies+1) || remaining[index] == tbDone {
+				// Already reached by a faster mate, or queued twice at this ply
+				continue
+			}
+			remaining[index] = tbDone
+			board.decode(int(index))
+			board.unmoves(int(index), func(previous int) {
+				if table.values[previous] != tbDraw {
+					return
+				}
+				if plies%2 == 0 {
+					// Moving here mates the opponent
+					table.values[previous] = uint8(plies + 2)
+					push(plies+1, previous)
+				} else {
+					remaining[previous]--
+					if remaining[previous] == 0 {
+						loss := plies
+						if int(exitLoss[previous]) > loss {
+							loss = int(exitLoss[previous])
+						}
+						table.values[previous] = uint8(loss + 2)
+						push(loss+1, previous)
+					}
+				}
+			})
+		}
+		levels[plies] = nil
+	}
+	return err
+}
+
+// tbMove is a move in a tbBoard. captured is the slot of the piece taken, or -1.
+type tbMove struct {
+	slot      int
+	to        int
+	promotion PieceType
+	captured  int
+}
+
+// tbBoard is a lightweight board for generating tables, with the pieces
+// kept in a fixed list of slots
+type tbBoard struct {
+	pieces   []Piece
+	squares  []int
+	masks    []int
+	occupant [128]int
+	kings    [2]int
+	turn     PieceColor
+}
+
+func newTBBoard(pieces []Piece) *tbBoard {
+	retVal := &tbBoard{pieces: pieces, squares: make([]int, len(pieces)), masks: make([]int, len(pieces))}
+	for slot, piece := range pieces {
+		retVal.masks[slot] = 1 << shifts[piece.ptype]
+		if piece.ptype == king {
+			retVal.kings[colorIndex(piece.pcolor)] = slot
+		}
+	}
+	return retVal
+}
+
+// decode sets the board up as the position at index, returning false if the position can't happen
+func (board *tbBoard) decode(index int) bool {
+	for _, square := range board.squares {
+		if square != emptySquare {
+			board.occupant[square] = 0
+		}
+	}
+	board.turn = white
+	if index&1 != 0 {
+		board.turn = black
+	}
+
+	retVal := true
+	for slot := range board.squares {
+		square := indexToSquare((index >> uint(6*slot+1)) & 63)
+		board.squares[slot] = square
+		if board.occupant[square] != 0 {
+			retVal = false
+			board.squares[slot] = emptySquare
+			continue
+		}
+		board.occupant[square] = slot + 1
+		if board.pieces[slot].ptype == pawn && (rank(square) == rank1 || rank(square) == rank8) {
+			retVal = false
+		}
+	}
+	// The side that just moved can't be left in check
+	if retVal {
+		retVal = !board.attacked(board.turn, board.squares[board.kings[colorIndex(swapColor(board.turn))]])
+	}
+	return retVal
+}
+
+// attacked returns true if any piece of colorAttacking attacks the square
+func (board *tbBoard) attacked(colorAttacking PieceColor, target int) bool {
+	retVal := false
+	for slot := 0; !retVal && slot < len(board.squares); slot++ {
+		square := board.squares[slot]
+		piece := board.pieces[slot]
+		if square == emptySquare || piece.pcolor != colorAttacking {
+			continue
+		}
+		difference := square - target
+		index := difference + 119
+		if attacks[index]&board.masks[slot] == 0 {
+			continue
+		}
+		switch piece.ptype {
+		case pawn:
+			retVal = (difference > 0) == (piece.pcolor == white)
+		case knight, king:
+			retVal = true
+		default:
+			offset := rays[index]
+			between := square + offset
+			for between != target && board.occupant[between] == 0 {
+				between += offset
+			}
+			retVal = between == target
+		}
+	}
+	return retVal
+}
+
+func (board *tbBoard) inCheck() bool {
+	return board.attacked(swapColor(board.turn), board.squares[board.kings[colorIndex(board.turn)]])
+}
+
+// legalMoves calls visit with each legal move of the side to move
+func (board *tbBoard) legalMoves(visit func(tbMove)) {
+	for slot, piece := range board.pieces {
+		from := board.squares[slot]
+		if piece.pcolor != board.turn {
+			continue
+		}
+		if piece.ptype == pawn {
+			push := pawnOffsets[piece.pcolor][0]
+			if board.occupant[from+push] == 0 {
+				board.tryMove(slot, from+push, visit)
+				if rank(from) == secondRank[piece.pcolor] && board.occupant[from+2*push] == 0 {
+					board.tryMove(slot, from+2*push, visit)
+				}
+			}
+			for _, offset := range pawnOffsets[piece.pcolor][2:] {
+				to := from + offset
+				if to&0x88 == 0 && board.occupant[to] != 0 && board.pieces[board.occupant[to]-1].pcolor != piece.pcolor {
+					board.tryMove(slot, to, visit)
+				}
+			}
+			continue
+		}
+		for _, offset := range pieceOffsets[piece.ptype] {
+			for to := from + offset; to&0x88 == 0; to += offset {
+				if board.occupant[to] != 0 {
+					if board.pieces[board.occupant[to]-1].pcolor != piece.pcolor {
+						board.tryMove(slot, to, visit)
+					}
+					break
+				}
+				board.tryMove(slot, to, visit)
+				if piece.ptype == knight || piece.ptype == king {
+					break
+				}
+			}
+		}
+	}
+}
+
+// tryMove calls visit with the move, or each of its promotions, if it doesn't leave the mover in check
+func (board *tbBoard) tryMove(slot int, to int, visit func(tbMove)) {
+	from := board.squares[slot]
+	move := tbMove{slot: slot, to: to, captured: board.occupant[to] - 1}
+
+	board.occupant[from] = 0
+	board.occupant[to] = slot + 1
+	board.squares[slot] = to
+	if move.captured >= 0 {
+		board.squares[move.captured] = emptySquare
+	}
+	legal := !board.inCheck()
+	board.squares[slot] = from
+	board.occupant[from] = slot + 1
+	board.occupant[to] = move.captured + 1
+	if move.captured >= 0 {
+		board.squares[move.captured] = to
+	}
+
+	if legal {
+		if board.pieces[slot].ptype == pawn && (rank(to) == rank1 || rank(to) == rank8) {
+			for _, promotion := range promotionTypes {
+				move.promotion = promotion
+				visit(move)
+			}
+		} else {
+			visit(move)
+		}
+	}
+}
+
+// after appends the pieces and squares of the position after move
+func (board *tbBoard) after(move tbMove, pieces []Piece, squares []int) ([]Piece, []int) {
+	for slot, piece := range board.pieces {
+		square := board.squares[slot]
+		if slot == move.captured {
+			continue
+		}
+		if slot == move.slot {
+			square = move.to
+			if move.promotion != 0 {
+				piece.ptype = move.promotion
+			}
+		}
+		pieces = append(pieces, piece)
+		squares = append(squares, square)
+	}
+	return pieces, squares
+}
+
+// unmoves calls visit with the index of every position in the table that
+// reaches this one with a single move. Captures and promotions come from
+// other tables so they aren't un-made.
+func (board *tbBoard) unmoves(index int, visit func(int)) {
+	mover := swapColor(board.turn)
+	for slot, piece := range board.pieces {
+		to := board.squares[slot]
+		if piece.pcolor != mover {
+			continue
+		}
+		shift := uint(6*slot + 1)
+		without := (index ^ 1) &^ (63 << shift)
+		if piece.ptype == pawn {
+			push := pawnOffsets[mover][0]
+			from := to - push
+			if board.occupant[from] == 0 && rank(from) != rank1 && rank(from) != rank8 {
+				visit(without | squareToIndex(from)<<shift)
+				if rank(to-2*push) == secondRank[mover] && board.occupant[to-2*push] == 0 {
+					visit(without | squareToIndex(to-2*push)<<shift)
+				}
+			}
+			continue
+		}
+		for _, offset := range pieceOffsets[piece.ptype] {
+			for from := to - offset; from&0x88 == 0 && board.occupant[from] == 0; from -= offset {
+				visit(without | squareToIndex(from)<<shift)
+				if piece.ptype == knight || piece.ptype == king {
+					break
+				}
+			}
+		}
+	}
+}
+
+func tablebaseResult(value uint8) TablebaseResult {
+	var retVal TablebaseResult
+	if value != tbDraw {
+		retVal.DTM = int(value) - 1
+		retVal.WDL = TablebaseLoss
+		if retVal.DTM%2 == 1 {
+			retVal.WDL = TablebaseWin
+		}
+	}
+	return retVal
+}
+
+// moveRank scores a move by the result it leaves the opponent with, higher
+// being better for the mover: quick mates, then draws, then slow losses
+func moveRank(opponent TablebaseResult) int {
+	retVal := 0
+	switch opponent.WDL {
+	case TablebaseLoss:
+		retVal = 1000 - opponent.DTM
+	case TablebaseWin:
+		retVal = -1000 + opponent.DTM
+	}
+	return retVal
+}
+
+// parseSignature splits a signature such as KRKN into each side's pieces
+// other than the king, in tbPieceOrder
+func parseSignature(signature string) (string, string, error) {
+	var err error
+	var whitePieces, blackPieces string
+	match := tbSignatureRegexp.FindStringSubmatch(strings.ToUpper(signature))
+	if match == nil {
+		err = fmt.Errorf("Invalid endgame signature '%s'", signature)
+	} else {
+		whitePieces = sortMaterial(match[1])
+		blackPieces = sortMaterial(match[2])
+	}
+	return whitePieces, blackPieces, err
+}
+
+func sortMaterial(pieces string) string {
+	symbols := []byte(pieces)
+	sort.Slice(symbols, func(i, j int) bool {
+		return strings.IndexByte(tbPieceOrder, symbols[i]) < strings.IndexByte(tbPieceOrder, symbols[j])
+	})
+	return string(symbols)
+}
+
+// sideMaterial returns each side's pieces other than the king, in tbPieceOrder
+func sideMaterial(pieces []Piece) (string, string) {
+	var whitePieces, blackPieces []byte
+	for _, piece := range pieces {
+		if piece.ptype == king {
+			continue
+		}
+		symbol := byte(piece.ptype) - 'a' + 'A'
+		if piece.pcolor == white {
+			whitePieces = append(whitePieces, symbol)
+		} else {
+			blackPieces = append(blackPieces, symbol)
+		}
+	}
+	return sortMaterial(string(whitePieces)), sortMaterial(string(blackPieces))
+}
+
+func materialSignature(pieces []Piece) string {
+	whitePieces, blackPieces := sideMaterial(pieces)
+	return "K" + whitePieces + "K" + blackPieces
+}
+
+// childSignatures returns the signatures a capture or promotion can lead to
+func childSignatures(whitePieces string, blackPieces string) []string {
+	var retVal []string
+	without := func(pieces string, cntr int) string {
+		return pieces[:cntr] + pieces[cntr+1:]
+	}
+	sides := [2]string{whitePieces, blackPieces}
+	for side := 0; side < 2; side++ {
+		ours, theirs := sides[side], sides[1-side]
+		signature := func(ours string, theirs string) string {
+			if side == 1 {
+				ours, theirs = theirs, ours
+			}
+			return "K" + ours + "K" + theirs
+		}
+		for cntr := range theirs {
+			retVal = append(retVal, signature(ours, without(theirs, cntr)))
+		}
+		if pawn := strings.IndexByte(ours, 'P'); pawn >= 0 {
+			for _, promotion := range "QRBN" {
+				promoted := without(ours, pawn) + string(promotion)
+				retVal = append(retVal, signature(promoted, theirs))
+				for cntr := range theirs {
+					retVal = append(retVal, signature(promoted, without(theirs, cntr)))
+				}
+			}
+		}
+	}
+	return retVal
+}
+
+// squareToIndex converts a 0x88 square into 0 to 63, a8 first
+func squareToIndex(square int) int {
+	return rank(square)*8 + file(square)
+}
+
+func indexToSquare(index int) int {
+	return (index/8)*16 + index%8
+}
@@ -0,0 +1,258 @@
+package chess
+
+import (
+	"bytes"
+	"math/rand"
+	"os"
+	"testing"
+)
+
+// Generating is the slow part, so the tests share one set
+var testTablebases *Tablebases
+
+func tablebasesForTest(t *testing.T) *Tablebases {
+	if testTablebases == nil {
+		testTablebases = NewTablebases()
+		if err := testTablebases.Generate("KPK"); err != nil {
+			t.Fatalf("Got an error %v", err)
+		}
+	}
+	return testTablebases
+}
+
+func probeFen(t *testing.T, tbs *Tablebases, fen string) TablebaseResult {
+	chess := New()
+	chess.Load(fen)
+	result, err := tbs.Probe(chess)
+	if err != nil {
+		t.Fatalf("Got an error %v probing %s", err, fen)
+	}
+	return result
+}
+
+func TestGenerateIncludesChildTables(t *testing.T) {
+	tbs := tablebasesForTest(t)
+	for _, signature := range []string{"KK", "KPK", "KQK", "KRK", "KBK", "KNK"} {
+		if _, ok := tbs.Table(signature); !ok {
+			t.Errorf("Expected a %s table", signature)
+		}
+	}
+	if _, ok := tbs.Table("KKQ"); !ok {
+		t.Errorf("Expected KKQ to be found as KQK")
+	}
+}
+
+func TestTablebaseLongestMates(t *testing.T) {
+	tbs := tablebasesForTest(t)
+	// The longest mates are 10 moves with a queen, 16 with a rook and 28 with a pawn
+	expected := map[string]int{"KQK": 19, "KRK": 31, "KPK": 55, "KBK": 0}
+	for signature, plies := range expected {
+		table, _ := tbs.Table(signature)
+		if table.MaxDTM(white) != plies {
+			t.Errorf("Expected the longest %s mate to be %d plies, got %d", signature, plies, table.MaxDTM(white))
+		}
+	}
+}
+
+func TestTablebaseProbe(t *testing.T) {
+	tbs := tablebasesForTest(t)
+	tests := []struct {
+		fen    string
+		result TablebaseResult
+	}{
+		{"k7/1Q6/1K6/8/8/8/8/8 b - - 0 1", TablebaseResult{TablebaseLoss, 0}},
+		{"k7/6Q1/1K6/8/8/8/8/8 w - - 0 1", TablebaseResult{TablebaseWin, 1}},
+		{"k7/8/1Q6/8/8/8/8/7K b - - 0 1", TablebaseResult{TablebaseDraw, 0}},
+		// Black has the queen, so KKQ is looked up in the KQK table
+		{"K7/1q6/1k6/8/8/8/8/8 w - - 0 1", TablebaseResult{TablebaseLoss, 0}},
+		// Stalemate, and the king in front of its pawn on the sixth rank
+		{"4k3/4P3/4K3/8/8/8/8/8 b - - 0 1", TablebaseResult{TablebaseDraw, 0}},
+		{"4k3/8/4K3/4P3/8/8/8/8 b - - 0 1", TablebaseResult{TablebaseLoss, 0}},
+		{"7k/8/8/8/8/8/P7/K7 w - - 0 1", TablebaseResult{TablebaseWin, 0}},
+		{"k7/8/8/8/8/8/7P/7K w - - 0 1", TablebaseResult{TablebaseWin, 0}},
+		{"k7/8/K7/P7/8/8/8/8 w - - 0 1", TablebaseResult{TablebaseDraw, 0}},
+	}
+	for _, test := range tests {
+		result := probeFen(t, tbs, test.fen)
+		// Only check the distance where it's given
+		if result.WDL != test.result.WDL || (test.result.DTM != 0 && result.DTM != test.result.DTM) {
+			t.Errorf("Expected %v for %s, got %v", test.result, test.fen, result)
+		}
+	}
+}
+
+func TestTablebaseProbeErrors(t *testing.T) {
+	tbs := tablebasesForTest(t)
+	chess := New()
+	if _, err := tbs.Probe(chess); err == nil {
+		t.Errorf("Expected an error for the starting position")
+	}
+	chess.Load("kR6/8/8/8/8/8/8/K7 w - - 0 1")
+	if _, err := tbs.Probe(chess); err == nil {
+		t.Errorf("Expected an error for a position in check with the other side to move")
+	}
+	if err := tbs.Generate("KQRBK"); err == nil {
+		t.Errorf("Expected an error for too many pieces")
+	}
+	if err := tbs.Generate("KXK"); err == nil {
+		t.Errorf("Expected an error for a bad signature")
+	}
+}
+
+func TestTablebaseAgreesWithCheckmateAndStalemate(t *testing.T) {
+	tbs := tablebasesForTest(t)
+	random := rand.New(rand.NewSource(1))
+	pieces := []Piece{{king, white}, {queen, white}, {king, black}}
+	checked := 0
+	for checked < 2000 {
+		chess := New()
+		chess.Clear()
+		for cntr, square := range random.Perm(64)[:len(pieces)] {
+			chess.Put(pieces[cntr], algebraic(indexToSquare(square)))
+		}
+		if random.Intn(2) == 1 {
+			chess.turn = black
+		}
+		if chess.kingAttacked(swapColor(chess.turn)) {
+			continue
+		}
+		result, err := tbs.Probe(chess)
+		if err != nil {
+			t.Fatalf("Got an error %v probing %s", err, chess.GenerateFen())
+		}
+		checked++
+		mated := result.WDL == TablebaseLoss && result.DTM == 0
+		if mated != chess.InCheckmate() {
+			t.Errorf("Checkmate mismatch for %s: %v", chess.GenerateFen(), result)
+		}
+		if chess.InStalemate() && result.WDL != TablebaseDraw {
+			t.Errorf("Stalemate mismatch for %s: %v", chess.GenerateFen(), result)
+		}
+	}
+}
+
+// TestTBBoardMatchesChess checks the generator's own board against the
+// game's rules in every position of KQK, for the sliders, and KPK, for pawn
+// pushes and promotions: the same legal moves and the same checks, so the
+// same mates and stalemates, and every move un-made from the position it
+// leads to. Knights and kings are in both.
+func TestTBBoardMatchesChess(t *testing.T) {
+	chess := new(Chess)
+	chess.Clear()
+	key := func(from int, to int, promotion PieceType) int {
+		return from<<16 | to<<8 | int(promotion)
+	}
+	for _, signature := range []string{"KQK", "KPK"} {
+		whitePieces, blackPieces, _ := parseSignature(signature)
+		table := newTablebase(whitePieces, blackPieces)
+		board := newTBBoard(table.pieces)
+		child := newTBBoard(table.pieces)
+		failures := 0
+		expected, found := make(map[int]bool), make(map[int]bool)
+		for index := 0; index < table.size() && failures < 10; index++ {
+			if !board.decode(index) {
+				continue
+			}
+			chess.board = make([]Piece, 128)
+			chess.turn = board.turn
+			for slot, square := range board.squares {
+				chess.board[square] = board.pieces[slot]
+				if board.pieces[slot].ptype == king {
+					chess.kings[board.pieces[slot].pcolor] = square
+				}
+			}
+
+			clear(expected)
+			for _, move := range chess.Moves(true, "") {
+				expected[key(move.from, move.to, move.promotedType)] = true
+			}
+			clear(found)
+			board.legalMoves(func(move tbMove) {
+				found[key(board.squares[move.slot], move.to, move.promotion)] = true
+				if move.captured >= 0 || move.promotion != 0 {
+					return
+				}
+				// The retrograde pass has to find its way back here
+				var pieces []Piece
+				var squares []int
+				pieces, squares = board.after(move, pieces, squares)
+				next, _ := table.index(pieces, squares, swapColor(board.turn), false)
+				child.decode(next)
+				unmade := false
+				child.unmoves(next, func(previous int) { unmade = unmade || previous == index })
+				if !unmade {
+					t.Errorf("%s: %s%s in %s isn't un-made", signature, algebraic(board.squares[move.slot]), algebraic(move.to), chess.GenerateFen())
+					failures++
+				}
+			})
+			if len(found) != len(expected) || board.inCheck() != chess.InCheck() {
+				t.Errorf("%s: expected %v, got %v in %s", signature, expected, found, chess.GenerateFen())
+				failures++
+				continue
+			}
+			for move := range expected {
+				if !found[move] {
+					t.Errorf("%s: missing %x in %s", signature, move, chess.GenerateFen())
+					failures++
+				}
+			}
+		}
+	}
+}
+
+func TestTablebaseBestMovePlaysPerfectly(t *testing.T) {
+	tbs := tablebasesForTest(t)
+	for _, fen := range []string{"8/8/8/3k4/8/8/8/KQ6 w - - 0 1", "4k3/8/4K3/4P3/8/8/8/8 w - - 0 1"} {
+		chess := New()
+		chess.Load(fen)
+		start := probeFen(t, tbs, fen)
+		if start.WDL != TablebaseWin {
+			t.Fatalf("Expected %s to be a win, got %v", fen, start)
+		}
+
+		plies := 0
+		for !chess.InCheckmate() && plies <= start.DTM {
+			move, result, err := tbs.BestMove(chess)
+			if err != nil {
+				t.Fatalf("Got an error %v", err)
+			}
+			if result.DTM != start.DTM-plies {
+				t.Errorf("Expected %d plies to mate, got %d at %s", start.DTM-plies, result.DTM, chess.GenerateFen())
+			}
+			chess.MakeMove(move)
+			plies++
+		}
+		if !chess.InCheckmate() || plies != start.DTM {
+			t.Errorf("Expected mate in %d plies from %s, got %d ending at %s", start.DTM, fen, plies, chess.GenerateFen())
+		}
+	}
+}
+
+func TestTablebaseSaveAndLoad(t *testing.T) {
+	tbs := tablebasesForTest(t)
+	dir, err := os.MkdirTemp("", "tablebases")
+	if err != nil {
+		t.Fatalf("Got an error %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err = tbs.Save(dir); err != nil {
+		t.Fatalf("Got an error %v", err)
+	}
+	loaded, err := LoadTablebases(dir)
+	if err != nil {
+		t.Fatalf("Got an error %v", err)
+	}
+	if len(loaded.Signatures()) != len(tbs.Signatures()) {
+		t.Errorf("Expected %v, got %v", tbs.Signatures(), loaded.Signatures())
+	}
+	original, _ := tbs.Table("KQK")
+	copied, _ := loaded.Table("KQK")
+	if !bytes.Equal(original.values, copied.values) {
+		t.Errorf("Expected the KQK values to survive a round trip")
+	}
+
+	if _, err = ReadTablebase(bytes.NewReader([]byte("not a table"))); err == nil {
+		t.Errorf("Expected an error reading junk")
+	}
+}
@@ -0,0 +1,113 @@
+package chess
+
+import "sync/atomic"
+
+// Bounds stored with a transposition table score
+const (
+	exactBound = 1
+	lowerBound = 2
+	upperBound = 3
+)
+
+const ttEntrySize = 16
+
+var promotionCodes = map[PieceType]uint64{knight: 1, bishop: 2, rook: 3, queen: 4}
+var promotionFromCode = []PieceType{0, knight, bishop, rook, queen}
+
+// ttEntry holds the position's hash XORed with its data, so a torn write by
+// another thread shows up as a hash mismatch instead of bad data. That lets
+// every search thread share the table without locking.
+type ttEntry struct {
+	check uint64
+	data  uint64
+}
+
+type transpositionTable struct {
+	entries []ttEntry
+	mask    uint64
+}
+
+// ttData is the unpacked form of ttEntry.data
+type ttData struct {
+	from      int
+	to        int
+	promotion PieceType
+	score     int
+	depth     int
+	bound     int
+}
+
+func newTranspositionTable(sizeMB int) *transpositionTable {
+	if sizeMB < 1 {
+		sizeMB = 1
+	}
+	// Round down to a power of two so the hash can be masked into an index
+	count := uint64(1)
+	for count*2*ttEntrySize <= uint64(sizeMB)<<20 {
+		count *= 2
+	}
+	return &transpositionTable{entries: make([]ttEntry, count), mask: count - 1}
+}
+
+func (tt *transpositionTable) probe(hash uint64) (ttData, bool) {
+	var retVal ttData
+	entry := &tt.entries[hash&tt.mask]
+	check := atomic.LoadUint64(&entry.check)
+	data := atomic.LoadUint64(&entry.data)
+	found := data != 0 && check^data == hash
+	if found {
+		retVal = unpackTTData(data)
+	}
+	return retVal, found
+}
+
+func (tt *transpositionTable) store(hash uint64, data ttData) {
+	entry := &tt.entries[hash&tt.mask]
+	oldCheck := atomic.LoadUint64(&entry.check)
+	oldData := atomic.LoadUint64(&entry.data)
+	// Keep deeper results for the same position unless this one is exact
+	if oldCheck^oldData == hash && int((oldData>>33)&0xff) > data.depth && data.bound != exactBound {
+		return
+	}
+	packed := packTTData(data)
+	atomic.StoreUint64(&entry.check, hash^packed)
+	atomic.StoreUint64(&entry.data, packed)
+}
+
+func (tt *transpositionTable) clear() {
+	for cntr := range tt.entries {
+		atomic.StoreUint64(&tt.entries[cntr].check, 0)
+		atomic.StoreUint64(&tt.entries[cntr].data, 0)
+	}
+}
+
+// Packed as move from (7 bits), to (7), promotion (3), score + 32768 (16),
+// depth (8) and bound (2)
+func packTTData(data ttData) uint64 {
+	retVal := uint64(data.from) |
+		uint64(data.to)<<7 |
+		promotionCodes[data.promotion]<<14 |
+		uint64(data.score+32768)<<17 |
+		uint64(data.depth)<<33 |
+		uint64(data.bound)<<41
+	return retVal
+}
+
+func unpackTTData(packed uint64) ttData {
+	return ttData{
+		from:      int(packed & 0x7f),
+		to:        int((packed >> 7) & 0x7f),
+		promotion: promotionFromCode[(packed>>14)&0x7],
+		score:     int((packed>>17)&0xffff) - 32768,
+		depth:     int((packed >> 33) & 0xff),
+		bound:     int((packed >> 41) & 0x3)}
+}
+
+// matches returns true if move is the move stored in the entry
+func (data ttData) matches(move Move) bool {
+	retVal := data.from == move.from && data.to == move.to
+	if retVal && move.flags&promotionMove != 0 {
+		retVal = data.promotion == move.promotedType
+	}
+	return retVal
+}
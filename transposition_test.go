@@ -0,0 +1,73 @@
+package chess
+
+import "testing"
+
+func TestTTDataRoundTrips(t *testing.T) {
+	data := ttData{from: squareNameToID["e7"], to: squareNameToID["e8"], promotion: knight, score: -29990, depth: 12, bound: lowerBound}
+	actual := unpackTTData(packTTData(data))
+	if actual != data {
+		t.Errorf("Expected %v, got %v", data, actual)
+	}
+}
+
+func TestTTProbeVerifiesHash(t *testing.T) {
+	tt := newTranspositionTable(1)
+	hash := uint64(0x1234567890abcdef)
+	data := ttData{from: 1, to: 2, score: 35, depth: 4, bound: exactBound}
+	tt.store(hash, data)
+
+	if actual, ok := tt.probe(hash); !ok || actual != data {
+		t.Errorf("Expected %v, got %v", data, actual)
+	}
+	// Same slot, different position
+	if _, ok := tt.probe(hash ^ (tt.mask + 1)); ok {
+		t.Errorf("Expected a miss for a different hash")
+	}
+
+	// A torn write leaves check and data out of step
+	tt.entries[hash&tt.mask].data ^= 1 << 20
+	if _, ok := tt.probe(hash); ok {
+		t.Errorf("Expected a corrupted entry to be rejected")
+	}
+}
+
+func TestTTKeepsDeeperEntries(t *testing.T) {
+	tt := newTranspositionTable(1)
+	tt.store(99, ttData{from: 1, to: 2, score: 10, depth: 8, bound: lowerBound})
+	tt.store(99, ttData{from: 3, to: 4, score: 20, depth: 2, bound: upperBound})
+
+	if actual, _ := tt.probe(99); actual.depth != 8 {
+		t.Errorf("Expected the depth 8 entry to survive, got %v", actual)
+	}
+}
+
+func TestHashIgnoresMoveOrderAndCounters(t *testing.T) {
+	first := New()
+	first.Move("Nf3")
+	first.Move("Nf6")
+	first.Move("Nc3")
+
+	second := New()
+	second.Move("Nc3")
+	second.Move("Nf6")
+	second.Move("Nf3")
+
+	if first.hash() != second.hash() {
+		t.Errorf("Expected transposed positions to hash the same")
+	}
+
+	chess := New()
+	other := New()
+	other.Load("rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 12 30")
+	if chess.hash() != other.hash() {
+		t.Errorf("Expected counters to be ignored")
+	}
+	other.Load("rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR b KQkq - 0 1")
+	if chess.hash() == other.hash() {
+		t.Errorf("Expected side to move to change the hash")
+	}
+	other.Load("rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w Kkq - 0 1")
+	if chess.hash() == other.hash() {
+		t.Errorf("Expected castling to change the hash")
+	}
+}
@@ -0,0 +1,436 @@
+// Package uci drives external chess engines that speak the Universal Chess
+// Interface over stdin/stdout.
+package uci
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rkitts/chess"
+)
+
+// DefaultTimeout is how long to wait for the engine to answer a command that
+// doesn't involve searching
+const DefaultTimeout = 10 * time.Second
+
+// DefaultSearchTime is how long a search limited only by depth or nodes may
+// run before the engine is told to stop, when Limits has no Deadline
+const DefaultSearchTime = time.Minute
+
+const startPosition = "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
+
+// ErrTimeout is returned when the engine doesn't answer in time
+var ErrTimeout = errors.New("uci: timed out waiting for engine")
+
+// ErrEngineExited is returned when the engine process goes away while we're waiting on it
+var ErrEngineExited = errors.New("uci: engine exited")
+
+// Option is an option the engine announced during the handshake
+type Option struct {
+	Name    string
+	Type    string
+	Default string
+	Min     int
+	Max     int
+	Vars    []string
+}
+
+// Limits tells the engine how long to search. Zero values are left out of
+// the go command. Deadline is a hard cap on our side; when it passes the
+// engine is told to stop and gets Timeout more to answer. A zero Deadline is
+// worked out from the other limits: the move time, or else the clock and
+// increment of the side to move, or else DefaultSearchTime. Infinite
+// searches have no deadline and run until Stop is called.
+type Limits struct {
+	Depth     int
+	Nodes     int64
+	MoveTime  time.Duration
+	WTime     time.Duration
+	BTime     time.Duration
+	WInc      time.Duration
+	BInc      time.Duration
+	MovesToGo int
+	Infinite  bool
+	Deadline  time.Duration
+}
+
+// Result is the outcome of a search. Lines holds the last info seen for each
+// multipv index, in multipv order.
+type Result struct {
+	BestMove    string
+	BestMoveSAN string
+	Ponder      string
+	Lines       []Info
+}
+
+// Engine is a running engine process
+type Engine struct {
+	Name    string
+	Author  string
+	Options map[string]Option
+	Timeout time.Duration
+
+	cmd     *exec.Cmd
+	stdin   io.WriteCloser
+	lines   chan string
+	waitErr error
+}
+
+// Start runs the engine at path and performs the uci handshake
+func Start(path string, args ...string) (*Engine, error) {
+	return StartCommand(exec.Command(path, args...), DefaultTimeout)
+}
+
+// StartCommand runs an already configured command as the engine and
+// performs the uci handshake, using timeout as the engine's Timeout. The
+// command's Stdin and Stdout must be unset.
+func StartCommand(cmd *exec.Cmd, timeout time.Duration) (*Engine, error) {
+	retVal := &Engine{
+		Options: make(map[string]Option),
+		Timeout: timeout,
+		cmd:     cmd,
+		lines:   make(chan string, 64)}
+
+	stdin, err := cmd.StdinPipe()
+	var stdout io.ReadCloser
+	if err == nil {
+		retVal.stdin = stdin
+		stdout, err = cmd.StdoutPipe()
+	}
+	if err == nil {
+		err = cmd.Start()
+	}
+	if err == nil {
+		go retVal.readLoop(stdout)
+		err = retVal.handshake()
+		if err != nil {
+			retVal.Kill()
+		}
+	}
+	if err != nil {
+		retVal = nil
+	}
+	return retVal, err
+}
+
+// SetOption sets one of the engine's options. Button options take an empty value.
+func (engine *Engine) SetOption(name string, value string) error {
+	command := "setoption name " + name
+	if value != "" {
+		command += " value " + value
+	}
+	err := engine.send(command)
+	if err == nil {
+		err = engine.IsReady()
+	}
+	return err
+}
+
+// IsReady sends isready and waits for readyok
+func (engine *Engine) IsReady() error {
+	err := engine.send("isready")
+	if err == nil {
+		err = engine.readUntil("readyok", engine.Timeout, nil)
+	}
+	return err
+}
+
+// NewGame tells the engine the next position is from a different game
+func (engine *Engine) NewGame() error {
+	err := engine.send("ucinewgame")
+	if err == nil {
+		err = engine.IsReady()
+	}
+	return err
+}
+
+// Position sends the game's starting position and the moves played since
+func (engine *Engine) Position(game *chess.Chess) error {
+	return engine.send(PositionCommand(game))
+}
+
+// PositionCommand builds the position command for the game's start FEN and move history
+func PositionCommand(game *chess.Chess) string {
+	var retVal strings.Builder
+
+	fen := game.InitialFen()
+	if fen == startPosition {
+		retVal.WriteString("position startpos")
+	} else {
+		retVal.WriteString("position fen " + fen)
+	}
+	history := game.History()
+	if len(history) > 0 {
+		retVal.WriteString(" moves")
+		for _, move := range history {
+			retVal.WriteString(" " + move.UCI())
+		}
+	}
+	return retVal.String()
+}
+
+// Go searches the game's current position. onInfo, if not nil, is called for
+// every info line that carries a principal variation. The PV is converted to
+// SAN on a copy of the game, so the game itself is never touched.
+func (engine *Engine) Go(game *chess.Chess, limits Limits, onInfo func(Info)) (Result, error) {
+	var retVal Result
+	copied := copyGame(game)
+
+	err := engine.Position(game)
+	if err == nil {
+		err = engine.send(goCommand(limits))
+	}
+	if err == nil {
+		lines := make(map[int]Info)
+		handler := func(line string) {
+			if strings.HasPrefix(line, "info ") {
+				info := parseInfo(line)
+				if len(info.PV) > 0 {
+					info.PVSAN = toSAN(copied, info.PV)
+					lines[info.MultiPV] = info
+					if onInfo != nil {
+						onInfo(info)
+					}
+				}
+			} else if strings.HasPrefix(line, "bestmove") {
+				retVal.BestMove, retVal.Ponder = parseBestMove(line)
+			}
+		}
+
+		err = engine.readUntil("bestmove", limits.deadline(game.Turn()), handler)
+		if err == ErrTimeout {
+			// Give the engine a chance to answer before giving up on it
+			err = engine.send("stop")
+			if err == nil {
+				err = engine.readUntil("bestmove", engine.Timeout, handler)
+			}
+		}
+		for cntr := 1; cntr <= len(lines); cntr++ {
+			if info, ok := lines[cntr]; ok {
+				retVal.Lines = append(retVal.Lines, info)
+			}
+		}
+	}
+	if err == nil {
+		if san := toSAN(copied, []string{retVal.BestMove}); len(san) == 1 {
+			retVal.BestMoveSAN = san[0]
+		}
+	}
+	return retVal, err
+}
+
+// Stop tells the engine to stop searching. The Go call in progress returns the best move found.
+func (engine *Engine) Stop() error {
+	return engine.send("stop")
+}
+
+// Quit asks the engine to exit, killing it if it doesn't do so in time
+func (engine *Engine) Quit() error {
+	err := engine.send("quit")
+	if err == nil {
+		err = engine.readUntil("", engine.Timeout, nil)
+		if err == ErrEngineExited {
+			err = nil
+		}
+	}
+	if err != nil {
+		engine.Kill()
+	}
+	return err
+}
+
+// Kill kills the engine process without asking
+func (engine *Engine) Kill() {
+	if engine.cmd.Process != nil {
+		engine.cmd.Process.Kill()
+	}
+}
+
+func (engine *Engine) handshake() error {
+	err := engine.send("uci")
+	if err == nil {
+		err = engine.readUntil("uciok", engine.Timeout, func(line string) {
+			if strings.HasPrefix(line, "id name ") {
+				engine.Name = strings.TrimPrefix(line, "id name ")
+			} else if strings.HasPrefix(line, "id author ") {
+				engine.Author = strings.TrimPrefix(line, "id author ")
+			} else if strings.HasPrefix(line, "option ") {
+				option := parseOption(line)
+				engine.Options[option.Name] = option
+			}
+		})
+	}
+	return err
+}
+
+func (engine *Engine) send(command string) error {
+	_, err := io.WriteString(engine.stdin, command+"\n")
+	if err != nil {
+		err = fmt.Errorf("%w: %v", ErrEngineExited, err)
+	}
+	return err
+}
+
+// readUntil passes each line to handler until one starts with prefix. A zero
+// timeout waits forever. An empty prefix waits for the engine to exit.
+func (engine *Engine) readUntil(prefix string, timeout time.Duration, handler func(string)) error {
+	var err error
+	var timer <-chan time.Time
+	if timeout > 0 {
+		timer = time.After(timeout)
+	}
+
+	for done := false; !done; {
+		select {
+		case line, ok := <-engine.lines:
+			if !ok {
+				err = ErrEngineExited
+				if engine.waitErr != nil {
+					err = fmt.Errorf("%w: %v", ErrEngineExited, engine.waitErr)
+				}
+				done = true
+			} else {
+				if handler != nil {
+					handler(line)
+				}
+				done = prefix != "" && strings.HasPrefix(line, prefix)
+			}
+		case <-timer:
+			err = ErrTimeout
+			done = true
+		}
+	}
+	return err
+}
+
+func (engine *Engine) readLoop(stdout io.Reader) {
+	scanner := bufio.NewScanner(stdout)
+	for scanner.Scan() {
+		engine.lines <- strings.TrimSpace(scanner.Text())
+	}
+	engine.waitErr = engine.cmd.Wait()
+	close(engine.lines)
+}
+
+func goCommand(limits Limits) string {
+	var retVal strings.Builder
+	retVal.WriteString("go")
+	if limits.Infinite {
+		retVal.WriteString(" infinite")
+	}
+	if limits.Depth > 0 {
+		retVal.WriteString(" depth " + strconv.Itoa(limits.Depth))
+	}
+	if limits.Nodes > 0 {
+		retVal.WriteString(" nodes " + strconv.FormatInt(limits.Nodes, 10))
+	}
+	if limits.MoveTime > 0 {
+		retVal.WriteString(" movetime " + millis(limits.MoveTime))
+	}
+	if limits.WTime > 0 {
+		retVal.WriteString(" wtime " + millis(limits.WTime))
+	}
+	if limits.BTime > 0 {
+		retVal.WriteString(" btime " + millis(limits.BTime))
+	}
+	if limits.WInc > 0 {
+		retVal.WriteString(" winc " + millis(limits.WInc))
+	}
+	if limits.BInc > 0 {
+		retVal.WriteString(" binc " + millis(limits.BInc))
+	}
+	if limits.MovesToGo > 0 {
+		retVal.WriteString(" movestogo " + strconv.Itoa(limits.MovesToGo))
+	}
+	return retVal.String()
+}
+
+// deadline is how long to let a search run before stopping it, or zero to
+// let it run until stopped
+func (limits Limits) deadline(turn chess.PieceColor) time.Duration {
+	retVal := limits.Deadline
+	if retVal == 0 && !limits.Infinite {
+		remaining, increment := limits.WTime, limits.WInc
+		if turn == chess.PieceColor('b') {
+			remaining, increment = limits.BTime, limits.BInc
+		}
+		switch {
+		case limits.MoveTime > 0:
+			retVal = limits.MoveTime
+		case remaining > 0:
+			retVal = remaining + increment
+		default:
+			retVal = DefaultSearchTime
+		}
+	}
+	return retVal
+}
+
+func millis(duration time.Duration) string {
+	return strconv.FormatInt(int64(duration/time.Millisecond), 10)
+}
+
+func parseOption(line string) Option {
+	var retVal Option
+	keywords := map[string]bool{"name": true, "type": true, "default": true, "min": true, "max": true, "var": true}
+
+	fields := strings.Fields(line)
+	for cntr := 1; cntr < len(fields); {
+		keyword := fields[cntr]
+		cntr++
+		var value []string
+		for cntr < len(fields) && !keywords[fields[cntr]] {
+			value = append(value, fields[cntr])
+			cntr++
+		}
+		joined := strings.Join(value, " ")
+		switch keyword {
+		case "name":
+			retVal.Name = joined
+		case "type":
+			retVal.Type = joined
+		case "default":
+			retVal.Default = joined
+		case "min":
+			retVal.Min, _ = strconv.Atoi(joined)
+		case "max":
+			retVal.Max, _ = strconv.Atoi(joined)
+		case "var":
+			retVal.Vars = append(retVal.Vars, joined)
+		}
+	}
+	return retVal
+}
+
+func copyGame(game *chess.Chess) *chess.Chess {
+	retVal := chess.New()
+	retVal.Load(game.InitialFen())
+	for _, move := range game.History() {
+		retVal.MakeMove(move)
+	}
+	return retVal
+}
+
+// toSAN converts UCI moves to SAN by playing them on game, then takes them
+// back. Conversion stops at the first move that isn't legal.
+func toSAN(game *chess.Chess, uciMoves []string) []string {
+	var retVal []string
+	for _, uciMove := range uciMoves {
+		move, err := game.UCIToMove(uciMove)
+		if err != nil {
+			break
+		}
+		retVal = append(retVal, game.SAN(move))
+		game.MakeMove(move)
+	}
+	for range retVal {
+		game.Undo()
+	}
+	return retVal
+}
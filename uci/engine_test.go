@@ -0,0 +1,242 @@
+package uci
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rkitts/chess"
+)
+
+// TestMain lets the test binary double as a scripted fake engine when
+// FAKE_UCI_ENGINE is set, so no real engine is needed to run the tests.
+func TestMain(m *testing.M) {
+	if mode := os.Getenv("FAKE_UCI_ENGINE"); mode != "" {
+		fakeEngine(mode)
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+func fakeEngine(mode string) {
+	scanner := bufio.NewScanner(os.Stdin)
+	position := ""
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "uci":
+			if mode == "silent" {
+				continue
+			}
+			fmt.Println("id name Fake 1.0")
+			fmt.Println("id author Nobody")
+			fmt.Println("option name Hash type spin default 16 min 1 max 1024")
+			fmt.Println("option name Skill Level type combo default Normal var Easy var Normal")
+			fmt.Println("uciok")
+		case line == "isready":
+			fmt.Println("readyok")
+		case strings.HasPrefix(line, "position"):
+			position = line
+		case strings.HasPrefix(line, "go"):
+			if mode == "crash" {
+				os.Exit(3)
+			}
+			if mode == "hang" || mode == "deaf" {
+				continue
+			}
+			fmt.Println("info string " + position)
+			fmt.Println("info depth 1 seldepth 2 multipv 1 score cp 20 nodes 30 nps 3000 time 10 pv e2e4")
+			fmt.Println("info depth 2 seldepth 3 multipv 1 score cp 35 lowerbound nodes 90 nps 9000 time 10 pv e2e4 e7e5 g1f3")
+			fmt.Println("info depth 2 multipv 2 score mate -3 pv d2d4")
+			fmt.Println("bestmove e2e4 ponder e7e5")
+		case line == "stop":
+			if mode == "deaf" {
+				continue
+			}
+			fmt.Println("bestmove d2d4")
+		case line == "quit":
+			return
+		}
+	}
+}
+
+func startFake(t *testing.T, mode string) *Engine {
+	cmd := exec.Command(os.Args[0])
+	cmd.Env = append(os.Environ(), "FAKE_UCI_ENGINE="+mode)
+	engine, err := StartCommand(cmd, 2*time.Second)
+	if err != nil {
+		t.Fatalf("Could not start fake engine: %v", err)
+	}
+	return engine
+}
+
+func TestHandshakeReadsIDAndOptions(t *testing.T) {
+	engine := startFake(t, "normal")
+	defer engine.Quit()
+
+	if engine.Name != "Fake 1.0" || engine.Author != "Nobody" {
+		t.Errorf("Expected Fake 1.0 by Nobody, got %s by %s", engine.Name, engine.Author)
+	}
+	if engine.Options["Hash"].Max != 1024 {
+		t.Errorf("Expected Hash max of 1024, got %v", engine.Options["Hash"])
+	}
+	if len(engine.Options["Skill Level"].Vars) != 2 {
+		t.Errorf("Expected two vars for Skill Level, got %v", engine.Options["Skill Level"])
+	}
+	if err := engine.SetOption("Hash", "32"); err != nil {
+		t.Errorf("Got an error %v", err)
+	}
+}
+
+func TestGoReturnsBestMoveAndLines(t *testing.T) {
+	engine := startFake(t, "normal")
+	defer engine.Quit()
+
+	game := chess.New()
+	var infos []Info
+	result, err := engine.Go(game, Limits{Depth: 2}, func(info Info) {
+		infos = append(infos, info)
+	})
+	if err != nil {
+		t.Fatalf("Got an error %v", err)
+	}
+	if result.BestMove != "e2e4" || result.Ponder != "e7e5" || result.BestMoveSAN != "e4" {
+		t.Errorf("Unexpected result %v", result)
+	}
+	if len(infos) != 3 {
+		t.Errorf("Expected 3 infos, got %d", len(infos))
+	}
+	if len(result.Lines) != 2 {
+		t.Fatalf("Expected 2 lines, got %d", len(result.Lines))
+	}
+	first := result.Lines[0]
+	if first.Score.CP != 35 || !first.Score.LowerBound || first.Depth != 2 {
+		t.Errorf("Unexpected first line %v", first)
+	}
+	if strings.Join(first.PVSAN, " ") != "e4 e5 Nf3" {
+		t.Errorf("Expected e4 e5 Nf3, got %v", first.PVSAN)
+	}
+	if !result.Lines[1].Score.IsMate || result.Lines[1].Score.Mate != -3 {
+		t.Errorf("Expected mate in -3, got %v", result.Lines[1].Score)
+	}
+	if len(game.History()) != 0 {
+		t.Errorf("Converting the pv changed the game")
+	}
+}
+
+func TestPositionCommand(t *testing.T) {
+	game := chess.New()
+	if PositionCommand(game) != "position startpos" {
+		t.Errorf("Got %s", PositionCommand(game))
+	}
+	game.Move("e4")
+	game.Move("c5")
+	if PositionCommand(game) != "position startpos moves e2e4 c7c5" {
+		t.Errorf("Got %s", PositionCommand(game))
+	}
+
+	game.Load("4k3/8/8/8/8/8/4P3/4K3 w - - 0 1")
+	game.Move("e4")
+	expected := "position fen 4k3/8/8/8/8/8/4P3/4K3 w - - 0 1 moves e2e4"
+	if PositionCommand(game) != expected {
+		t.Errorf("Expected %s, got %s", expected, PositionCommand(game))
+	}
+}
+
+func TestGoCommand(t *testing.T) {
+	limits := Limits{Depth: 5, WTime: time.Minute, BTime: 30 * time.Second, WInc: time.Second, MovesToGo: 20}
+	expected := "go depth 5 wtime 60000 btime 30000 winc 1000 movestogo 20"
+	if goCommand(limits) != expected {
+		t.Errorf("Expected %s, got %s", expected, goCommand(limits))
+	}
+}
+
+func TestHandshakeTimesOut(t *testing.T) {
+	cmd := exec.Command(os.Args[0])
+	cmd.Env = append(os.Environ(), "FAKE_UCI_ENGINE=silent")
+	start := time.Now()
+	_, err := StartCommand(cmd, 100*time.Millisecond)
+	if !errors.Is(err, ErrTimeout) {
+		t.Errorf("Expected a timeout, got %v", err)
+	}
+	if time.Since(start) > 2*time.Second {
+		t.Errorf("Timeout took too long")
+	}
+}
+
+func TestGoReportsCrash(t *testing.T) {
+	engine := startFake(t, "crash")
+	_, err := engine.Go(chess.New(), Limits{Depth: 1}, nil)
+	if !errors.Is(err, ErrEngineExited) {
+		t.Errorf("Expected the engine to have exited, got %v", err)
+	}
+}
+
+func TestGoStopsAtDeadline(t *testing.T) {
+	engine := startFake(t, "hang")
+	defer engine.Quit()
+
+	result, err := engine.Go(chess.New(), Limits{Infinite: true, Deadline: 50 * time.Millisecond}, nil)
+	if err != nil {
+		t.Fatalf("Got an error %v", err)
+	}
+	if result.BestMove != "d2d4" {
+		t.Errorf("Expected d2d4 after stop, got %s", result.BestMove)
+	}
+}
+
+func TestGoStopsAtMoveTimeWithoutADeadline(t *testing.T) {
+	engine := startFake(t, "hang")
+	defer engine.Quit()
+
+	result, err := engine.Go(chess.New(), Limits{MoveTime: 50 * time.Millisecond}, nil)
+	if err != nil {
+		t.Fatalf("Got an error %v", err)
+	}
+	if result.BestMove != "d2d4" {
+		t.Errorf("Expected d2d4 after stop, got %s", result.BestMove)
+	}
+}
+
+func TestGoGivesUpOnAHungEngine(t *testing.T) {
+	engine := startFake(t, "deaf")
+	defer engine.Kill()
+	engine.Timeout = 50 * time.Millisecond
+
+	game := chess.New()
+	game.Move("e4")
+	start := time.Now()
+	_, err := engine.Go(game, Limits{WTime: time.Minute, BTime: 50 * time.Millisecond, BInc: 50 * time.Millisecond}, nil)
+	if err != ErrTimeout {
+		t.Errorf("Expected a timeout, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected to give up after about 150ms, took %v", elapsed)
+	}
+}
+
+func TestDeadlineFromLimits(t *testing.T) {
+	tests := []struct {
+		limits   Limits
+		turn     chess.PieceColor
+		expected time.Duration
+	}{
+		{Limits{Deadline: time.Second, MoveTime: time.Minute}, 'w', time.Second},
+		{Limits{MoveTime: 2 * time.Second, WTime: time.Minute}, 'w', 2 * time.Second},
+		{Limits{WTime: time.Minute, WInc: time.Second, BTime: time.Hour}, 'w', 61 * time.Second},
+		{Limits{WTime: time.Minute, BTime: 2 * time.Minute, BInc: time.Second}, 'b', 121 * time.Second},
+		{Limits{Depth: 20}, 'w', DefaultSearchTime},
+		{Limits{}, 'b', DefaultSearchTime},
+		{Limits{Infinite: true}, 'w', 0},
+	}
+	for _, test := range tests {
+		if actual := test.limits.deadline(test.turn); actual != test.expected {
+			t.Errorf("Expected %v for %+v, got %v", test.expected, test.limits, actual)
+		}
+	}
+}
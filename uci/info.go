@@ -0,0 +1,108 @@
+package uci
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Score is the evaluation reported by an engine, either in centipawns or as
+// a number of moves to mate. Negative mates mean the side to move is getting
+// mated.
+type Score struct {
+	CP         int
+	Mate       int
+	IsMate     bool
+	LowerBound bool
+	UpperBound bool
+}
+
+// Info holds the fields of a single "info" line sent by the engine while searching
+type Info struct {
+	Depth    int
+	SelDepth int
+	MultiPV  int
+	Score    Score
+	HasScore bool
+	Nodes    int64
+	NPS      int64
+	Time     time.Duration
+	PV       []string
+	PVSAN    []string
+	String   string
+}
+
+// parseInfo parses the fields of an info line. Unknown tokens are skipped so
+// newer engines don't break the parse.
+func parseInfo(line string) Info {
+	var retVal Info
+	fields := strings.Fields(line)
+
+	for cntr := 1; cntr < len(fields); cntr++ {
+		switch fields[cntr] {
+		case "depth":
+			cntr++
+			retVal.Depth = atoi(fields, cntr)
+		case "seldepth":
+			cntr++
+			retVal.SelDepth = atoi(fields, cntr)
+		case "multipv":
+			cntr++
+			retVal.MultiPV = atoi(fields, cntr)
+		case "nodes":
+			cntr++
+			retVal.Nodes = int64(atoi(fields, cntr))
+		case "nps":
+			cntr++
+			retVal.NPS = int64(atoi(fields, cntr))
+		case "time":
+			cntr++
+			retVal.Time = time.Duration(atoi(fields, cntr)) * time.Millisecond
+		case "score":
+			retVal.HasScore = true
+		case "cp":
+			cntr++
+			retVal.Score.CP = atoi(fields, cntr)
+		case "mate":
+			cntr++
+			retVal.Score.Mate = atoi(fields, cntr)
+			retVal.Score.IsMate = true
+		case "lowerbound":
+			retVal.Score.LowerBound = true
+		case "upperbound":
+			retVal.Score.UpperBound = true
+		case "pv":
+			// pv runs to the end of the line
+			retVal.PV = append([]string{}, fields[cntr+1:]...)
+			cntr = len(fields)
+		case "string":
+			retVal.String = strings.Join(fields[cntr+1:], " ")
+			cntr = len(fields)
+		}
+	}
+	if retVal.MultiPV == 0 {
+		retVal.MultiPV = 1
+	}
+	return retVal
+}
+
+// parseBestMove returns the best move and ponder move, if any, from a bestmove line
+func parseBestMove(line string) (string, string) {
+	var bestMove, ponder string
+	fields := strings.Fields(line)
+	if len(fields) > 1 {
+		bestMove = fields[1]
+	}
+	if len(fields) > 3 && fields[2] == "ponder" {
+		ponder = fields[3]
+	}
+	return bestMove, ponder
+}
+
+func atoi(fields []string, index int) int {
+	var retVal int
+	if index < len(fields) {
+		retVal, _ = strconv.Atoi(fields[index])
+	}
+	return retVal
+}
@@ -0,0 +1,40 @@
+package uci
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseInfo(t *testing.T) {
+	info := parseInfo("info depth 12 seldepth 18 multipv 2 score mate 4 upperbound nodes 12345 nps 67890 time 250 pv e2e4 e7e5")
+	if info.Depth != 12 || info.SelDepth != 18 || info.MultiPV != 2 {
+		t.Errorf("Depths wrong in %v", info)
+	}
+	if !info.HasScore || !info.Score.IsMate || info.Score.Mate != 4 || !info.Score.UpperBound {
+		t.Errorf("Score wrong in %v", info.Score)
+	}
+	if info.Nodes != 12345 || info.NPS != 67890 || info.Time != 250*time.Millisecond {
+		t.Errorf("Counters wrong in %v", info)
+	}
+	if len(info.PV) != 2 || info.PV[1] != "e7e5" {
+		t.Errorf("PV wrong in %v", info.PV)
+	}
+}
+
+func TestParseInfoDefaultsMultiPVAndSkipsUnknown(t *testing.T) {
+	info := parseInfo("info depth 3 hashfull 12 tbhits 0 score cp -15")
+	if info.MultiPV != 1 || info.Depth != 3 || info.Score.CP != -15 {
+		t.Errorf("Unexpected info %v", info)
+	}
+}
+
+func TestParseBestMove(t *testing.T) {
+	best, ponder := parseBestMove("bestmove g1f3 ponder d7d5")
+	if best != "g1f3" || ponder != "d7d5" {
+		t.Errorf("Expected g1f3 and d7d5, got %s and %s", best, ponder)
+	}
+	best, ponder = parseBestMove("bestmove (none)")
+	if best != "(none)" || ponder != "" {
+		t.Errorf("Expected (none) and no ponder, got %s and %s", best, ponder)
+	}
+}
@@ -0,0 +1,73 @@
+package chess
+
+// Zobrist keys, indexed by color, the piece's shift and 0x88 square
+var zobristPieces [2][6][128]uint64
+var zobristCastling [2][4]uint64
+var zobristEnpassant [8]uint64
+var zobristBlackToMove uint64
+
+func init() {
+	// splitmix64 with a fixed seed so hashes are the same from run to run
+	seed := uint64(0x5eed0fc4e55)
+	next := func() uint64 {
+		seed += 0x9e3779b97f4a7c15
+		z := seed
+		z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+		z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+		return z ^ (z >> 31)
+	}
+	for color := 0; color < 2; color++ {
+		for ptype := 0; ptype < 6; ptype++ {
+			for square := 0; square < 128; square++ {
+				zobristPieces[color][ptype][square] = next()
+			}
+		}
+		for castling := 0; castling < 4; castling++ {
+			zobristCastling[color][castling] = next()
+		}
+	}
+	for cntr := range zobristEnpassant {
+		zobristEnpassant[cntr] = next()
+	}
+	zobristBlackToMove = next()
+}
+
+// hash returns the Zobrist hash of the position. Two positions with the same
+// pieces, side to move, castling rights and en passant square hash the same,
+// whatever the move counters. The en passant square only counts if a pawn
+// can capture there, so move orders that transpose hash the same.
+func (chess *Chess) hash() uint64 {
+	var retVal uint64
+
+	for cntr := squareNameToID["a8"]; cntr <= squareNameToID["h1"]; cntr++ {
+		if cntr&0x88 != 0 {
+			cntr += 7
+			continue
+		}
+		piece := chess.board[cntr]
+		if !piece.IsUnspecified() {
+			retVal ^= zobristPieces[colorIndex(piece.pcolor)][shifts[piece.ptype]][cntr]
+		}
+	}
+	retVal ^= zobristCastling[0][castlingIndex(chess.castling[white])]
+	retVal ^= zobristCastling[1][castlingIndex(chess.castling[black])]
+	if chess.enpassantCapturePossible() {
+		retVal ^= zobristEnpassant[file(chess.enpassantSquare)]
+	}
+	if chess.turn == black {
+		retVal ^= zobristBlackToMove
+	}
+	return retVal
+}
+
+func colorIndex(color PieceColor) int {
+	retVal := 0
+	if color == black {
+		retVal = 1
+	}
+	return retVal
+}
+
+func castlingIndex(castling int) int {
+	return (castling & (ksideCastleMove | qsideCastleMove)) >> 5
+}